@@ -0,0 +1,203 @@
+package api
+
+import (
+	"context"
+
+	"github.com/go-msvc/errors"
+	"github.com/jansemmelink/money/bank"
+)
+
+//AccountFilter lists accounts one page at a time, either from an offset
+//or after the cursor returned with the previous page
+type AccountFilter struct {
+	Name   string `json:"name"`
+	Type   string `json:"type"`
+	Under  string `json:"under"` //account id to list with its descendants
+	Sort   string `json:"sort"`  //path (default), name or type, with "-" prefix for descending order
+	Offset int    `json:"offset"`
+	Cursor string `json:"cursor"`
+	Limit  int    `json:"limit"` //page size, default 100
+}
+
+func (req *AccountFilter) Validate() error {
+	if req.Limit <= 0 {
+		req.Limit = defaultPageSize
+	}
+	if req.Limit > maxPageSize {
+		return fieldErrorf("limit", "limit %d > %d", req.Limit, maxPageSize)
+	}
+	if req.Offset < 0 {
+		return fieldErrorf("offset", "negative")
+	}
+	if _, _, err := bank.ParseAccountSort(req.Sort); err != nil {
+		return fieldErrorf("sort", "%s", err)
+	}
+	if req.Cursor != "" {
+		if _, _, err := bank.ParseAccountCursor(req.Cursor); err != nil {
+			return fieldErrorf("cursor", "%s", err)
+		}
+	}
+	return nil
+}
+
+//AccountPage is one page of accounts, with the cursor for the next page,
+//which is "" on the last page
+type AccountPage struct {
+	Accounts []bank.Account `json:"accounts"`
+	Next     string         `json:"next,omitempty"`
+}
+
+func getAccounts(ctx context.Context, req AccountFilter) (*AccountPage, error) {
+	//one more than the page tells if there is a next page
+	accList, err := bank.GetAccounts(bank.AccountFilter{
+		Name:    req.Name,
+		Type:    req.Type,
+		UnderID: req.Under,
+		Sort:    req.Sort,
+		Offset:  req.Offset,
+		After:   req.Cursor,
+		Limit:   req.Limit + 1,
+	})
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to get accounts")
+	}
+	page := AccountPage{Accounts: accList}
+	if len(accList) > req.Limit {
+		page.Accounts = accList[:req.Limit]
+		page.Next = bank.AccountCursor(accList[req.Limit-1], req.Sort)
+	}
+	return &page, nil
+}
+
+type AccountRequest struct {
+	ID string `json:"id"`
+}
+
+func (req AccountRequest) Validate() error {
+	if req.ID == "" {
+		return fieldErrorf("id", "missing")
+	}
+	return nil
+}
+
+func getAccount(ctx context.Context, req AccountRequest) (*bank.Account, error) {
+	acc, err := bank.GetAccount(req.ID)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to get account")
+	}
+	if acc == nil {
+		return nil, notFoundf("account(%s) not found", req.ID)
+	}
+	return acc, nil
+}
+
+//SaveAccountRequest creates an account, or updates account ID.
+//Accounts with a parent inherit its type.
+type SaveAccountRequest struct {
+	ID       string `json:"id"`
+	Name     string `json:"name"`
+	Type     string `json:"type"` //asset, liability, equity, income or expense
+	ParentID string `json:"parent_id"`
+}
+
+func (req SaveAccountRequest) Validate() error {
+	if req.Name == "" {
+		return fieldErrorf("name", "missing")
+	}
+	if req.Type != "" {
+		if _, err := bank.ParseAccountType(req.Type); err != nil {
+			return fieldErrorf("type", "%s", err)
+		}
+	}
+	return nil
+}
+
+func createAccount(ctx context.Context, req SaveAccountRequest) (*bank.Account, error) {
+	if req.Type == "" && req.ParentID == "" {
+		return nil, fieldErrorf("type", "missing type or parent_id")
+	}
+	if err := checkParent(req.ParentID); err != nil {
+		return nil, err
+	}
+	acc := bank.Account{Name: req.Name, ParentID: req.ParentID}
+	acc.Type, _ = bank.ParseAccountType(req.Type)
+	if err := acc.Save(); err != nil {
+		return nil, errors.Wrapf(err, "failed to create account")
+	}
+	return &acc, nil
+}
+
+func updateAccount(ctx context.Context, req SaveAccountRequest) (*bank.Account, error) {
+	acc, err := getAccount(ctx, AccountRequest{ID: req.ID})
+	if err != nil {
+		return nil, err
+	}
+	if err := checkParent(req.ParentID); err != nil {
+		return nil, err
+	}
+	acc.Name = req.Name
+	acc.ParentID = req.ParentID
+	if req.Type != "" {
+		acc.Type, _ = bank.ParseAccountType(req.Type)
+	} else if acc.ParentID != "" {
+		acc.Type = "" //inherit from the parent
+	}
+	if err := acc.Save(); err != nil {
+		return nil, errors.Wrapf(err, "failed to update account")
+	}
+	return acc, nil
+}
+
+//checkParent fails when parentID is not "" and not an existing account
+func checkParent(parentID string) error {
+	if parentID == "" {
+		return nil
+	}
+	parent, err := bank.GetAccount(parentID)
+	if err != nil {
+		return errors.Wrapf(err, "failed to get parent account")
+	}
+	if parent == nil {
+		return fieldErrorf("parent_id", "account(%s) not found", parentID)
+	}
+	return nil
+}
+
+func deleteAccount(ctx context.Context, req AccountRequest) (interface{}, error) {
+	if err := bank.DeleteAccount(req.ID); err != nil {
+		return nil, errors.Wrapf(err, "failed to delete account")
+	}
+	return nil, nil
+}
+
+//MergeRequest merges account ID into account To
+type MergeRequest struct {
+	ID string `json:"id"`
+	To string `json:"to"`
+}
+
+func (req MergeRequest) Validate() error {
+	if req.ID == "" {
+		return fieldErrorf("id", "missing")
+	}
+	if req.To == "" {
+		return fieldErrorf("to", "missing")
+	}
+	return nil
+}
+
+func previewMerge(ctx context.Context, req MergeRequest) (*bank.MergePreview, error) {
+	preview, err := bank.PreviewMerge(req.ID, req.To)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to preview merge")
+	}
+	return preview, nil
+}
+
+func mergeAccount(ctx context.Context, req MergeRequest) (*bank.MergePreview, error) {
+	merged, err := bank.MergeAccounts(req.ID, req.To)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to merge accounts")
+	}
+	return merged, nil
+}
@@ -0,0 +1,72 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/jansemmelink/money/bank"
+)
+
+func TestAccountFilter(t *testing.T) {
+	for _, c := range []struct {
+		query string
+		field string
+	}{
+		{"sort=balance", "sort"},
+		{"sort=-size", "sort"},
+		{"cursor=x", "cursor"},
+		{"cursor=%21%21", "cursor"},
+		{"offset=-1", "offset"},
+		{"limit=1001", "limit"},
+	} {
+		expectError(t, serve(t, http.MethodGet, "/accounts?"+c.query, ""), http.StatusUnprocessableEntity, c.field)
+	}
+
+	//cursor of the last account on a page is accepted for the next page
+	req := AccountFilter{Sort: "-name", Cursor: bank.AccountCursor(bank.Account{ID: "1", Name: "Diesel"}, "-name")}
+	if err := req.Validate(); err != nil {
+		t.Fatalf("next page: %+v", err)
+	}
+	if req.Limit != defaultPageSize {
+		t.Fatalf("limit %d", req.Limit)
+	}
+}
+
+func TestSaveAccountErrors(t *testing.T) {
+	for _, c := range []struct {
+		body  string
+		field string
+	}{
+		{`{}`, "name"},
+		{`{"name":"Diesel","type":"car"}`, "type"},
+		{`{"name":"Diesel"}`, "type"},
+		{`{"name":"Car:Diesel","type":"expense"}`, "name"},
+	} {
+		expectError(t, serve(t, http.MethodPost, "/accounts", c.body), http.StatusUnprocessableEntity, c.field)
+	}
+}
+
+func TestAccountJSON(t *testing.T) {
+	page := AccountPage{Accounts: []bank.Account{
+		{ID: "1", Name: "Car", Type: bank.AccountTypeExpense, Path: "Car"},
+		{ID: "2", Name: "Diesel", Type: bank.AccountTypeExpense, ParentID: "1", Path: "Car:Diesel"},
+	}}
+	body, err := json.Marshal(page)
+	if err != nil {
+		t.Fatalf("marshal: %+v", err)
+	}
+	var decoded struct {
+		Accounts []map[string]string `json:"accounts"`
+	}
+	if err := json.Unmarshal(body, &decoded); err != nil || len(decoded.Accounts) != 2 {
+		t.Fatalf("decoded %s: %v", body, err)
+	}
+	diesel := decoded.Accounts[1]
+	if diesel["id"] != "2" || diesel["name"] != "Diesel" || diesel["type"] != "expense" || diesel["parent_id"] != "1" || diesel["path"] != "Car:Diesel" {
+		t.Errorf("account %s", body)
+	}
+	if _, ok := decoded.Accounts[0]["parent_id"]; ok {
+		t.Errorf("root account with parent_id: %s", body)
+	}
+}
@@ -0,0 +1,233 @@
+//Package api serves the money database over HTTP
+package api
+
+import (
+	"context"
+	_ "embed"
+	"encoding/json"
+	"io"
+	"net/http"
+	"reflect"
+
+	"github.com/go-msvc/errors"
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	"github.com/jansemmelink/money/dot"
+	"github.com/stewelarend/logger"
+)
+
+var log = logger.New().WithLevel(logger.LevelDebug)
+
+//go:embed docs.html
+var docsPage []byte
+
+//Serve runs the HTTP server until it fails
+func Serve(addr string) error {
+	mux, err := newRouter()
+	if err != nil {
+		return err
+	}
+	log.Infof("Serving on %s", addr)
+	if err := http.ListenAndServe(addr, mux); err != nil {
+		return errors.Wrapf(err, "HTTP server failed on addr(%s)", addr)
+	}
+	return nil
+}
+
+//newRouter registers the api routes with the OpenAPI document and docs page describing them
+func newRouter() (*mux.Router, error) {
+	mux := mux.NewRouter()
+	mux.Handle("/accounts", hdlr(getAccounts)).Methods(http.MethodGet)
+	mux.Handle("/accounts", hdlr(createAccount)).Methods(http.MethodPost)
+	mux.Handle("/accounts/{id}", hdlr(getAccount)).Methods(http.MethodGet)
+	mux.Handle("/accounts/{id}", hdlr(updateAccount)).Methods(http.MethodPut)
+	mux.Handle("/accounts/{id}", hdlr(deleteAccount)).Methods(http.MethodDelete)
+	mux.Handle("/accounts/{id}/merge", hdlr(previewMerge)).Methods(http.MethodGet)
+	mux.Handle("/accounts/{id}/merge", hdlr(mergeAccount)).Methods(http.MethodPost)
+	mux.Handle("/accounts/{id}/balance", hdlr(getAccountBalance)).Methods(http.MethodGet)
+	mux.Handle("/accounts/{id}/ledger", hdlr(getAccountLedger)).Methods(http.MethodGet)
+	mux.Handle("/budgets/{month}", hdlr(getBudgetReport)).Methods(http.MethodGet)
+	mux.Handle("/budgets/{month}", hdlr(setBudget)).Methods(http.MethodPost)
+	mux.Handle("/budgets/{month}/copy", hdlr(copyBudgets)).Methods(http.MethodPost)
+	mux.Handle("/envelopes", hdlr(getEnvelopeReport)).Methods(http.MethodGet)
+	mux.Handle("/envelopes/moves", hdlr(moveToEnvelope)).Methods(http.MethodPost)
+	mux.Handle("/reports/trial-balance", hdlr(getTrialBalance)).Methods(http.MethodGet)
+	mux.Handle("/reports/income", hdlr(getIncomeStatement)).Methods(http.MethodGet)
+	mux.HandleFunc("/statements", uploadStatement).Methods(http.MethodPost)
+	mux.Handle("/statements/uploads/{id}/confirm", hdlr(confirmImport)).Methods(http.MethodPost)
+	mux.Handle("/tags", hdlr(getTags)).Methods(http.MethodGet)
+	mux.Handle("/transactions", hdlr(getTransactions)).Methods(http.MethodGet)
+	mux.Handle("/transactions/{id}", hdlr(getTransaction)).Methods(http.MethodGet)
+	mux.Handle("/transactions/{id}", hdlr(updateTransaction)).Methods(http.MethodPatch)
+	mux.Handle("/transactions/{id}/notes", hdlr(setTransactionNotes)).Methods(http.MethodPut)
+	mux.Handle("/transactions/{id}/tags", hdlr(tagTransaction)).Methods(http.MethodPost)
+	mux.Handle("/transactions/{id}/tags/{tag}", hdlr(untagTransaction)).Methods(http.MethodDelete)
+	mux.Handle("/transactions/{id}/suggestions", hdlr(getTransactionSuggestions)).Methods(http.MethodGet)
+	mux.Handle("/transactions/{id}/documents", hdlr(getTransactionDocuments)).Methods(http.MethodGet)
+	mux.HandleFunc("/transactions/{id}/documents", uploadDocuments).Methods(http.MethodPost)
+	mux.Handle("/transactions/{id}/documents/{document_id}", hdlr(unlinkDocument)).Methods(http.MethodDelete)
+	mux.HandleFunc("/documents/{id}", downloadDocument).Methods(http.MethodGet)
+
+	//describe the routes above
+	doc, err := newOpenAPIDoc(mux)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to generate OpenAPI document")
+	}
+	mux.HandleFunc("/openapi.json", func(httpRes http.ResponseWriter, httpReq *http.Request) {
+		if err := writeJSON(httpRes, doc); err != nil {
+			writeError(httpRes, uuid.New().String(), err)
+		}
+	}).Methods(http.MethodGet)
+	mux.HandleFunc("/docs", func(httpRes http.ResponseWriter, httpReq *http.Request) {
+		httpRes.Header().Set("Content-Type", "text/html; charset=utf-8")
+		httpRes.Write(docsPage)
+	}).Methods(http.MethodGet)
+	return mux, nil
+} //newRouter()
+
+//handler calls a func(ctx[, req]) (res, error) with req decoded from the
+//JSON body, URL query and path params, and res encoded as JSON
+type handler struct {
+	funcValue reflect.Value
+	reqType   reflect.Type //nil when the func only takes ctx
+	resType   reflect.Type
+}
+
+type CtxUuid struct{}
+
+func hdlr(f interface{}) *handler {
+	funcType := reflect.TypeOf(f)
+	if funcType.Kind() != reflect.Func {
+		panic("handler is not a function")
+	}
+	if funcType.NumOut() != 2 {
+		panic("handler does not return (res, error)")
+	}
+
+	h := &handler{
+		funcValue: reflect.ValueOf(f),
+		resType:   funcType.Out(0),
+	}
+	if funcType.NumIn() == 2 {
+		h.reqType = funcType.In(1)
+	}
+	return h
+}
+
+func (h *handler) ServeHTTP(httpRes http.ResponseWriter, httpReq *http.Request) {
+	ctx := context.Background()
+
+	uuid := uuid.New().String()
+	ctx = context.WithValue(ctx, CtxUuid{}, uuid)
+
+	var err error
+	var res interface{}
+	defer func() {
+		if err != nil {
+			writeError(httpRes, uuid, err)
+			return
+		}
+
+	}()
+
+	args := []reflect.Value{
+		reflect.ValueOf(ctx),
+	}
+
+	if h.reqType != nil {
+		reqPtrValue := reflect.New(h.reqType)
+		//parse body into request
+		if err = json.NewDecoder(httpReq.Body).Decode(reqPtrValue.Interface()); err != nil && err != io.EOF {
+			err = decodeError(err)
+			return
+		}
+		//parse URL params into request (over writing body if duplicate)
+		for n, v := range httpReq.URL.Query() {
+			if err = dot.Set(reqPtrValue.Interface(), n, v); err != nil {
+				err = paramError("query", n, err)
+				return
+			}
+		}
+		//apply URL path params
+		for n, v := range mux.Vars(httpReq) {
+			if err = dot.Set(reqPtrValue.Interface(), n, v); err != nil {
+				err = paramError("path", n, err)
+				return
+			}
+		}
+
+		//validate the request
+		if validator, ok := reqPtrValue.Interface().(Validator); ok {
+			if err = validator.Validate(); err != nil {
+				err = invalidRequest(err)
+				return
+			}
+			log.Debugf("Validated (%T)%+v", reqPtrValue.Interface(), reqPtrValue.Elem().Interface())
+		} else {
+			log.Debugf("NOT Validated (%T)%+v", reqPtrValue.Interface(), reqPtrValue.Elem().Interface())
+		}
+
+		args = append(args, reflect.ValueOf(reqPtrValue.Elem().Interface()))
+	}
+
+	results := h.funcValue.Call(args)
+	if results[1].Interface() != nil {
+		err = results[1].Interface().(error)
+		if err != nil {
+			err = errors.Wrapf(err, "handler failed")
+			return
+		}
+	}
+
+	res = results[0].Interface()
+	if res != nil {
+		err = writeJSON(httpRes, res)
+	}
+} //handler.ServeHTTP()
+
+func writeJSON(httpRes http.ResponseWriter, res interface{}) error {
+	jsonRes, err := json.Marshal(res)
+	if err != nil {
+		return errors.Wrapf(err, "cannot encode response")
+	}
+	httpRes.Header().Set("Content-Type", "application/json")
+	httpRes.Write(jsonRes)
+	return nil
+}
+
+type Validator interface {
+	Validate() error
+}
+
+//decodeError is a 400 for a request body that is not valid JSON
+//or does not fit the request fields
+func decodeError(err error) error {
+	if typeErr, ok := err.(*json.UnmarshalTypeError); ok && typeErr.Field != "" {
+		return Error{
+			Status:  http.StatusBadRequest,
+			Code:    CodeBadRequest,
+			Message: "invalid JSON request body",
+			Fields:  []FieldError{{Field: typeErr.Field, Message: "expects " + typeErr.Type.String()}},
+		}
+	}
+	return badRequestf("invalid JSON request body: %s", err)
+}
+
+//paramError is a 400 for a URL query or path param that does not fit the request field
+func paramError(kind string, name string, err error) error {
+	return Error{
+		Status:  http.StatusBadRequest,
+		Code:    CodeBadRequest,
+		Message: "invalid URL " + kind + " param",
+		Fields:  []FieldError{{Field: name, Message: err.Error()}},
+	}
+}
+
+//invalidRequest is a 422 for a request that failed validation,
+//unless the error already determines the response
+func invalidRequest(err error) error {
+	if e := toError(err); e.Status != http.StatusInternalServerError {
+		return e
+	}
+	return Error{Status: http.StatusUnprocessableEntity, Code: CodeInvalid, Message: err.Error()}
+}
@@ -0,0 +1,47 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+//serve sends the request to the api router, requests that fail before
+//calling the bank do not need a database
+func serve(t *testing.T, method string, target string, body string) *httptest.ResponseRecorder {
+	router, err := newRouter()
+	if err != nil {
+		t.Fatalf("router: %+v", err)
+	}
+	httpRes := httptest.NewRecorder()
+	router.ServeHTTP(httpRes, httptest.NewRequest(method, target, strings.NewReader(body)))
+	return httpRes
+}
+
+//expectError checks the status and the field in the error response
+func expectError(t *testing.T, httpRes *httptest.ResponseRecorder, status int, field string) {
+	t.Helper()
+	if httpRes.Code != status {
+		t.Errorf("status %d != %d: %s", httpRes.Code, status, httpRes.Body)
+		return
+	}
+	var e Error
+	if err := json.Unmarshal(httpRes.Body.Bytes(), &e); err != nil {
+		t.Errorf("invalid error response: %s", httpRes.Body)
+		return
+	}
+	if e.RequestID == "" {
+		t.Errorf("missing request_id: %s", httpRes.Body)
+	}
+	if field != "" && (len(e.Fields) != 1 || e.Fields[0].Field != field) {
+		t.Errorf("fields %+v != %s", e.Fields, field)
+	}
+}
+
+func TestDecodeErrors(t *testing.T) {
+	expectError(t, serve(t, http.MethodPost, "/accounts", "{"), http.StatusBadRequest, "")
+	expectError(t, serve(t, http.MethodPost, "/accounts", `{"name":1}`), http.StatusBadRequest, "name")
+	expectError(t, serve(t, http.MethodGet, "/accounts?limit=ten", ""), http.StatusBadRequest, "limit")
+}
@@ -0,0 +1,82 @@
+package api
+
+import (
+	"context"
+
+	"github.com/go-msvc/errors"
+	"github.com/jansemmelink/money/bank"
+)
+
+type BudgetReportRequest struct {
+	Month string `json:"month"`
+}
+
+func (req BudgetReportRequest) Validate() error {
+	if req.Month == "" {
+		return fieldErrorf("month", "missing")
+	}
+	if _, err := bank.ParseMonth(req.Month); err != nil {
+		return fieldErrorf("month", "%s", err)
+	}
+	return nil
+}
+
+func getBudgetReport(ctx context.Context, req BudgetReportRequest) ([]bank.BudgetLine, error) {
+	lines, err := bank.GetBudgetReport(req.Month)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to get budget report")
+	}
+	return lines, nil
+}
+
+//SetBudgetRequest sets the budget of account_id in month
+type SetBudgetRequest struct {
+	Month     string      `json:"month"`
+	AccountID string      `json:"account_id"`
+	Amount    bank.Amount `json:"amount"`
+	CarryOver bool        `json:"carry_over"`
+}
+
+func (req SetBudgetRequest) Validate() error {
+	if req.AccountID == "" {
+		return fieldErrorf("account_id", "missing")
+	}
+	if _, err := bank.ParseMonth(req.Month); err != nil {
+		return fieldErrorf("month", "%s", err)
+	}
+	return nil
+}
+
+func setBudget(ctx context.Context, req SetBudgetRequest) (*bank.Budget, error) {
+	b := bank.Budget{
+		AccountID: req.AccountID,
+		Month:     req.Month,
+		Amount:    req.Amount,
+		CarryOver: req.CarryOver,
+	}
+	if err := b.Save(); err != nil {
+		return nil, errors.Wrapf(err, "failed to set budget")
+	}
+	return bank.GetBudget(b.AccountID, b.Month)
+}
+
+//CopyBudgetsRequest copies the budgets of the month before into month
+type CopyBudgetsRequest struct {
+	Month     string `json:"month"`
+	Overwrite bool   `json:"overwrite"`
+}
+
+func (req CopyBudgetsRequest) Validate() error {
+	if _, err := bank.ParseMonth(req.Month); err != nil {
+		return fieldErrorf("month", "%s", err)
+	}
+	return nil
+}
+
+func copyBudgets(ctx context.Context, req CopyBudgetsRequest) ([]bank.Budget, error) {
+	copied, err := bank.CopyBudgets(req.Month, req.Overwrite)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to copy budgets")
+	}
+	return copied, nil
+}
@@ -0,0 +1,149 @@
+package api
+
+import (
+	"context"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+
+	"github.com/go-msvc/errors"
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	"github.com/jansemmelink/money/bank"
+)
+
+//max size of a multipart upload kept in memory, larger files use temp files
+const maxUploadMemory = 10 << 20
+
+//max size of a multipart upload request
+const maxUploadSize = 50 << 20
+
+type DocumentsRequest struct {
+	ID string `json:"id"` //transaction id
+}
+
+func (req DocumentsRequest) Validate() error {
+	if req.ID == "" {
+		return fieldErrorf("id", "missing")
+	}
+	return nil
+}
+
+func getTransactionDocuments(ctx context.Context, req DocumentsRequest) ([]bank.Document, error) {
+	list, err := bank.GetDocuments(req.ID)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to get documents")
+	}
+	return list, nil
+}
+
+type UnlinkDocumentRequest struct {
+	ID         string `json:"id"` //transaction id
+	DocumentID string `json:"document_id"`
+}
+
+func (req UnlinkDocumentRequest) Validate() error {
+	if req.ID == "" {
+		return fieldErrorf("id", "missing")
+	}
+	if req.DocumentID == "" {
+		return fieldErrorf("document_id", "missing")
+	}
+	return nil
+}
+
+func unlinkDocument(ctx context.Context, req UnlinkDocumentRequest) (interface{}, error) {
+	if err := bank.UnlinkDocument(req.ID, req.DocumentID); err != nil {
+		return nil, errors.Wrapf(err, "failed to unlink document")
+	}
+	return nil, nil
+}
+
+//uploadDocuments links all files in the multipart form to the transaction,
+//or none of them when one fails, and responds with the list of documents
+func uploadDocuments(httpRes http.ResponseWriter, httpReq *http.Request) {
+	requestID := uuid.New().String()
+	transactionID := mux.Vars(httpReq)["id"]
+	httpReq.Body = http.MaxBytesReader(httpRes, httpReq.Body, maxUploadSize)
+	if err := httpReq.ParseMultipartForm(maxUploadMemory); err != nil {
+		writeError(httpRes, requestID, badRequestf("expects multipart/form-data of at most %d bytes: %s", maxUploadSize, err))
+		return
+	}
+	defer httpReq.MultipartForm.RemoveAll()
+	uploads := []bank.DocumentUpload{}
+	for _, files := range httpReq.MultipartForm.File {
+		for _, fh := range files {
+			f, err := fh.Open()
+			if err != nil {
+				writeError(httpRes, requestID, badRequestf("cannot open %s: %s", fh.Filename, err))
+				return
+			}
+			defer f.Close()
+			uploads = append(uploads, bank.DocumentUpload{Name: fh.Filename, MimeType: fh.Header.Get("Content-Type"), Content: f})
+		}
+	}
+	if len(uploads) == 0 {
+		writeError(httpRes, requestID, badRequestf("no files in form"))
+		return
+	}
+	list, err := bank.AddDocuments(transactionID, uploads)
+	if err != nil {
+		writeError(httpRes, requestID, err)
+		return
+	}
+	if err := writeJSON(httpRes, list); err != nil {
+		writeError(httpRes, requestID, err)
+	}
+}
+
+//mime types served as stored, any other is served as application/octet-stream
+var downloadMimeTypes = map[string]bool{
+	"application/pdf": true,
+	"image/gif":       true,
+	"image/jpeg":      true,
+	"image/png":       true,
+	"image/webp":      true,
+	"text/csv":        true,
+	"text/plain":      true,
+}
+
+//downloadMimeType is the stored mime type when allowed, else application/octet-stream
+func downloadMimeType(stored string) string {
+	if t, _, err := mime.ParseMediaType(stored); err == nil && downloadMimeTypes[t] {
+		return stored
+	}
+	return "application/octet-stream"
+}
+
+//downloadDocument responds with the content of the document as an attachment
+func downloadDocument(httpRes http.ResponseWriter, httpReq *http.Request) {
+	requestID := uuid.New().String()
+	id := mux.Vars(httpReq)["id"]
+	d, err := bank.GetDocument(id)
+	if err != nil {
+		writeError(httpRes, requestID, err)
+		return
+	}
+	if d == nil {
+		writeError(httpRes, requestID, notFoundf("document(%s) not found", id))
+		return
+	}
+	f, err := bank.Documents.Open(d.Hash)
+	if err != nil {
+		writeError(httpRes, requestID, err)
+		return
+	}
+	defer f.Close()
+	httpRes.Header().Set("Content-Type", downloadMimeType(d.MimeType))
+	httpRes.Header().Set("Content-Length", strconv.FormatInt(d.Size, 10))
+	disposition := mime.FormatMediaType("attachment", map[string]string{"filename": d.Name})
+	if disposition == "" {
+		disposition = "attachment" //name cannot be encoded
+	}
+	httpRes.Header().Set("Content-Disposition", disposition)
+	httpRes.Header().Set("X-Content-Type-Options", "nosniff")
+	if _, err := io.Copy(httpRes, f); err != nil {
+		log.Errorf("failed to send document(%s): %+v", d.ID, err)
+	}
+}
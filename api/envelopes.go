@@ -0,0 +1,68 @@
+package api
+
+import (
+	"context"
+	"time"
+
+	"github.com/go-msvc/errors"
+	"github.com/jansemmelink/money/bank"
+)
+
+type EnvelopeReportRequest struct {
+	Month string `json:"month"` //CCYY-MM
+
+	from time.Time
+}
+
+func (req *EnvelopeReportRequest) Validate() (err error) {
+	if req.Month == "" {
+		req.Month = time.Now().Format(bank.MonthFormat)
+	}
+	if req.from, err = bank.ParseMonth(req.Month); err != nil {
+		return fieldErrorf("month", "%s", err)
+	}
+	return nil
+}
+
+func getEnvelopeReport(ctx context.Context, req EnvelopeReportRequest) (*bank.EnvelopeReport, error) {
+	r, err := bank.GetEnvelopeReport(req.from, req.from.AddDate(0, 1, 0).Add(-time.Second))
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to get envelope report")
+	}
+	return r, nil
+}
+
+//EnvelopeMoveRequest moves money between envelopes, where "" is to be budgeted
+type EnvelopeMoveRequest struct {
+	Date           string      `json:"date"` //CCYY-MM-DD, default today
+	FromEnvelopeID string      `json:"from_envelope_id"`
+	ToEnvelopeID   string      `json:"to_envelope_id"`
+	Amount         bank.Amount `json:"amount"`
+	Notes          string      `json:"notes"`
+
+	date time.Time
+}
+
+func (req *EnvelopeMoveRequest) Validate() (err error) {
+	if req.FromEnvelopeID == req.ToEnvelopeID {
+		return fieldErrorf("to_envelope_id", "same as from_envelope_id")
+	}
+	if req.Amount.MilliCents() <= 0 {
+		return fieldErrorf("amount", "must be > 0")
+	}
+	req.date = time.Now()
+	if req.Date != "" {
+		if req.date, err = parseDate(req.Date, false); err != nil {
+			return fieldErrorf("date", "%s", err)
+		}
+	}
+	return nil
+}
+
+func moveToEnvelope(ctx context.Context, req EnvelopeMoveRequest) (*bank.EnvelopeMove, error) {
+	m, err := bank.MoveToEnvelope(req.date, req.FromEnvelopeID, req.ToEnvelopeID, req.Amount, req.Notes)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to move to envelope")
+	}
+	return m, nil
+}
@@ -0,0 +1,120 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/go-msvc/errors"
+	"github.com/jansemmelink/money/bank"
+	"github.com/jansemmelink/money/db"
+	"github.com/jansemmelink/money/query"
+)
+
+//Codes in error responses
+const (
+	CodeBadRequest = "bad_request" //400 the request could not be decoded
+	CodeNotFound   = "not_found"   //404
+	CodeConflict   = "conflict"    //409 e.g. a duplicate name
+	CodeTooLarge   = "too_large"   //413 the request body or uploaded file is too large
+	CodeInvalid    = "invalid"     //422 the request or uploaded file is not valid
+	CodeInternal   = "internal"    //500
+)
+
+//Error is a failed request, sent to the client as the JSON response body
+type Error struct {
+	Status    int              `json:"-"`
+	Code      string           `json:"code"`
+	Message   string           `json:"message"`
+	Fields    []FieldError     `json:"fields,omitempty"` //problems with request fields
+	Lines     bank.ParseErrors `json:"lines,omitempty"`  //problems in an uploaded file
+	RequestID string           `json:"request_id,omitempty"`
+}
+
+func (e Error) Error() string {
+	s := e.Message
+	for _, f := range e.Fields {
+		s += "; " + f.Error()
+	}
+	for _, l := range e.Lines {
+		s += "; " + l.Error()
+	}
+	return s
+}
+
+//FieldError is a problem with one field of the request
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+func (e FieldError) Error() string {
+	return e.Field + ": " + e.Message
+}
+
+func fieldErrorf(field string, format string, args ...interface{}) error {
+	return FieldError{Field: field, Message: fmt.Sprintf(format, args...)}
+}
+
+func badRequestf(format string, args ...interface{}) error {
+	return Error{Status: http.StatusBadRequest, Code: CodeBadRequest, Message: fmt.Sprintf(format, args...)}
+}
+
+func notFoundf(format string, args ...interface{}) error {
+	return Error{Status: http.StatusNotFound, Code: CodeNotFound, Message: fmt.Sprintf(format, args...)}
+}
+
+//toError finds the cause of err that determines the response,
+//which is 500 when the cause is not known, with a generic message
+//as the details are only logged
+func toError(err error) Error {
+	var wrapper error //wraps the current cause, with more detail
+	for cause := err; cause != nil; {
+		switch e := cause.(type) {
+		case Error:
+			return e
+		case FieldError:
+			return Error{Status: http.StatusUnprocessableEntity, Code: CodeInvalid, Message: "invalid request", Fields: []FieldError{e}}
+		case bank.ParseErrors:
+			return Error{Status: http.StatusUnprocessableEntity, Code: CodeInvalid, Message: "invalid statement", Lines: e}
+		case bank.ValidationError:
+			return Error{Status: http.StatusUnprocessableEntity, Code: CodeInvalid, Message: "invalid request", Fields: []FieldError{{Field: e.Field, Message: e.Message}}}
+		case bank.NotFoundError:
+			return Error{Status: http.StatusNotFound, Code: CodeNotFound, Message: e.Error()}
+		case *query.Error:
+			return Error{Status: http.StatusUnprocessableEntity, Code: CodeInvalid, Message: "invalid request", Fields: []FieldError{{Field: "q", Message: e.Error()}}}
+		}
+		if cause == bank.ErrAccountNameExists || cause == bank.ErrAccountInUse {
+			message := cause.Error()
+			if wrapper != nil {
+				message = wrapper.Error()
+			}
+			return Error{Status: http.StatusConflict, Code: CodeConflict, Message: message}
+		}
+		if db.IsDup(cause) {
+			return Error{Status: http.StatusConflict, Code: CodeConflict, Message: cause.Error()}
+		}
+		stack, ok := cause.(errors.IError)
+		if !ok {
+			break
+		}
+		wrapper, cause = cause, stack.Parent()
+	}
+	return Error{Status: http.StatusInternalServerError, Code: CodeInternal, Message: "internal error"}
+} //toError()
+
+//writeError responds with the status and JSON body for err
+func writeError(httpRes http.ResponseWriter, requestID string, err error) {
+	e := toError(err)
+	e.RequestID = requestID
+	if e.Status == http.StatusInternalServerError {
+		log.Errorf("request(%s) failed: %+v", requestID, err)
+	} else {
+		log.Debugf("request(%s) failed: %+v", requestID, err)
+	}
+	httpRes.Header().Set("Content-Type", "application/json")
+	httpRes.WriteHeader(e.Status)
+	if err := json.NewEncoder(httpRes).Encode(e); err != nil {
+		log.Errorf("failed to encode error response: %+v", err)
+	}
+}
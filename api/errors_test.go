@@ -0,0 +1,59 @@
+package api
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-msvc/errors"
+	"github.com/jansemmelink/money/bank"
+	"github.com/jansemmelink/money/query"
+)
+
+func TestToError(t *testing.T) {
+	_, _, queryErr := query.Compile("amount <", bank.TransactionFields)
+	if _, ok := queryErr.(*query.Error); !ok {
+		t.Fatalf("query error %T", queryErr)
+	}
+	_, moveErr := bank.MoveToEnvelope(time.Now(), "e1", "e1", bank.Amount{}, "")
+	for _, c := range []struct {
+		name   string
+		err    error
+		status int
+		code   string
+		field  string
+	}{
+		{"field", fieldErrorf("limit", "too big"), http.StatusUnprocessableEntity, CodeInvalid, "limit"},
+		{"api error", badRequestf("bad"), http.StatusBadRequest, CodeBadRequest, ""},
+		{"parse errors", bank.ParseErrors{{Line: 3, Message: "bad date"}}, http.StatusUnprocessableEntity, CodeInvalid, ""},
+		{"not found", errors.Wrapf(errors.Wrapf(bank.NotFoundError{Message: "transaction(1) not found"}, "a"), "b"), http.StatusNotFound, CodeNotFound, ""},
+		{"account save", errors.Wrapf((&bank.Account{}).Save(), "failed to create account"), http.StatusUnprocessableEntity, CodeInvalid, "name"},
+		{"account name", (&bank.Account{Name: "Car:Diesel"}).Save(), http.StatusUnprocessableEntity, CodeInvalid, "name"},
+		{"budget", errors.Wrapf((&bank.Budget{}).Save(), "failed to save budget"), http.StatusUnprocessableEntity, CodeInvalid, "account_id"},
+		{"budget month", (&bank.Budget{AccountID: "a", Month: "2021"}).Validate(), http.StatusUnprocessableEntity, CodeInvalid, "month"},
+		{"envelope", (&bank.Envelope{}).Save(), http.StatusUnprocessableEntity, CodeInvalid, "name"},
+		{"envelope move", moveErr, http.StatusUnprocessableEntity, CodeInvalid, "to_envelope_id"},
+		{"scheduled", (&bank.ScheduledTransaction{}).Save(), http.StatusUnprocessableEntity, CodeInvalid, "name"},
+		{"query", errors.Wrapf(queryErr, "failed to get transactions"), http.StatusUnprocessableEntity, CodeInvalid, "q"},
+		{"name exists", errors.Wrapf(bank.ErrAccountNameExists, "cannot create account(x)"), http.StatusConflict, CodeConflict, ""},
+		{"in use", bank.ErrAccountInUse, http.StatusConflict, CodeConflict, ""},
+		{"unknown", errors.Errorf("Error 1054: Unknown column 'x' in 'field list'"), http.StatusInternalServerError, CodeInternal, ""},
+	} {
+		e := toError(c.err)
+		if e.Status != c.status || e.Code != c.code {
+			t.Errorf("%s: %d %s != %d %s (%v)", c.name, e.Status, e.Code, c.status, c.code, c.err)
+			continue
+		}
+		if c.field != "" && (len(e.Fields) != 1 || e.Fields[0].Field != c.field) {
+			t.Errorf("%s: fields %+v != %s", c.name, e.Fields, c.field)
+		}
+	}
+}
+
+func TestInternalErrorMessage(t *testing.T) {
+	e := toError(errors.Wrapf(errors.Errorf("Error 1054: Unknown column 'x' in 'field list'"), "failed to get accounts"))
+	if e.Status != http.StatusInternalServerError || strings.Contains(e.Message, "column") {
+		t.Fatalf("internal error %d %s", e.Status, e.Message)
+	}
+}
@@ -0,0 +1,85 @@
+package api
+
+import (
+	"context"
+	"time"
+
+	"github.com/go-msvc/errors"
+	"github.com/jansemmelink/money/bank"
+	"github.com/jansemmelink/money/query"
+)
+
+type BalanceRequest struct {
+	ID   string `json:"id"`
+	Date string `json:"date"` //CCYY-MM-DD, default all transactions
+
+	asOf time.Time
+}
+
+func (req *BalanceRequest) Validate() (err error) {
+	if req.ID == "" {
+		return fieldErrorf("id", "missing")
+	}
+	if req.asOf, err = parseDate(req.Date, true); err != nil {
+		return fieldErrorf("date", "%s", err)
+	}
+	return nil
+}
+
+func getAccountBalance(ctx context.Context, req BalanceRequest) (*bank.AccountBalance, error) {
+	b, err := bank.GetBalance(req.ID, req.asOf)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to get balance")
+	}
+	return b, nil
+}
+
+type LedgerRequest struct {
+	ID   string `json:"id"`
+	From string `json:"from"` //CCYY-MM-DD
+	To   string `json:"to"`   //CCYY-MM-DD
+	Tag  string `json:"tag"`  //only transactions with this tag
+	Q    string `json:"q"`    //filter expression, e.g. amount < -500 and details ~ "sasol"
+
+	from time.Time
+	to   time.Time
+}
+
+func (req *LedgerRequest) Validate() (err error) {
+	if req.ID == "" {
+		return fieldErrorf("id", "missing")
+	}
+	if req.from, err = parseDate(req.From, false); err != nil {
+		return fieldErrorf("from", "%s", err)
+	}
+	if req.to, err = parseDate(req.To, true); err != nil {
+		return fieldErrorf("to", "%s", err)
+	}
+	if err := query.Parse(req.Q, bank.TransactionFields); err != nil {
+		return fieldErrorf("q", "%s", err)
+	}
+	return nil
+}
+
+func getAccountLedger(ctx context.Context, req LedgerRequest) (*bank.Ledger, error) {
+	l, err := bank.GetLedger(req.ID, bank.LedgerFilter{From: req.from, To: req.to, Tag: req.Tag, Query: req.Q})
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to get ledger")
+	}
+	return l, nil
+}
+
+//parseDate parses CCYY-MM-DD in local time, "" is zero time
+func parseDate(s string, endOfDay bool) (time.Time, error) {
+	if s == "" {
+		return time.Time{}, nil
+	}
+	t, err := time.ParseInLocation("2006-01-02", s, time.Local)
+	if err != nil {
+		return time.Time{}, errors.Errorf("invalid date \"%s\" expects CCYY-MM-DD", s)
+	}
+	if endOfDay {
+		t = t.Add(24*time.Hour - time.Second)
+	}
+	return t, nil
+}
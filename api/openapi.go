@@ -0,0 +1,308 @@
+package api
+
+import (
+	"net/http"
+	"path"
+	"reflect"
+	"regexp"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/jansemmelink/money/bank"
+	"github.com/jansemmelink/money/db"
+)
+
+//openAPIDoc is an OpenAPI 3 document, see https://spec.openapis.org/oas/v3.0.3
+type openAPIDoc struct {
+	OpenAPI    string                           `json:"openapi"`
+	Info       openAPIInfo                      `json:"info"`
+	Paths      map[string]map[string]*operation `json:"paths"` //operations by path and lowercase method
+	Components struct {
+		Schemas map[string]*schema `json:"schemas"`
+	} `json:"components"`
+
+	typeNames map[reflect.Type]string //component names of the types in Schemas
+}
+
+type openAPIInfo struct {
+	Title   string `json:"title"`
+	Version string `json:"version"`
+}
+
+type operation struct {
+	OperationID string              `json:"operationId"`
+	Parameters  []parameter         `json:"parameters,omitempty"`
+	RequestBody *requestBody        `json:"requestBody,omitempty"`
+	Responses   map[string]response `json:"responses"`
+}
+
+type parameter struct {
+	Name     string  `json:"name"`
+	In       string  `json:"in"` //path or query
+	Required bool    `json:"required,omitempty"`
+	Schema   *schema `json:"schema"`
+}
+
+type requestBody struct {
+	Required bool                 `json:"required,omitempty"`
+	Content  map[string]mediaType `json:"content"`
+}
+
+type response struct {
+	Description string               `json:"description"`
+	Content     map[string]mediaType `json:"content,omitempty"`
+}
+
+type mediaType struct {
+	Schema *schema `json:"schema"`
+}
+
+type schema struct {
+	Ref                  string             `json:"$ref,omitempty"`
+	Type                 string             `json:"type,omitempty"`
+	Format               string             `json:"format,omitempty"`
+	Example              string             `json:"example,omitempty"`
+	Items                *schema            `json:"items,omitempty"`
+	Properties           map[string]*schema `json:"properties,omitempty"`
+	AdditionalProperties *schema            `json:"additionalProperties,omitempty"`
+}
+
+//schemas of types that encode themselves as JSON strings
+var stringSchemas = map[reflect.Type]schema{
+	reflect.TypeOf(bank.Amount{}): {Type: "string", Example: "-123.45"},
+	reflect.TypeOf(db.SqlTime{}):  {Type: "string", Example: "2006-01-02 15:04:05"},
+	reflect.TypeOf(db.SqlDate{}):  {Type: "string", Format: "date"},
+	reflect.TypeOf(time.Time{}):   {Type: "string", Format: "date-time"},
+}
+
+//pathVar matches "{name}" and "{name:pattern}" in mux path templates
+var pathVar = regexp.MustCompile(`{([^}:]+)(:[^}]*)?}`)
+
+//newOpenAPIDoc describes the routes registered on the router.
+//Request and response schemas come from the types of hdlr funcs.
+//Other handlers are file uploads (multipart form) or downloads.
+func newOpenAPIDoc(router *mux.Router) (*openAPIDoc, error) {
+	doc := &openAPIDoc{
+		OpenAPI:   "3.0.3",
+		Info:      openAPIInfo{Title: "money", Version: "1"},
+		Paths:     map[string]map[string]*operation{},
+		typeNames: map[reflect.Type]string{},
+	}
+	doc.Components.Schemas = map[string]*schema{}
+	errorSchema := doc.schemaOf(reflect.TypeOf(Error{}))
+	err := router.Walk(func(route *mux.Route, router *mux.Router, ancestors []*mux.Route) error {
+		tpl, err := route.GetPathTemplate()
+		if err != nil {
+			return nil //route without a path
+		}
+		methods, err := route.GetMethods()
+		if err != nil {
+			return nil //route without methods
+		}
+		pathVars := []string{}
+		for _, m := range pathVar.FindAllStringSubmatch(tpl, -1) {
+			pathVars = append(pathVars, m[1])
+		}
+		oasPath := pathVar.ReplaceAllString(tpl, "{$1}")
+		for _, method := range methods {
+			var op *operation
+			switch h := route.GetHandler().(type) {
+			case *handler:
+				op = doc.handlerOperation(method, pathVars, h)
+			case http.HandlerFunc:
+				op = doc.fileOperation(method, pathVars, h)
+			default:
+				continue
+			}
+			op.Responses["default"] = response{
+				Description: "Error",
+				Content:     map[string]mediaType{"application/json": {Schema: errorSchema}},
+			}
+			if doc.Paths[oasPath] == nil {
+				doc.Paths[oasPath] = map[string]*operation{}
+			}
+			doc.Paths[oasPath][strings.ToLower(method)] = op
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return doc, nil
+} //newOpenAPIDoc()
+
+//handlerOperation has path params for the path vars, query params for
+//the other string and int fields of GET and DELETE requests, or the
+//other fields in a JSON request body
+func (doc *openAPIDoc) handlerOperation(method string, pathVars []string, h *handler) *operation {
+	op := &operation{
+		OperationID: funcName(h.funcValue),
+		Responses:   map[string]response{},
+	}
+	isPathVar := map[string]bool{}
+	for _, name := range pathVars {
+		isPathVar[name] = true
+		p := parameter{Name: name, In: "path", Required: true, Schema: &schema{Type: "string"}}
+		if h.reqType != nil {
+			if f, ok := jsonField(h.reqType, name); ok {
+				p.Schema = doc.schemaOf(f.Type)
+			}
+		}
+		op.Parameters = append(op.Parameters, p)
+	}
+	if h.reqType != nil {
+		if method == http.MethodGet || method == http.MethodDelete {
+			for _, f := range jsonFields(h.reqType) {
+				kind := f.Type.Kind()
+				if isPathVar[f.name] || (kind != reflect.String && kind != reflect.Int) {
+					continue //dot.Set only sets strings and ints
+				}
+				op.Parameters = append(op.Parameters, parameter{Name: f.name, In: "query", Schema: doc.schemaOf(f.Type)})
+			}
+		} else {
+			if body := doc.objectSchema(h.reqType, isPathVar); len(body.Properties) > 0 {
+				op.RequestBody = &requestBody{Content: map[string]mediaType{"application/json": {Schema: body}}}
+			}
+		}
+	}
+	res := response{Description: "OK"}
+	if h.resType.Kind() != reflect.Interface {
+		res.Content = map[string]mediaType{"application/json": {Schema: doc.schemaOf(h.resType)}}
+	}
+	op.Responses["200"] = res
+	return op
+} //openAPIDoc.handlerOperation()
+
+//fileOperation uploads files in a multipart form, or downloads a file
+func (doc *openAPIDoc) fileOperation(method string, pathVars []string, f http.HandlerFunc) *operation {
+	op := &operation{
+		OperationID: funcName(reflect.ValueOf(f)),
+		Responses:   map[string]response{},
+	}
+	for _, name := range pathVars {
+		op.Parameters = append(op.Parameters, parameter{Name: name, In: "path", Required: true, Schema: &schema{Type: "string"}})
+	}
+	file := &schema{Type: "string", Format: "binary"}
+	if method == http.MethodGet {
+		op.Responses["200"] = response{Description: "File", Content: map[string]mediaType{"application/octet-stream": {Schema: file}}}
+		return op
+	}
+	op.RequestBody = &requestBody{
+		Required: true,
+		Content: map[string]mediaType{"multipart/form-data": {Schema: &schema{
+			Type:       "object",
+			Properties: map[string]*schema{"file": file},
+		}}},
+	}
+	op.Responses["200"] = response{Description: "OK", Content: map[string]mediaType{"application/json": {Schema: &schema{}}}}
+	return op
+}
+
+//schemaOf returns the schema of a type, with a reference to the
+//components for named structs
+func (doc *openAPIDoc) schemaOf(t reflect.Type) *schema {
+	if s, ok := stringSchemas[t]; ok {
+		return &s
+	}
+	switch t.Kind() {
+	case reflect.Ptr:
+		return doc.schemaOf(t.Elem())
+	case reflect.Bool:
+		return &schema{Type: "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return &schema{Type: "integer"}
+	case reflect.Float32, reflect.Float64:
+		return &schema{Type: "number"}
+	case reflect.String:
+		return &schema{Type: "string"}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return &schema{Type: "string", Format: "byte"}
+		}
+		return &schema{Type: "array", Items: doc.schemaOf(t.Elem())}
+	case reflect.Map:
+		return &schema{Type: "object", AdditionalProperties: doc.schemaOf(t.Elem())}
+	case reflect.Struct:
+		if t.Name() == "" {
+			return doc.objectSchema(t, nil)
+		}
+		name, ok := doc.typeNames[t]
+		if !ok {
+			name = t.Name()
+			if _, used := doc.Components.Schemas[name]; used {
+				name = path.Base(t.PkgPath()) + "." + t.Name()
+			}
+			doc.typeNames[t] = name
+			//added before its fields so that recursive types refer to it
+			doc.Components.Schemas[name] = &schema{}
+			*doc.Components.Schemas[name] = *doc.objectSchema(t, nil)
+		}
+		return &schema{Ref: "#/components/schemas/" + name}
+	}
+	return &schema{} //any value
+} //openAPIDoc.schemaOf()
+
+//objectSchema has a property for each JSON field of the struct, except skipped names
+func (doc *openAPIDoc) objectSchema(t reflect.Type, skip map[string]bool) *schema {
+	s := &schema{Type: "object", Properties: map[string]*schema{}}
+	for _, f := range jsonFields(t) {
+		if !skip[f.name] {
+			s.Properties[f.name] = doc.schemaOf(f.Type)
+		}
+	}
+	return s
+}
+
+type field struct {
+	reflect.StructField
+	name string
+}
+
+//jsonFields are the fields of the struct as encoding/json names them,
+//including fields of embedded structs
+func jsonFields(t reflect.Type) []field {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct {
+		return nil
+	}
+	list := []field{}
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		name := strings.SplitN(f.Tag.Get("json"), ",", 2)[0]
+		if name == "-" {
+			continue
+		}
+		if f.Anonymous && name == "" {
+			list = append(list, jsonFields(f.Type)...)
+			continue
+		}
+		if f.PkgPath != "" {
+			continue //unexported
+		}
+		if name == "" {
+			name = f.Name
+		}
+		list = append(list, field{StructField: f, name: name})
+	}
+	return list
+} //jsonFields()
+
+func jsonField(t reflect.Type, name string) (field, bool) {
+	for _, f := range jsonFields(t) {
+		if f.name == name {
+			return f, true
+		}
+	}
+	return field{}, false
+}
+
+//funcName is the name of the func without its package
+func funcName(f reflect.Value) string {
+	name := runtime.FuncForPC(f.Pointer()).Name()
+	return name[strings.LastIndex(name, ".")+1:]
+}
@@ -0,0 +1,71 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/mux"
+)
+
+func TestOpenAPIDoc(t *testing.T) {
+	httpRes := serve(t, http.MethodGet, "/openapi.json", "")
+	if httpRes.Code != http.StatusOK {
+		t.Fatalf("status %d: %s", httpRes.Code, httpRes.Body)
+	}
+	var doc openAPIDoc
+	if err := json.Unmarshal(httpRes.Body.Bytes(), &doc); err != nil {
+		t.Fatalf("invalid document: %+v", err)
+	}
+
+	//every route on the router is described, with its path params
+	router, err := newRouter()
+	if err != nil {
+		t.Fatalf("router: %+v", err)
+	}
+	nrOperations := 0
+	router.Walk(func(route *mux.Route, router *mux.Router, ancestors []*mux.Route) error {
+		tpl, _ := route.GetPathTemplate()
+		methods, _ := route.GetMethods()
+		if tpl == "/openapi.json" || tpl == "/docs" {
+			return nil //describe the routes before them
+		}
+		for _, method := range methods {
+			op := doc.Paths[pathVar.ReplaceAllString(tpl, "{$1}")][strings.ToLower(method)]
+			if op == nil {
+				t.Errorf("%s %s not in document", method, tpl)
+				continue
+			}
+			nrOperations++
+			for _, m := range pathVar.FindAllStringSubmatch(tpl, -1) {
+				found := false
+				for _, p := range op.Parameters {
+					found = found || (p.Name == m[1] && p.In == "path" && p.Required)
+				}
+				if !found {
+					t.Errorf("%s %s without path param %s: %+v", method, tpl, m[1], op.Parameters)
+				}
+			}
+			if _, ok := op.Responses["default"]; !ok {
+				t.Errorf("%s %s without error response", method, tpl)
+			}
+		}
+		return nil
+	})
+	if nrOperations < 30 {
+		t.Errorf("only %d operations", nrOperations)
+	}
+
+	//query params come from the request fields that are not path params
+	op := doc.Paths["/transactions"]["get"]
+	names := map[string]string{}
+	for _, p := range op.Parameters {
+		names[p.Name] = p.In
+	}
+	for _, name := range []string{"account", "from", "q", "cursor", "limit"} {
+		if names[name] != "query" {
+			t.Errorf("GET /transactions param %s in \"%s\"", name, names[name])
+		}
+	}
+}
@@ -0,0 +1,73 @@
+package api
+
+import (
+	"context"
+	"time"
+
+	"github.com/go-msvc/errors"
+	"github.com/jansemmelink/money/report"
+)
+
+type TrialBalanceRequest struct {
+	Date string `json:"date"` //CCYY-MM-DD, default all transactions
+	Tag  string `json:"tag"`  //only transactions with this tag
+
+	asOf time.Time
+}
+
+func (req *TrialBalanceRequest) Validate() (err error) {
+	if req.asOf, err = parseDate(req.Date, true); err != nil {
+		return fieldErrorf("date", "%s", err)
+	}
+	return nil
+}
+
+func getTrialBalance(ctx context.Context, req TrialBalanceRequest) (*report.Report, error) {
+	r, err := report.TrialBalance(req.asOf, req.Tag)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to get trial balance")
+	}
+	return r, nil
+}
+
+type IncomeStatementRequest struct {
+	From   string `json:"from"`   //CCYY-MM-DD, default start of the year
+	To     string `json:"to"`     //CCYY-MM-DD, default today
+	Period string `json:"period"` //month|quarter|year, default month
+	Tag    string `json:"tag"`    //only transactions with this tag
+
+	periods []report.Period
+}
+
+func (req *IncomeStatementRequest) Validate() (err error) {
+	if req.Period == "" {
+		req.Period = string(report.Month)
+	}
+	interval, err := report.ParseInterval(req.Period)
+	if err != nil {
+		return fieldErrorf("period", "%s", err)
+	}
+	now := time.Now()
+	from := time.Date(now.Year(), 1, 1, 0, 0, 0, 0, time.Local)
+	if req.From != "" {
+		if from, err = parseDate(req.From, false); err != nil {
+			return fieldErrorf("from", "%s", err)
+		}
+	}
+	to := time.Date(now.Year(), now.Month(), now.Day(), 23, 59, 59, 0, time.Local)
+	if req.To != "" {
+		if to, err = parseDate(req.To, true); err != nil {
+			return fieldErrorf("to", "%s", err)
+		}
+	}
+	req.periods, err = report.Periods(from, to, interval)
+	return err
+}
+
+func getIncomeStatement(ctx context.Context, req IncomeStatementRequest) (*report.Report, error) {
+	r, err := report.IncomeStatement(req.periods, req.Tag)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to get income statement")
+	}
+	return r, nil
+}
@@ -0,0 +1,132 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+
+	"github.com/go-msvc/errors"
+	"github.com/google/uuid"
+	"github.com/jansemmelink/money/bank"
+	"github.com/jansemmelink/money/importer"
+)
+
+//largest statement file accepted, which must fit in a MEDIUMBLOB
+const maxStatementSize = 10 << 20
+
+//room for the multipart boundaries and headers around the statement file
+const maxStatementFormOverhead = 1 << 20
+
+//StatementUploadPreview shows what will be imported when upload ID is confirmed
+type StatementUploadPreview struct {
+	ID      string              `json:"id"`
+	Name    string              `json:"name"`
+	Format  string              `json:"format"`
+	Preview *bank.ImportPreview `json:"preview"`
+}
+
+var statementTooLarge = Error{Status: http.StatusRequestEntityTooLarge, Code: CodeTooLarge, Message: fmt.Sprintf("statement file larger than %d bytes", maxStatementSize)}
+
+//uploadStatement parses the statement file in the multipart form field "file"
+//and responds with the preview of the import without changing the transactions
+func uploadStatement(httpRes http.ResponseWriter, httpReq *http.Request) {
+	requestID := uuid.New().String()
+	httpReq.Body = http.MaxBytesReader(httpRes, httpReq.Body, maxStatementSize+maxStatementFormOverhead)
+	if err := httpReq.ParseMultipartForm(maxUploadMemory); err != nil {
+		if strings.Contains(err.Error(), "request body too large") {
+			writeError(httpRes, requestID, statementTooLarge)
+			return
+		}
+		writeError(httpRes, requestID, badRequestf("expects multipart/form-data: %s", err))
+		return
+	}
+	defer httpReq.MultipartForm.RemoveAll()
+	f, fh, err := httpReq.FormFile("file")
+	if err != nil {
+		writeError(httpRes, requestID, badRequestf("expects statement file in form field \"file\""))
+		return
+	}
+	defer f.Close()
+	if fh.Size > maxStatementSize {
+		writeError(httpRes, requestID, statementTooLarge)
+		return
+	}
+	data, err := ioutil.ReadAll(f)
+	if err != nil {
+		writeError(httpRes, requestID, badRequestf("cannot read %s: %s", fh.Filename, err))
+		return
+	}
+
+	format, stmt, err := importer.Parse(data)
+	if err != nil {
+		e := toError(err)
+		if format == nil {
+			e = Error{Status: http.StatusUnprocessableEntity, Code: CodeInvalid, Message: err.Error()}
+		} else if e.Status == http.StatusUnprocessableEntity {
+			e.Message = "invalid " + format.Name + " statement"
+		}
+		writeError(httpRes, requestID, e)
+		return
+	}
+	preview, err := stmt.Preview()
+	if err != nil {
+		writeError(httpRes, requestID, err)
+		return
+	}
+	u, err := bank.SaveStatementUpload(fh.Filename, format.Name, data)
+	if err != nil {
+		writeError(httpRes, requestID, err)
+		return
+	}
+	if err := writeJSON(httpRes, StatementUploadPreview{ID: u.ID, Name: u.Name, Format: u.Format, Preview: preview}); err != nil {
+		writeError(httpRes, requestID, err)
+	}
+} //uploadStatement()
+
+type ConfirmImportRequest struct {
+	ID string `json:"id"` //of the statement upload
+}
+
+func (req ConfirmImportRequest) Validate() error {
+	if req.ID == "" {
+		return fieldErrorf("id", "missing")
+	}
+	return nil
+}
+
+//ImportResult is the imported statement, which is nil when all its dates
+//were already imported from other statements
+type ImportResult struct {
+	Statement *bank.StatementRecord `json:"statement"`
+}
+
+//confirmImport imports the uploaded statement as shown in its preview
+func confirmImport(ctx context.Context, req ConfirmImportRequest) (*ImportResult, error) {
+	u, err := bank.GetStatementUpload(req.ID)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to get statement upload")
+	}
+	if u == nil {
+		return nil, notFoundf("statement upload(%s) not found", req.ID)
+	}
+	_, stmt, err := importer.Parse(u.Content)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to parse statement upload(%s)", u.ID)
+	}
+	id, err := stmt.ImportToDb()
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to import")
+	}
+	if err := bank.DeleteStatementUpload(u.ID); err != nil {
+		return nil, err
+	}
+	res := ImportResult{}
+	if id != "" {
+		if res.Statement, err = bank.GetStatement(id); err != nil {
+			return nil, err
+		}
+	}
+	return &res, nil
+} //confirmImport()
@@ -0,0 +1,77 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+//uploadFile posts the content as a multipart form with the file in field
+func uploadFile(t *testing.T, target string, field string, content string) *httptest.ResponseRecorder {
+	body := &bytes.Buffer{}
+	w := multipart.NewWriter(body)
+	fw, err := w.CreateFormFile(field, "statement.csv")
+	if err != nil {
+		t.Fatalf("form: %+v", err)
+	}
+	fw.Write([]byte(content))
+	w.Close()
+	router, err := newRouter()
+	if err != nil {
+		t.Fatalf("router: %+v", err)
+	}
+	httpReq := httptest.NewRequest(http.MethodPost, target, body)
+	httpReq.Header.Set("Content-Type", w.FormDataContentType())
+	httpRes := httptest.NewRecorder()
+	router.ServeHTTP(httpRes, httpReq)
+	return httpRes
+}
+
+const stdbankHeader = "0,2645,BRANCH,0,,CENTURION,0,0\n" +
+	",12319791,ACC-NO,0,,,0,0\n"
+
+func TestUploadStatementErrors(t *testing.T) {
+	expectError(t, serve(t, http.MethodPost, "/statements", "date,amount\n"), http.StatusBadRequest, "")
+	expectError(t, uploadFile(t, "/statements", "statement", stdbankHeader), http.StatusBadRequest, "")
+	expectError(t, uploadFile(t, "/statements", "file", "date,amount,details\n"), http.StatusUnprocessableEntity, "")
+	//a file just over the limit, and a body too large to read as a form
+	for _, size := range []int{maxStatementSize, maxStatementSize + maxStatementFormOverhead} {
+		httpRes := uploadFile(t, "/statements", "file", stdbankHeader+strings.Repeat(" ", size))
+		expectError(t, httpRes, http.StatusRequestEntityTooLarge, "")
+		var e Error
+		json.Unmarshal(httpRes.Body.Bytes(), &e)
+		if e.Code != CodeTooLarge {
+			t.Errorf("%d bytes: code %s", size, e.Code)
+		}
+	}
+
+	//every line with a problem is reported before anything is previewed
+	httpRes := uploadFile(t, "/statements", "file", stdbankHeader+
+		",0,OPEN,1000.00,OPEN BALANCE,,0,0\n"+
+		"HIST,20200928,,-21x.22,TJEKKAART-AANKOOP,Spar,6076,0\n"+
+		"HIST,2020-09-29,,500,KREDIETOORPLASING,CORNUEX,6088,0\n"+
+		",0,CLOSE,1288.78,CLOSE BALANCE,,0,0\n")
+	expectError(t, httpRes, http.StatusUnprocessableEntity, "")
+	var e Error
+	json.Unmarshal(httpRes.Body.Bytes(), &e)
+	if e.Code != CodeInvalid || e.Message != "invalid standardbank-csv statement" || len(e.Lines) != 2 || e.Lines[0].Line != 4 || e.Lines[1].Line != 5 {
+		t.Errorf("error %+v", e)
+	}
+}
+
+func TestConfirmImportRequest(t *testing.T) {
+	if err := (ConfirmImportRequest{}).Validate(); err == nil {
+		t.Errorf("confirmed without id")
+	}
+	if err := (ConfirmImportRequest{ID: "u1"}).Validate(); err != nil {
+		t.Errorf("confirm failed: %+v", err)
+	}
+	//confirm is only routed for an upload id
+	if httpRes := serve(t, http.MethodPost, "/statements/uploads//confirm", ""); httpRes.Code == http.StatusOK {
+		t.Errorf("confirmed without upload id: %d", httpRes.Code)
+	}
+}
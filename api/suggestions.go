@@ -0,0 +1,64 @@
+package api
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/go-msvc/errors"
+	"github.com/jansemmelink/money/bank"
+)
+
+//the suggester is trained from all categorised transactions,
+//so it is kept for a while rather than trained on every request
+const suggesterMaxAge = 5 * time.Minute
+
+var (
+	suggesterMutex     sync.Mutex
+	suggester          *bank.Suggester
+	suggesterTrainedAt time.Time
+)
+
+func getSuggester() (*bank.Suggester, error) {
+	suggesterMutex.Lock()
+	defer suggesterMutex.Unlock()
+	if suggester == nil || time.Since(suggesterTrainedAt) > suggesterMaxAge {
+		s, err := bank.TrainSuggester()
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to train suggester")
+		}
+		suggester = s
+		suggesterTrainedAt = time.Now()
+	}
+	return suggester, nil
+}
+
+type SuggestionsRequest struct {
+	ID string `json:"id"`
+	N  int    `json:"n"`
+}
+
+func (req *SuggestionsRequest) Validate() error {
+	if req.ID == "" {
+		return fieldErrorf("id", "missing")
+	}
+	if req.N <= 0 {
+		req.N = 3
+	}
+	return nil
+}
+
+func getTransactionSuggestions(ctx context.Context, req SuggestionsRequest) ([]bank.AccountSuggestion, error) {
+	tx, err := bank.GetTransaction(req.ID)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to get transaction")
+	}
+	if tx == nil {
+		return nil, notFoundf("transaction(%s) not found", req.ID)
+	}
+	s, err := getSuggester()
+	if err != nil {
+		return nil, err
+	}
+	return s.Suggest(tx.StatementTransaction(), req.N), nil
+}
@@ -0,0 +1,92 @@
+package api
+
+import (
+	"context"
+
+	"github.com/go-msvc/errors"
+	"github.com/jansemmelink/money/bank"
+)
+
+func getTags(ctx context.Context) ([]bank.Tag, error) {
+	list, err := bank.GetTags()
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to get tags")
+	}
+	return list, nil
+}
+
+type TagRequest struct {
+	ID   string   `json:"id"` //transaction id
+	Tags []string `json:"tags"`
+}
+
+func (req TagRequest) Validate() error {
+	if req.ID == "" {
+		return fieldErrorf("id", "missing")
+	}
+	if len(req.Tags) == 0 {
+		return fieldErrorf("tags", "missing")
+	}
+	for _, tag := range req.Tags {
+		if _, err := bank.ParseTag(tag); err != nil {
+			return fieldErrorf("tags", "%s", err)
+		}
+	}
+	return nil
+}
+
+func tagTransaction(ctx context.Context, req TagRequest) (*bank.TransactionRecord, error) {
+	if err := bank.TagTransaction(req.ID, req.Tags...); err != nil {
+		return nil, errors.Wrapf(err, "failed to tag transaction")
+	}
+	return bank.GetTransaction(req.ID)
+}
+
+type UntagRequest struct {
+	ID  string `json:"id"` //transaction id
+	Tag string `json:"tag"`
+}
+
+func (req UntagRequest) Validate() error {
+	if req.ID == "" {
+		return fieldErrorf("id", "missing")
+	}
+	if req.Tag == "" {
+		return fieldErrorf("tag", "missing")
+	}
+	return nil
+}
+
+func untagTransaction(ctx context.Context, req UntagRequest) (*bank.TransactionRecord, error) {
+	if err := bank.UntagTransaction(req.ID, req.Tag); err != nil {
+		return nil, errors.Wrapf(err, "failed to untag transaction")
+	}
+	return bank.GetTransaction(req.ID)
+}
+
+type NotesRequest struct {
+	ID    string `json:"id"` //transaction id
+	Notes string `json:"notes"`
+}
+
+func (req NotesRequest) Validate() error {
+	if req.ID == "" {
+		return fieldErrorf("id", "missing")
+	}
+	return nil
+}
+
+func setTransactionNotes(ctx context.Context, req NotesRequest) (*bank.TransactionRecord, error) {
+	tx, err := bank.GetTransaction(req.ID)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to get transaction")
+	}
+	if tx == nil {
+		return nil, notFoundf("transaction(%s) not found", req.ID)
+	}
+	tx.Notes = req.Notes
+	if err := tx.Save(); err != nil {
+		return nil, errors.Wrapf(err, "failed to save transaction")
+	}
+	return tx, nil
+}
@@ -0,0 +1,184 @@
+package api
+
+import (
+	"context"
+
+	"github.com/go-msvc/errors"
+	"github.com/jansemmelink/money/bank"
+	"github.com/jansemmelink/money/query"
+)
+
+const (
+	defaultPageSize = 100
+	maxPageSize     = 1000
+)
+
+//TransactionsRequest lists transactions by date with optional filters,
+//one page at a time. The next page is requested with the cursor from the
+//previous response and is stable while transactions are recategorised.
+type TransactionsRequest struct {
+	Account   string `json:"account"` //account id
+	From      string `json:"from"`    //CCYY-MM-DD
+	To        string `json:"to"`      //CCYY-MM-DD
+	MinAmount string `json:"min_amount"`
+	MaxAmount string `json:"max_amount"`
+	Text      string `json:"text"`   //part of statement details or notes
+	Search    string `json:"search"` //words, prefixes (word*) and "phrases" in statement details, notes or tags
+	Statement string `json:"statement"`
+	Tag       string `json:"tag"`
+	Q         string `json:"q"` //filter expression, e.g. amount < -500 and details ~ "sasol"
+	Cursor    string `json:"cursor"`
+	Limit     int    `json:"limit"` //page size, default 100
+
+	filter bank.TransactionFilter
+}
+
+func (req *TransactionsRequest) Validate() (err error) {
+	req.filter = bank.TransactionFilter{
+		AccountID:   req.Account,
+		StatementID: req.Statement,
+		Details:     req.Text,
+		Tag:         req.Tag,
+		Search:      req.Search,
+		Query:       req.Q,
+		After:       req.Cursor,
+	}
+	if req.filter.From, err = parseDate(req.From, false); err != nil {
+		return fieldErrorf("from", "%s", err)
+	}
+	if req.filter.To, err = parseDate(req.To, true); err != nil {
+		return fieldErrorf("to", "%s", err)
+	}
+	if req.MinAmount != "" {
+		a, err := bank.NewAmount(req.MinAmount)
+		if err != nil {
+			return fieldErrorf("min_amount", "%s", err)
+		}
+		req.filter.MinAmount = &a
+	}
+	if req.MaxAmount != "" {
+		a, err := bank.NewAmount(req.MaxAmount)
+		if err != nil {
+			return fieldErrorf("max_amount", "%s", err)
+		}
+		req.filter.MaxAmount = &a
+	}
+	if _, err := bank.ParseSearch(req.Search); err != nil {
+		return fieldErrorf("search", "%s", err)
+	}
+	if err := query.Parse(req.Q, bank.TransactionFields); err != nil {
+		return fieldErrorf("q", "%s", err)
+	}
+	if req.Cursor != "" {
+		if _, _, err := bank.ParseTransactionCursor(req.Cursor); err != nil {
+			return fieldErrorf("cursor", "%s", err)
+		}
+	}
+	if req.Limit <= 0 {
+		req.Limit = defaultPageSize
+	}
+	if req.Limit > maxPageSize {
+		return fieldErrorf("limit", "limit %d > %d", req.Limit, maxPageSize)
+	}
+	//one more than the page tells if there is a next page
+	req.filter.Limit = req.Limit + 1
+	return nil
+} //TransactionsRequest.Validate()
+
+//TransactionPage is one page of transactions, with the cursor for the
+//next page, which is "" on the last page
+type TransactionPage struct {
+	Transactions []bank.TransactionRecord `json:"transactions"`
+	Next         string                   `json:"next,omitempty"`
+}
+
+func getTransactions(ctx context.Context, req TransactionsRequest) (*TransactionPage, error) {
+	list, err := bank.GetTransactions(req.filter)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to get transactions")
+	}
+	return newTransactionPage(list, req.Limit), nil
+}
+
+//newTransactionPage from up to limit+1 transactions, of which the extra one
+//tells that there is a next page
+func newTransactionPage(list []bank.TransactionRecord, limit int) *TransactionPage {
+	page := TransactionPage{Transactions: list}
+	if page.Transactions == nil {
+		page.Transactions = []bank.TransactionRecord{}
+	}
+	if len(list) > limit {
+		page.Transactions = list[:limit]
+		page.Next = bank.TransactionCursor(list[limit-1])
+	}
+	return &page
+}
+
+type TransactionRequest struct {
+	ID string `json:"id"`
+}
+
+func (req TransactionRequest) Validate() error {
+	if req.ID == "" {
+		return fieldErrorf("id", "missing")
+	}
+	return nil
+}
+
+func getTransaction(ctx context.Context, req TransactionRequest) (*bank.TransactionRecord, error) {
+	tx, err := bank.GetTransaction(req.ID)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to get transaction")
+	}
+	if tx == nil {
+		return nil, notFoundf("transaction(%s) not found", req.ID)
+	}
+	return tx, nil
+}
+
+//UpdateTransactionRequest changes the fields that are present
+type UpdateTransactionRequest struct {
+	ID        string  `json:"id"`
+	AccountID *string `json:"account_id"` //counter account
+	Notes     *string `json:"notes"`      //"" clears the notes
+}
+
+func (req UpdateTransactionRequest) Validate() error {
+	if req.ID == "" {
+		return fieldErrorf("id", "missing")
+	}
+	if req.AccountID == nil && req.Notes == nil {
+		return fieldErrorf("account_id", "missing account_id and/or notes")
+	}
+	if req.AccountID != nil && *req.AccountID == "" {
+		return fieldErrorf("account_id", "missing")
+	}
+	return nil
+}
+
+func updateTransaction(ctx context.Context, req UpdateTransactionRequest) (*bank.TransactionRecord, error) {
+	tx, err := getTransaction(ctx, TransactionRequest{ID: req.ID})
+	if err != nil {
+		return nil, err
+	}
+	if req.AccountID != nil {
+		if tx.IsSplit() {
+			return nil, fieldErrorf("account_id", "transaction(%s) is split, change its postings instead", tx.ID)
+		}
+		acc, err := bank.GetAccount(*req.AccountID)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to get account")
+		}
+		if acc == nil {
+			return nil, fieldErrorf("account_id", "account(%s) not found", *req.AccountID)
+		}
+		tx.SetCounterAccount(*acc)
+	}
+	if req.Notes != nil {
+		tx.Notes = *req.Notes
+	}
+	if err := tx.Save(); err != nil {
+		return nil, errors.Wrapf(err, "failed to update transaction")
+	}
+	return tx, nil
+} //updateTransaction()
@@ -0,0 +1,59 @@
+package api
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/jansemmelink/money/bank"
+	"github.com/jansemmelink/money/db"
+)
+
+func TestTransactionCursor(t *testing.T) {
+	day := func(d int) db.SqlTime { return db.SqlTime(time.Date(2021, 3, d, 0, 0, 0, 0, time.UTC)) }
+	list := []bank.TransactionRecord{
+		{ID: "a", Date: day(1)},
+		{ID: "b", Date: day(1)},
+		{ID: "c", Date: day(2)},
+	}
+	//one more than the limit gives a next page after the last one on the page
+	page := newTransactionPage(list, 2)
+	if len(page.Transactions) != 2 || page.Next == "" {
+		t.Fatalf("page %+v", page)
+	}
+	req := TransactionsRequest{Cursor: page.Next, Limit: 2}
+	if err := req.Validate(); err != nil {
+		t.Fatalf("next page: %+v", err)
+	}
+	if req.filter.After != page.Next || req.filter.Limit != 3 {
+		t.Fatalf("filter %+v", req.filter)
+	}
+	date, id, err := bank.ParseTransactionCursor(req.filter.After)
+	if err != nil || id != "b" || !time.Time(date).Equal(time.Time(day(1))) {
+		t.Fatalf("cursor %s %s %v", date, id, err)
+	}
+	//last page
+	page = newTransactionPage(list[2:], 2)
+	if len(page.Transactions) != 1 || page.Next != "" {
+		t.Fatalf("last page %+v", page)
+	}
+	if page = newTransactionPage(nil, 2); page.Transactions == nil {
+		t.Fatalf("nil transactions")
+	}
+}
+
+func TestTransactionsRequest(t *testing.T) {
+	for _, c := range []struct {
+		query string
+		field string
+	}{
+		{"cursor=abc", "cursor"},
+		{"from=2021-13-01", "from"},
+		{"min_amount=ten", "min_amount"},
+		{"limit=1001", "limit"},
+		{"q=amount+%3C", "q"},
+		{"q=colour+%3D+1", "q"},
+	} {
+		expectError(t, serve(t, http.MethodGet, "/transactions?"+c.query, ""), http.StatusUnprocessableEntity, c.field)
+	}
+}
@@ -0,0 +1,115 @@
+package bank
+
+import (
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/go-msvc/errors"
+	"github.com/jansemmelink/money/db"
+)
+
+//AccountTotal is the sum of all debits and credits posted to an account
+type AccountTotal struct {
+	Account        Account `json:"account"`
+	Debits         Amount  `json:"debits"`
+	Credits        Amount  `json:"credits"`
+	NrTransactions int     `json:"nr_transactions"`
+}
+
+//Balance is debits minus credits
+func (t AccountTotal) Balance() Amount {
+	return t.Debits.Sub(t.Credits)
+}
+
+//NormalBalance is the balance with the sign normal for the account type
+func (t AccountTotal) NormalBalance() Amount {
+	return t.Account.Type.NormalBalance(t.Balance())
+}
+
+type postingRow struct {
+	AccountID string `db:"account_id"`
+	Amount    Amount `db:"amount"`
+}
+
+//add the posting to the debits or credits
+func (t *AccountTotal) add(amount Amount) {
+	if amount.MilliCents() > 0 {
+		t.Debits = t.Debits.Add(amount)
+	} else {
+		t.Credits = t.Credits.Add(amount.Neg())
+	}
+	t.NrTransactions++
+}
+
+//GetAccountTotals sums all postings per account, sorted by account type and name.
+//Amounts are stored as strings, so the sums are done here rather than in SQL.
+func GetAccountTotals() ([]AccountTotal, error) {
+	return GetPeriodTotals(time.Time{}, time.Time{}, "")
+}
+
+//GetPeriodTotals is GetAccountTotals for transactions dated from..to
+//where zero times do not limit the period, and only transactions with
+//the tag unless it is "". All accounts are included.
+func GetPeriodTotals(from, to time.Time, tag string) ([]AccountTotal, error) {
+	accByID, err := getAllAccounts()
+	if err != nil {
+		return nil, err
+	}
+	totalByID := map[string]*AccountTotal{}
+	for _, acc := range accByID {
+		totalByID[acc.ID] = &AccountTotal{Account: acc}
+	}
+
+	sql := "SELECT p.account_id,p.amount FROM `postings` AS p"
+	args := []interface{}{}
+	if !from.IsZero() || !to.IsZero() || tag != "" {
+		sql += " JOIN `transactions` AS t ON t.id=p.transaction_id WHERE 1=1"
+		if !from.IsZero() {
+			sql += " AND t.date>=?"
+			args = append(args, db.SqlTime(from))
+		}
+		if !to.IsZero() {
+			sql += " AND t.date<=?"
+			args = append(args, db.SqlTime(to))
+		}
+		if tag != "" {
+			sql += " AND " + tagFilter
+			args = append(args, strings.ToLower(tag))
+		}
+	}
+	var rows []postingRow
+	if err := db.Db().Select(&rows, sql, args...); err != nil {
+		return nil, errors.Wrapf(err, "failed to get postings")
+	}
+	for _, row := range rows {
+		if t, ok := totalByID[row.AccountID]; ok {
+			t.add(row.Amount)
+		}
+	}
+
+	list := []AccountTotal{}
+	for _, t := range totalByID {
+		list = append(list, *t)
+	}
+	sort.Slice(list, func(i, j int) bool {
+		if list[i].Account.Type != list[j].Account.Type {
+			return list[i].Account.Type.Order() < list[j].Account.Type.Order()
+		}
+		return list[i].Account.Name < list[j].Account.Name
+	})
+	return list, nil
+} //GetPeriodTotals()
+
+//getAccountTotal sums the postings of one account
+func getAccountTotal(acc Account) (AccountTotal, error) {
+	total := AccountTotal{Account: acc}
+	var rows []postingRow
+	if err := db.Db().Select(&rows, "SELECT account_id,amount FROM `postings` WHERE account_id=?", acc.ID); err != nil {
+		return total, errors.Wrapf(err, "failed to get postings of account(%s)", acc.ID)
+	}
+	for _, row := range rows {
+		total.add(row.Amount)
+	}
+	return total, nil
+}
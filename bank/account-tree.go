@@ -0,0 +1,58 @@
+package bank
+
+import (
+	"sort"
+)
+
+//AccountTreeNode is the total of one account and the total rolled up
+//from the account and all accounts under it
+type AccountTreeNode struct {
+	Account Account      `json:"account"`
+	Depth   int          `json:"depth"` //0 for root accounts
+	Own     AccountTotal `json:"own"`
+	RollUp  AccountTotal `json:"roll_up"`
+}
+
+//GetAccountTree returns all accounts in path order with rolled up totals
+func GetAccountTree() ([]AccountTreeNode, error) {
+	totals, err := GetAccountTotals()
+	if err != nil {
+		return nil, err
+	}
+	return RollUp(totals), nil
+}
+
+//RollUp adds the totals of each account to all its ancestors
+//and returns the nodes sorted by account path
+func RollUp(totals []AccountTotal) []AccountTreeNode {
+	nodeByID := map[string]*AccountTreeNode{}
+	for _, t := range totals {
+		nodeByID[t.Account.ID] = &AccountTreeNode{
+			Account: t.Account,
+			Own:     t,
+			RollUp:  AccountTotal{Account: t.Account},
+		}
+	}
+	for _, t := range totals {
+		seen := map[string]bool{}
+		level := 0
+		for id := t.Account.ID; id != "" && !seen[id]; level++ {
+			seen[id] = true
+			node, ok := nodeByID[id]
+			if !ok {
+				break
+			}
+			node.RollUp.Debits = node.RollUp.Debits.Add(t.Debits)
+			node.RollUp.Credits = node.RollUp.Credits.Add(t.Credits)
+			node.RollUp.NrTransactions += t.NrTransactions
+			id = node.Account.ParentID
+		}
+		nodeByID[t.Account.ID].Depth = level - 1
+	}
+	list := []AccountTreeNode{}
+	for _, node := range nodeByID {
+		list = append(list, *node)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Account.Path < list[j].Account.Path })
+	return list
+} //RollUp()
@@ -0,0 +1,38 @@
+package bank_test
+
+import (
+	"testing"
+
+	"github.com/jansemmelink/money/bank"
+)
+
+func TestRollUp(t *testing.T) {
+	expenses := bank.Account{ID: "1", Name: "Expenses", Path: "Expenses"}
+	car := bank.Account{ID: "2", Name: "Car", ParentID: "1", Path: "Expenses:Car"}
+	diesel := bank.Account{ID: "3", Name: "Diesel", ParentID: "2", Path: "Expenses:Car:Diesel"}
+	food := bank.Account{ID: "4", Name: "Food", ParentID: "1", Path: "Expenses:Food"}
+	list := bank.RollUp([]bank.AccountTotal{
+		{Account: food, Debits: amount(t, "211.22"), NrTransactions: 1},
+		{Account: diesel, Debits: amount(t, "932"), NrTransactions: 1},
+		{Account: car, Debits: amount(t, "100"), Credits: amount(t, "20"), NrTransactions: 2},
+		{Account: expenses},
+	})
+	expected := []struct {
+		path    string
+		depth   int
+		balance string
+	}{
+		{"Expenses", 0, "1223.22"},
+		{"Expenses:Car", 1, "1012.00"},
+		{"Expenses:Car:Diesel", 2, "932.00"},
+		{"Expenses:Food", 1, "211.22"},
+	}
+	if len(list) != len(expected) {
+		t.Fatalf("%d nodes != %d", len(list), len(expected))
+	}
+	for i, e := range expected {
+		if list[i].Account.Path != e.path || list[i].Depth != e.depth || list[i].RollUp.Balance().String() != e.balance {
+			t.Fatalf("node[%d] = %s depth %d balance %s != %+v", i, list[i].Account.Path, list[i].Depth, list[i].RollUp.Balance(), e)
+		}
+	}
+}
@@ -0,0 +1,71 @@
+package bank
+
+import (
+	"strings"
+
+	"github.com/go-msvc/errors"
+)
+
+//AccountType is one of the five standard types of accounts
+type AccountType string
+
+const (
+	AccountTypeAsset     AccountType = "asset"
+	AccountTypeLiability AccountType = "liability"
+	AccountTypeEquity    AccountType = "equity"
+	AccountTypeIncome    AccountType = "income"
+	AccountTypeExpense   AccountType = "expense"
+)
+
+//AccountTypes in the order they are reported
+var AccountTypes = []AccountType{
+	AccountTypeAsset,
+	AccountTypeLiability,
+	AccountTypeEquity,
+	AccountTypeIncome,
+	AccountTypeExpense,
+}
+
+func ParseAccountType(s string) (AccountType, error) {
+	t := AccountType(strings.ToLower(strings.TrimSpace(s)))
+	if err := t.Validate(); err != nil {
+		return "", err
+	}
+	return t, nil
+}
+
+func (t AccountType) Validate() error {
+	for _, at := range AccountTypes {
+		if t == at {
+			return nil
+		}
+	}
+	return errors.Errorf("invalid account type \"%s\" (expects %s|%s|%s|%s|%s)", t,
+		AccountTypeAsset, AccountTypeLiability, AccountTypeEquity, AccountTypeIncome, AccountTypeExpense)
+}
+
+//DebitNormal is true for assets and expenses that increase with debits,
+//false for liabilities, equity and income that increase with credits
+func (t AccountType) DebitNormal() bool {
+	return t == AccountTypeAsset || t == AccountTypeExpense
+}
+
+//Order is the position of the type in AccountTypes, used to sort reports
+func (t AccountType) Order() int {
+	for i, at := range AccountTypes {
+		if t == at {
+			return i
+		}
+	}
+	return len(AccountTypes)
+}
+
+//NormalBalance presents a debits-credits balance with the sign
+//that is normal for the type, e.g. money owed on a credit card
+//(liability) or earned (income) is positive.
+func (t AccountType) NormalBalance(debitsMinusCredits Amount) Amount {
+	if t.DebitNormal() {
+		return debitsMinusCredits
+	}
+	return debitsMinusCredits.Neg()
+}
@@ -0,0 +1,40 @@
+package bank_test
+
+import (
+	"testing"
+
+	"github.com/jansemmelink/money/bank"
+)
+
+func TestAccountType(t *testing.T) {
+	at, err := bank.ParseAccountType(" Liability ")
+	assert(t, err)
+	if at != bank.AccountTypeLiability {
+		t.Fatalf("parsed %s", at)
+	}
+	if _, err := bank.ParseAccountType("savings"); err == nil {
+		t.Fatalf("parsed invalid type")
+	}
+
+	//R100 debits and R250 credits
+	total := bank.AccountTotal{}
+	total.Debits, err = bank.NewAmount("100")
+	assert(t, err)
+	total.Credits, err = bank.NewAmount("250")
+	assert(t, err)
+	for _, tc := range []struct {
+		accountType bank.AccountType
+		balance     string
+	}{
+		{bank.AccountTypeAsset, "-150.00"},
+		{bank.AccountTypeExpense, "-150.00"},
+		{bank.AccountTypeLiability, "150.00"},
+		{bank.AccountTypeEquity, "150.00"},
+		{bank.AccountTypeIncome, "150.00"},
+	} {
+		total.Account.Type = tc.accountType
+		if s := total.NormalBalance().String(); s != tc.balance {
+			t.Errorf("%s balance %s != %s", tc.accountType, s, tc.balance)
+		}
+	}
+}
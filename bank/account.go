@@ -2,107 +2,471 @@ package bank
 
 import (
 	"database/sql"
+	"encoding/base64"
+	"fmt"
+	"sort"
 	"strings"
 
 	"github.com/go-msvc/errors"
 	"github.com/google/uuid"
 	"github.com/jansemmelink/money/db"
+	"github.com/jmoiron/sqlx"
 )
 
+//PathSeparator separates account names in a path e.g. "Expenses:Car:Diesel"
+const PathSeparator = ":"
+
 type Account struct {
-	ID   string
-	Name string
-	Type string
+	ID       string      `db:"id" json:"id"`
+	Name     string      `db:"name" json:"name"`
+	Type     AccountType `db:"type" json:"type"`
+	ParentID string      `db:"parent_id" json:"parent_id,omitempty"`
+	Path     string      `db:"-" json:"path"` //names from the root account separated by PathSeparator
+}
+
+const accountSelect = "SELECT id,name,type,IFNULL(parent_id,'') AS parent_id FROM `accounts`"
+
+var (
+	//ErrAccountNameExists is returned when saving an account with the name
+	//of another account under the same parent
+	ErrAccountNameExists = errors.Error("account name already exists under the same parent")
+	//ErrAccountInUse is returned when deleting an account that is still
+	//referenced by transactions or other records
+	ErrAccountInUse = errors.Error("account is in use")
+)
+
+//AccountFilter selects accounts for GetAccounts
+type AccountFilter struct {
+	Name    string //part of the name
+	Type    string //part of the type
+	UnderID string //only this account and its descendants
+	Sort    string //path (default), name or type, with "-" prefix for descending order
+	Offset  int    //nr of accounts to skip
+	After   string //cursor of the last account on the previous page
+	Limit   int    //0 for all
+}
+
+//accountSortKeys are the values accounts can be sorted on
+var accountSortKeys = map[string]func(acc Account) string{
+	"path": func(acc Account) string { return acc.Path },
+	"name": func(acc Account) string { return acc.Name },
+	"type": func(acc Account) string { return string(acc.Type) },
 }
 
-func GetAccounts(nameFilter string, typeFilter string, limit int) ([]Account, error) {
-	sql := "SELECT * FROM accounts"
+//ParseAccountSort returns the sort key and true for descending order
+func ParseAccountSort(sort string) (func(acc Account) string, bool, error) {
+	desc := strings.HasPrefix(sort, "-")
+	sort = strings.TrimPrefix(sort, "-")
+	if sort == "" {
+		sort = "path"
+	}
+	key, ok := accountSortKeys[sort]
+	if !ok {
+		return nil, false, errors.Errorf("cannot sort accounts on \"%s\", expects path, name or type", sort)
+	}
+	return key, desc, nil
+}
+
+//AccountCursor identifies the position of the account in the list sorted
+//on sort, so the next page starts after it even when accounts before it
+//were added or deleted
+func AccountCursor(acc Account, sort string) string {
+	key, _, err := ParseAccountSort(sort)
+	if err != nil {
+		return ""
+	}
+	return base64.RawURLEncoding.EncodeToString([]byte(key(acc) + "|" + acc.ID))
+}
+
+//ParseAccountCursor returns the sort value and id in the cursor
+func ParseAccountCursor(cursor string) (string, string, error) {
+	b, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return "", "", errors.Errorf("invalid cursor")
+	}
+	i := strings.LastIndex(string(b), "|")
+	if i < 0 || i == len(b)-1 {
+		return "", "", errors.Errorf("invalid cursor")
+	}
+	return string(b[:i]), string(b[i+1:]), nil
+}
+
+//PageAccounts sorts the accounts and returns the page selected by the
+//filter's Sort, After, Offset and Limit
+func PageAccounts(accList []Account, filter AccountFilter) ([]Account, error) {
+	key, desc, err := ParseAccountSort(filter.Sort)
+	if err != nil {
+		return nil, err
+	}
+	//id breaks ties so the order is the same on every page
+	before := func(k1, id1, k2, id2 string) bool {
+		if k1 != k2 {
+			return (k1 < k2) != desc
+		}
+		return (id1 < id2) != desc
+	}
+	sort.Slice(accList, func(i, j int) bool {
+		return before(key(accList[i]), accList[i].ID, key(accList[j]), accList[j].ID)
+	})
+	if filter.After != "" {
+		afterKey, afterID, err := ParseAccountCursor(filter.After)
+		if err != nil {
+			return nil, err
+		}
+		i := sort.Search(len(accList), func(i int) bool {
+			return before(afterKey, afterID, key(accList[i]), accList[i].ID)
+		})
+		accList = accList[i:]
+	}
+	if filter.Offset > 0 {
+		if filter.Offset > len(accList) {
+			filter.Offset = len(accList)
+		}
+		accList = accList[filter.Offset:]
+	}
+	if filter.Limit > 0 && len(accList) > filter.Limit {
+		accList = accList[:filter.Limit]
+	}
+	return accList, nil
+} //PageAccounts()
+
+func GetAccounts(filter AccountFilter) ([]Account, error) {
+	sql := accountSelect
 	args := []interface{}{}
 
 	filters := []string{}
-	if nameFilter != "" {
+	if filter.Name != "" {
 		filters = append(filters, "name like ?")
-		args = append(args, "%"+nameFilter+"%")
+		args = append(args, "%"+db.LikeEscape(filter.Name)+"%")
 	}
-	if typeFilter != "" {
+	if filter.Type != "" {
 		filters = append(filters, "type like ?")
-		args = append(args, "%"+typeFilter+"%")
+		args = append(args, "%"+db.LikeEscape(filter.Type)+"%")
+	}
+	if filter.UnderID != "" {
+		sql = "WITH RECURSIVE subtree AS (" +
+			"SELECT id FROM `accounts` WHERE id=?" +
+			" UNION ALL SELECT a.id FROM `accounts` AS a JOIN subtree ON a.parent_id=subtree.id" +
+			") " + sql
+		args = append([]interface{}{filter.UnderID}, args...)
+		filters = append(filters, "id IN (SELECT id FROM subtree)")
 	}
 	if len(filters) > 0 {
 		sql += " WHERE " + strings.Join(filters, " AND ")
 	}
 
-	sql += " ORDER BY name"
-	if limit <= 0 {
-		limit = 10
+	//paths are not stored, so all matching accounts are sorted and paged here
+	accList := []Account{}
+	if err := db.Db().Select(&accList, sql, args...); err != nil {
+		return nil, errors.Wrapf(err, "failed to get list of accounts(%+v)", filter)
 	}
-	sql += " LIMIT ?"
-	args = append(args, limit)
+	if err := setPaths(accList); err != nil {
+		return nil, err
+	}
+	return PageAccounts(accList, filter)
+} //GetAccounts()
+
+//getAllAccounts returns all accounts (with paths) indexed by id
+func getAllAccounts() (map[string]Account, error) {
 	var accList []Account
-	if err := db.Db().Select(&accList, sql, args...); err != nil {
-		return nil, errors.Wrapf(err, "failed to get list of accounts(%s,%s,%d)", nameFilter, typeFilter, limit)
+	if err := db.Db().Select(&accList, accountSelect); err != nil {
+		return nil, errors.Wrapf(err, "failed to get accounts")
 	}
-	return accList, nil
+	accByID := map[string]Account{}
+	for _, acc := range accList {
+		accByID[acc.ID] = acc
+	}
+	for id, acc := range accByID {
+		acc.Path = accountPath(accByID, acc)
+		accByID[id] = acc
+	}
+	return accByID, nil
 }
 
-func GetAccount(id string) (*Account, error) {
+//accountPath joins the names from the root to acc
+func accountPath(accByID map[string]Account, acc Account) string {
+	path := acc.Name
+	seen := map[string]bool{acc.ID: true}
+	for acc.ParentID != "" && !seen[acc.ParentID] {
+		parent, ok := accByID[acc.ParentID]
+		if !ok {
+			break
+		}
+		path = parent.Name + PathSeparator + path
+		seen[parent.ID] = true
+		acc = parent
+	}
+	return path
+}
+
+func setPaths(accList []Account) error {
+	accByID, err := getAllAccounts()
+	if err != nil {
+		return err
+	}
+	for i := range accList {
+		accList[i].Path = accountPath(accByID, accList[i])
+	}
+	return nil
+}
+
+//descendantIDs lists the ids of all accounts under the account
+func descendantIDs(accByID map[string]Account, id string) []string {
+	ids := []string{}
+	for _, acc := range accByID {
+		if acc.ParentID == id {
+			ids = append(ids, acc.ID)
+			ids = append(ids, descendantIDs(accByID, acc.ID)...)
+		}
+	}
+	return ids
+}
+
+func setTypeOfAccounts(exec sqlx.Execer, ids []string, accountType AccountType) error {
+	for _, id := range ids {
+		if _, err := exec.Exec("UPDATE `accounts` SET type=? WHERE id=?", accountType, id); err != nil {
+			return errors.Wrapf(err, "failed to update account(%s) type", id)
+		}
+	}
+	return nil
+}
+
+//getAccountWhere returns nil,nil when not found
+func getAccountWhere(where string, arg interface{}) (*Account, error) {
 	var acc Account
-	if err := db.Db().Get(&acc,
-		"SELECT id,name,type FROM accounts WHERE id=?",
-		id,
-	); err != nil {
+	if err := db.Db().Get(&acc, accountSelect+" WHERE "+where, arg); err != nil {
 		if err == sql.ErrNoRows {
 			return nil, nil
 		}
 		return nil, errors.Wrapf(err, "failed to select account")
 	}
-	return &acc, nil
+	list := []Account{acc}
+	if err := setPaths(list); err != nil {
+		return nil, err
+	}
+	return &list[0], nil
 }
 
+func GetAccount(id string) (*Account, error) {
+	return getAccountWhere("id=?", id)
+}
+
+//GetAccountByName returns nil,nil when not found, and fails when accounts
+//under different parents have the name, e.g. Expenses:Fuel and Business:Fuel
 func GetAccountByName(name string) (*Account, error) {
-	var acc Account
-	if err := db.Db().Get(&acc,
-		"SELECT id,name,type FROM accounts WHERE name=?",
-		name,
-	); err != nil {
-		if err == sql.ErrNoRows {
-			return nil, nil
+	var list []Account
+	if err := db.Db().Select(&list, accountSelect+" WHERE name=?", name); err != nil {
+		return nil, errors.Wrapf(err, "failed to select accounts")
+	}
+	if len(list) == 0 {
+		return nil, nil
+	}
+	if err := setPaths(list); err != nil {
+		return nil, err
+	}
+	if len(list) > 1 {
+		paths := []string{}
+		for _, acc := range list {
+			paths = append(paths, acc.Path)
 		}
-		return nil, errors.Wrapf(err, "failed to select account")
+		return nil, errors.Errorf("%d accounts named \"%s\", use the path: %s", len(list), name, strings.Join(paths, ", "))
 	}
-	return &acc, nil
+	return &list[0], nil
 }
 
+//GetAccountByPath finds the account from its path of names
+//e.g. "Expenses:Car:Diesel", returning nil,nil when not found
+func GetAccountByPath(path string) (*Account, error) {
+	accByID, err := getAllAccounts()
+	if err != nil {
+		return nil, err
+	}
+	for _, acc := range accByID {
+		if acc.Path == path {
+			return &acc, nil
+		}
+	}
+	return nil, nil
+}
+
+//SplitAccountPath returns the names in the path without surrounding spaces
+//so that "Expenses : Car" is the same path as "Expenses:Car"
+func SplitAccountPath(path string) []string {
+	names := strings.Split(path, PathSeparator)
+	for i := range names {
+		names[i] = strings.TrimSpace(names[i])
+	}
+	return names
+}
+
+//CreateAccountPath returns the account at the path, creating missing
+//accounts along the path. New root accounts get accountType, others
+//inherit the type of their parent.
+func CreateAccountPath(path string, accountType AccountType) (*Account, error) {
+	names := SplitAccountPath(path)
+	var parent *Account
+	for i := range names {
+		acc, err := GetAccountByPath(strings.Join(names[:i+1], PathSeparator))
+		if err != nil {
+			return nil, err
+		}
+		if acc == nil {
+			acc = &Account{Name: names[i], Type: accountType}
+			if parent != nil {
+				acc.ParentID = parent.ID
+				acc.Type = ""
+			}
+			if err := acc.Save(); err != nil {
+				return nil, errors.Wrapf(err, "failed to create account(%s)", strings.Join(names[:i+1], PathSeparator))
+			}
+		}
+		parent = acc
+	}
+	return parent, nil
+} //CreateAccountPath()
+
+//Save creates or updates the account, with a ValidationError when the
+//name, type or parent is not valid
 func (acc *Account) Save() error {
 	if acc.Name == "" {
-		return errors.Errorf("missing name")
+		return invalidf("name", "missing name")
+	}
+	if strings.Contains(acc.Name, PathSeparator) {
+		return invalidf("name", "name \"%s\" may not contain \"%s\"", acc.Name, PathSeparator)
+	}
+	if acc.ParentID != "" {
+		//type is inherited from the parent
+		parent, err := GetAccount(acc.ParentID)
+		if err != nil {
+			return errors.Wrapf(err, "failed to get parent account")
+		}
+		if parent == nil {
+			return invalidf("parent_id", "parent account(%s) not found", acc.ParentID)
+		}
+		if acc.Type != "" && acc.Type != parent.Type {
+			return invalidf("type", "type %s differs from parent type %s", acc.Type, parent.Type)
+		}
+		acc.Type = parent.Type
+		if acc.ID != "" {
+			//parent may not be the account itself or one of its descendants
+			for p := parent; p != nil; {
+				if p.ID == acc.ID {
+					return invalidf("parent_id", "parent(%s) cannot be under account(%s)", parent.Name, acc.Name)
+				}
+				if p.ParentID == "" {
+					break
+				}
+				if p, err = GetAccount(p.ParentID); err != nil {
+					return errors.Wrapf(err, "failed to get parent account")
+				}
+			}
+		}
+		acc.Path = parent.Path + PathSeparator + acc.Name
+	} else {
+		acc.Path = acc.Name
 	}
 	if acc.Type == "" {
-		return errors.Errorf("missing type")
+		return invalidf("type", "missing type")
+	}
+	if err := acc.Type.Validate(); err != nil {
+		return invalidf("type", "%s", err)
 	}
 	if acc.ID == "" {
 		id := uuid.New().String()
-		if _, err := db.Db().Exec("INSERT INTO `accounts` SET id=?, name=?, type=?",
+		if _, err := db.Db().Exec("INSERT INTO `accounts` SET id=?, name=?, type=?, parent_id=?",
 			id,
 			acc.Name,
 			acc.Type,
+			nullIfEmpty(acc.ParentID),
 		); err != nil {
+			if db.IsDup(err) {
+				return errors.Wrapf(ErrAccountNameExists, "cannot create account(%s)", acc.Name)
+			}
 			return errors.Wrapf(err, "failed to insert account")
 		}
 		acc.ID = id
-		log.Infof("Inserted bank_account(%s)", acc.ID)
+		log.Infof("Inserted account(%s)", acc.ID)
 	} else {
-		if result, err := db.Db().Exec("UPDATE `accounts` SET name=?,type=? WHERE id=?",
+		if result, err := db.Db().Exec("UPDATE `accounts` SET name=?,type=?,parent_id=? WHERE id=?",
 			acc.Name,
 			acc.Type,
+			nullIfEmpty(acc.ParentID),
 			acc.ID,
 		); err != nil {
+			if db.IsDup(err) {
+				return errors.Wrapf(ErrAccountNameExists, "cannot rename account(%s) to %s", acc.ID, acc.Name)
+			}
 			return errors.Wrapf(err, "failed to update account")
 		} else {
 			if nr, _ := result.RowsAffected(); nr != 1 {
-				return errors.Errorf("updated %d account rows")
+				return errors.Errorf("updated %d account rows", nr)
 			}
 		}
+		//descendants inherit the type
+		accByID, err := getAllAccounts()
+		if err != nil {
+			return err
+		}
+		if err := setTypeOfAccounts(db.Db(), descendantIDs(accByID, acc.ID), acc.Type); err != nil {
+			return errors.Wrapf(err, "failed to update type of sub accounts")
+		}
 	}
 	return nil
 } //Account.Save()
+
+//accountReferences count the records that refer to an account
+var accountReferences = []struct {
+	name  string
+	count string
+}{
+	{"transactions", "SELECT COUNT(DISTINCT transaction_id) FROM `postings` WHERE account_id=?"},
+	{"sub accounts", "SELECT COUNT(*) FROM `accounts` WHERE parent_id=?"},
+	{"bank accounts", "SELECT COUNT(*) FROM `bank_accounts` WHERE account_id=?"},
+	{"rules", "SELECT COUNT(*) FROM `rules` WHERE account_id=?"},
+	{"budgets", "SELECT COUNT(*) FROM `budgets` WHERE account_id=?"},
+	{"envelopes", "SELECT COUNT(*) FROM `envelopes` WHERE account_id=?"},
+	{"scheduled transactions", "SELECT COUNT(*) FROM `scheduled_transactions` WHERE account_id=?"},
+	{"transfers", "SELECT COUNT(*) FROM `transfers` AS tr JOIN `transactions` AS t ON t.id=tr.in_transaction_id" +
+		" WHERE ? IN (tr.out_dt_account_id,t.dt_account_id,t.ct_account_id)"},
+}
+
+//DeleteAccount deletes an account that is not used, else it fails with
+//ErrAccountInUse. Use MergeAccounts to move its transactions first.
+func DeleteAccount(id string) (err error) {
+	tx, err := db.Db().Beginx()
+	if err != nil {
+		return errors.Wrapf(err, "failed to begin db transaction")
+	}
+	defer func() {
+		if err != nil {
+			tx.Rollback()
+		}
+	}()
+	inUse := []string{}
+	for _, ref := range accountReferences {
+		var nr int
+		if err = tx.Get(&nr, ref.count, id); err != nil {
+			return errors.Wrapf(err, "failed to count %s of account", ref.name)
+		}
+		if nr > 0 {
+			inUse = append(inUse, fmt.Sprintf("%d %s", nr, ref.name))
+		}
+	}
+	if len(inUse) > 0 {
+		err = errors.Wrapf(ErrAccountInUse, "cannot delete account(%s) with %s", id, strings.Join(inUse, ", "))
+		return err
+	}
+	result, err := tx.Exec("DELETE FROM `accounts` WHERE id=?", id)
+	if err != nil {
+		return errors.Wrapf(err, "failed to delete account")
+	}
+	if nr, _ := result.RowsAffected(); nr != 1 {
+		err = notFoundf("account(%s) not found", id)
+		return err
+	}
+	if err = tx.Commit(); err != nil {
+		return errors.Wrapf(err, "failed to commit")
+	}
+	log.Infof("Deleted account(%s)", id)
+	return nil
+} //DeleteAccount()
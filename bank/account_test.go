@@ -0,0 +1,75 @@
+package bank_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/jansemmelink/money/bank"
+)
+
+func TestPageAccounts(t *testing.T) {
+	accounts := func() []bank.Account {
+		return []bank.Account{
+			{ID: "1", Name: "Expenses", Type: bank.AccountType("expense"), Path: "Expenses"},
+			{ID: "2", Name: "Car", Type: bank.AccountType("expense"), Path: "Expenses:Car"},
+			{ID: "3", Name: "Cheque", Type: bank.AccountType("asset"), Path: "Cheque"},
+			{ID: "4", Name: "Salary", Type: bank.AccountType("income"), Path: "Salary"},
+		}
+	}
+	ids := func(list []bank.Account) string {
+		s := []string{}
+		for _, acc := range list {
+			s = append(s, acc.ID)
+		}
+		return strings.Join(s, ",")
+	}
+	for _, test := range []struct {
+		filter   bank.AccountFilter
+		expected string
+	}{
+		{bank.AccountFilter{}, "3,1,2,4"},
+		{bank.AccountFilter{Sort: "name"}, "2,3,1,4"},
+		{bank.AccountFilter{Sort: "-name"}, "4,1,3,2"},
+		{bank.AccountFilter{Sort: "type"}, "3,1,2,4"},
+		{bank.AccountFilter{Sort: "-type"}, "4,2,1,3"},
+		{bank.AccountFilter{Offset: 1, Limit: 2}, "1,2"},
+		{bank.AccountFilter{Offset: 10}, ""},
+	} {
+		list, err := bank.PageAccounts(accounts(), test.filter)
+		assert(t, err)
+		if ids(list) != test.expected {
+			t.Errorf("%+v: %s != %s", test.filter, ids(list), test.expected)
+		}
+	}
+
+	//the next page starts after the cursor even when an account before it was deleted
+	page, err := bank.PageAccounts(accounts(), bank.AccountFilter{Sort: "name", Limit: 2})
+	assert(t, err)
+	cursor := bank.AccountCursor(page[1], "name")
+	withoutCar := append(accounts()[:1], accounts()[2:]...)
+	list, err := bank.PageAccounts(withoutCar, bank.AccountFilter{Sort: "name", After: cursor})
+	assert(t, err)
+	if ids(list) != "1,4" {
+		t.Errorf("after %s: %s", page[1].Name, ids(list))
+	}
+
+	if _, err := bank.PageAccounts(accounts(), bank.AccountFilter{Sort: "balance"}); err == nil {
+		t.Errorf("sorted on unknown key")
+	}
+	if _, err := bank.PageAccounts(accounts(), bank.AccountFilter{After: "x"}); err == nil {
+		t.Errorf("accepted invalid cursor")
+	}
+}
+
+func TestSplitAccountPath(t *testing.T) {
+	for path, expected := range map[string]string{
+		"Expenses:Car":        "Expenses|Car",
+		" Expenses : Car ":    "Expenses|Car",
+		"Expenses:Car:Diesel": "Expenses|Car|Diesel",
+		"Salary":              "Salary",
+	} {
+		if names := strings.Join(bank.SplitAccountPath(path), "|"); names != expected {
+			t.Errorf("%s: %s != %s", path, names, expected)
+		}
+	}
+}
@@ -3,6 +3,7 @@ package bank
 import (
 	"database/sql/driver"
 	"fmt"
+	"math"
 	"strconv"
 	"strings"
 
@@ -19,118 +20,125 @@ type Amount struct {
 func NewAmount(v interface{}) (Amount, error) {
 	switch v := v.(type) {
 	case string:
-		s := strings.Trim(v, " \t")
-		sign := int64(1)
-		if s[0] == '-' {
-			sign = -1
-			s = s[1:]
-		}
-		parts := strings.SplitN(s, ".", 2)
-		vMain := int64(0)
-		if len(parts) > 0 {
-			var err error
-			vMain, err = strconv.ParseInt(parts[0], 10, 64)
-			if err != nil {
-				return Amount{}, fmt.Errorf("invalid main=\"%s\"", parts[0])
-			}
-		}
-		vCents := int64(0)
-		if len(parts) > 1 {
-			var err error
-			for len(parts[1]) < 3 {
-				parts[1] = parts[1] + "0"
-			}
-			vCents, err = strconv.ParseInt(parts[1], 10, 64)
-			if err != nil {
-				return Amount{}, fmt.Errorf("invalid cents=\"%s\"", parts[1])
-			}
-		}
-		aa := Amount{mc: sign * (vMain*1000 + vCents)}
-		log.Debugf("\"%s\" -> %v %v %v -> {%d}=%v", v, sign, vMain, vCents, aa.mc, aa)
-		return aa, nil
+		return parseAmount(v)
 	case int:
-		return Amount{mc: int64(v) * 100000}, nil
+		return Amount{mc: int64(v) * 1000}, nil
 	case int8:
-		return Amount{mc: int64(v) * 100000}, nil
+		return Amount{mc: int64(v) * 1000}, nil
 	case uint8:
-		return Amount{mc: int64(v) * 100000}, nil
+		return Amount{mc: int64(v) * 1000}, nil
 	case int16:
-		return Amount{mc: int64(v) * 100000}, nil
+		return Amount{mc: int64(v) * 1000}, nil
 	case uint16:
-		return Amount{mc: int64(v) * 100000}, nil
+		return Amount{mc: int64(v) * 1000}, nil
 	case int32:
-		return Amount{mc: int64(v) * 100000}, nil
+		return Amount{mc: int64(v) * 1000}, nil
 	case uint32:
-		return Amount{mc: int64(v) * 100000}, nil
+		return Amount{mc: int64(v) * 1000}, nil
 	case int64:
-		return Amount{mc: int64(v) * 100000}, nil
+		return Amount{mc: int64(v) * 1000}, nil
 	case uint64:
-		return Amount{mc: int64(v) * 100000}, nil
+		return Amount{mc: int64(v) * 1000}, nil
 	case float32:
-		return Amount{mc: int64(v) * 100000}, nil
+		return Amount{mc: int64(math.Round(float64(v) * 1000))}, nil
 	case float64:
-		return Amount{mc: int64(v) * 100000}, nil
+		return Amount{mc: int64(math.Round(v * 1000))}, nil
 	}
 	return Amount{}, fmt.Errorf("(%T)%v is not a valid amount", v, v)
 }
 
+//parse "123", "-123.4" or "123.45" in main currency
+func parseAmount(v string) (Amount, error) {
+	s := strings.Trim(v, " \t")
+	if s == "" {
+		return Amount{}, fmt.Errorf("empty amount")
+	}
+	sign := int64(1)
+	if s[0] == '-' {
+		sign = -1
+		s = s[1:]
+	}
+	parts := strings.SplitN(s, ".", 2)
+	vMain := int64(0)
+	if len(parts) > 0 {
+		var err error
+		vMain, err = strconv.ParseInt(parts[0], 10, 64)
+		if err != nil {
+			return Amount{}, fmt.Errorf("invalid main=\"%s\"", parts[0])
+		}
+	}
+	vCents := int64(0)
+	if len(parts) > 1 {
+		var err error
+		for len(parts[1]) < 3 {
+			parts[1] = parts[1] + "0"
+		}
+		if len(parts[1]) > 3 {
+			return Amount{}, fmt.Errorf("invalid cents=\"%s\" (max 3 decimals)", parts[1])
+		}
+		vCents, err = strconv.ParseInt(parts[1], 10, 64)
+		if err != nil {
+			return Amount{}, fmt.Errorf("invalid cents=\"%s\"", parts[1])
+		}
+	}
+	return Amount{mc: sign * (vMain*1000 + vCents)}, nil
+}
+
 func (a Amount) MilliCents() int64 {
 	return a.mc
 }
 
 func (a Amount) Cents() int64 {
-	return a.mc / 1000
+	return a.mc / 10
 }
 
 func (a Amount) Add(b Amount) Amount {
-	aa := Amount{mc: a.mc + b.mc}
-	log.Debugf("%s + %s = %s", a, b, aa)
-	return aa
+	return Amount{mc: a.mc + b.mc}
 }
 
 func (a Amount) Sub(b Amount) Amount {
 	return Amount{mc: a.mc - b.mc}
 }
 
+//Neg returns the amount with the opposite sign
+func (a Amount) Neg() Amount {
+	return Amount{mc: -a.mc}
+}
+
+//Abs returns the amount without sign
+func (a Amount) Abs() Amount {
+	if a.mc < 0 {
+		return Amount{mc: -a.mc}
+	}
+	return a
+}
+
+func (a Amount) IsZero() bool {
+	return a.mc == 0
+}
+
 func (a Amount) String() string {
-	m := a.mc / 1000
-	c := (a.mc % 1000) / 10
-	if c < 0 {
-		c = -c
+	mc := a.mc
+	sign := ""
+	if mc < 0 {
+		sign = "-"
+		mc = -mc
 	}
-	return fmt.Sprintf("%d.%02d", m, c)
+	return fmt.Sprintf("%s%d.%02d", sign, mc/1000, (mc%1000)/10)
 }
 
 func (a *Amount) Scan(value interface{}) error {
 	if byteArray, ok := value.([]uint8); ok {
-		strValue := string(byteArray)
-		//if int value - assume
-		i64, err := strconv.ParseInt(strValue, 10, 64)
-		if err == nil {
-			//simple integer value is full currency unit, i.e. "1" = 100*1000 millicents
-			i64 *= 100000 //=100c*1000mc/c
-		} else {
-			//not simple int - assume currentcy with decimal
-			//e.g. 123.45 = 12345000 millicents
-			dotIndex := strings.Index(strValue, ".")
-			if dotIndex < 0 {
-				return errors.Errorf("\"%s\" is not formatted as \"123.45\" or \"123\"")
-			}
-			nrDec := len(strValue) - dotIndex - 1 //e.g. 6 for "123.45" with dotIndex=3, or 4 for "1.23" and dotIndex=1 or 7 for "1234.56" and dotIndex=4
-			s := strValue[0:dotIndex] + strValue[dotIndex+1:]
-			i64, err := strconv.ParseInt(strValue, 10, 64)
-			if err != nil {
-				return errors.Errorf("\"%s\" removed dot -> \"%s\" is not valid int", strValue, s)
-			} else {
-				for nrDec < 5 {
-					i64 *= 10
-					nrDec--
-				}
-			}
+		aa, err := parseAmount(string(byteArray))
+		if err != nil {
+			return errors.Errorf("\"%s\" is not formatted as \"123.45\" or \"123\": %v", string(byteArray), err)
 		}
-		a.mc = i64
+		*a = aa
 		return nil
 	} //if []byte
+	if s, ok := value.(string); ok {
+		return a.Scan([]uint8(s))
+	}
 	if value == nil {
 		a.mc = 0
 		return nil
@@ -0,0 +1,48 @@
+package bank_test
+
+import (
+	"testing"
+
+	"github.com/jansemmelink/money/bank"
+)
+
+func TestAmount(t *testing.T) {
+	for s, expected := range map[string]string{
+		"0":         "0.00",
+		"123":       "123.00",
+		"-211.22":   "-211.22",
+		"-0.5":      "-0.50",
+		"44608.60":  "44608.60",
+		"-45775.73": "-45775.73",
+	} {
+		a, err := bank.NewAmount(s)
+		assert(t, err)
+		if a.String() != expected {
+			t.Fatalf("\"%s\" -> %s != %s", s, a, expected)
+		}
+		var scanned bank.Amount
+		assert(t, scanned.Scan([]uint8(a.String())))
+		if scanned != a {
+			t.Fatalf("scanned %s != %s", scanned, a)
+		}
+	}
+
+	a, _ := bank.NewAmount(12)
+	b, _ := bank.NewAmount("0.75")
+	if a.Add(b).String() != "12.75" || a.Sub(b).String() != "11.25" || b.Neg().Abs() != b {
+		t.Fatalf("wrong arithmetic on %s and %s", a, b)
+	}
+}
+
+func assert(t *testing.T, err error) {
+	if err != nil {
+		t.Fatalf("failed: %+v", err)
+	}
+}
+
+//amount parses s or fails the test
+func amount(t *testing.T, s string) bank.Amount {
+	a, err := bank.NewAmount(s)
+	assert(t, err)
+	return a
+}
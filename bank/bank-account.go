@@ -38,6 +38,24 @@ func GetBankAccount(bankName string, accountNumber string) (*BankAccount, error)
 	return &ba, nil
 }
 
+//GetBankAccounts lists all bank accounts ordered by bank and account number
+func GetBankAccounts() ([]BankAccount, error) {
+	var list []BankAccount
+	if err := db.Db().Select(&list,
+		"SELECT id, account_id, bank_name, IFNULL(branch_name,'') AS branch_name, IFNULL(branch_code,'') AS branch_code, account_number FROM bank_accounts ORDER BY bank_name,account_number",
+	); err != nil {
+		return nil, errors.Wrapf(err, "failed to select bank_accounts")
+	}
+	for i, ba := range list {
+		acc, err := GetAccount(ba.AccountID)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed in GetAccount(%s)", ba.AccountID)
+		}
+		list[i].Account = acc
+	}
+	return list, nil
+}
+
 func (ba *BankAccount) Save() error {
 	if ba.BankName == "" {
 		return errors.Errorf("missing bank_name")
@@ -90,7 +108,7 @@ func (ba *BankAccount) Save() error {
 			return errors.Wrapf(err, "failed to update bank_account")
 		} else {
 			if nr, _ := result.RowsAffected(); nr != 1 {
-				return errors.Errorf("updated %d account rows")
+				return errors.Errorf("updated %d account rows", nr)
 			}
 		}
 		log.Infof("Updated bank_account(%s)", ba.ID)
@@ -0,0 +1,292 @@
+package bank
+
+import (
+	"database/sql"
+	"sort"
+	"time"
+
+	"github.com/go-msvc/errors"
+	"github.com/google/uuid"
+	"github.com/jansemmelink/money/db"
+	"github.com/jmoiron/sqlx"
+)
+
+//MonthFormat is the format of budget months e.g. "2021-03"
+const MonthFormat = "2006-01"
+
+//Budget is the amount planned for an account (and its sub accounts) in a month.
+//With CarryOver the amount left (or overspent) in the previous month is added.
+type Budget struct {
+	ID          string `db:"id" json:"id"`
+	AccountID   string `db:"account_id" json:"account_id"`
+	AccountName string `db:"account_name" json:"account_name"`
+	Month       string `db:"month" json:"month"`
+	Amount      Amount `db:"amount" json:"amount"`
+	CarryOver   bool   `db:"carry_over" json:"carry_over"`
+}
+
+const budgetSelect = "SELECT b.id,b.account_id,a.name AS account_name,b.month,b.amount,b.carry_over" +
+	" FROM `budgets` AS b" +
+	" JOIN `accounts` AS a ON a.id=b.account_id"
+
+//ParseMonth parses "CCYY-MM" to the first day of the month in local time
+func ParseMonth(s string) (time.Time, error) {
+	t, err := time.ParseInLocation(MonthFormat, s, time.Local)
+	if err != nil {
+		return time.Time{}, errors.Errorf("invalid month \"%s\" expects CCYY-MM", s)
+	}
+	return t, nil
+}
+
+//previousMonth of a valid "CCYY-MM"
+func previousMonth(month string) string {
+	t, _ := ParseMonth(month)
+	return t.AddDate(0, -1, 0).Format(MonthFormat)
+}
+
+//GetBudgets lists the budgets of a month by account name
+func GetBudgets(month string) ([]Budget, error) {
+	var list []Budget
+	if err := db.Db().Select(&list, budgetSelect+" WHERE b.month=? ORDER BY a.name", month); err != nil {
+		return nil, errors.Wrapf(err, "failed to get budgets of %s", month)
+	}
+	return list, nil
+}
+
+//GetBudget returns nil,nil when not found
+func GetBudget(accountID, month string) (*Budget, error) {
+	var b Budget
+	if err := db.Db().Get(&b, budgetSelect+" WHERE b.account_id=? AND b.month=?", accountID, month); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, errors.Wrapf(err, "failed to select budget")
+	}
+	return &b, nil
+}
+
+func (b *Budget) Validate() error {
+	if b.AccountID == "" {
+		return invalidf("account_id", "missing account_id")
+	}
+	if _, err := ParseMonth(b.Month); err != nil {
+		return invalidf("month", "%s", err)
+	}
+	if b.Amount.MilliCents() < 0 {
+		return invalidf("amount", "negative amount %s", b.Amount)
+	}
+	return nil
+}
+
+//Save inserts the budget or replaces the budget of the same account and month
+func (b *Budget) Save() error {
+	if err := b.Validate(); err != nil {
+		return errors.Wrapf(err, "invalid budget")
+	}
+	existing, err := GetBudget(b.AccountID, b.Month)
+	if err != nil {
+		return err
+	}
+	if existing == nil {
+		id := uuid.New().String()
+		if _, err := db.Db().Exec("INSERT INTO `budgets` SET id=?,account_id=?,month=?,amount=?,carry_over=?",
+			id, b.AccountID, b.Month, b.Amount, b.CarryOver,
+		); err != nil {
+			return errors.Wrapf(err, "failed to insert budget")
+		}
+		b.ID = id
+		log.Infof("Inserted budget(%s)", b.ID)
+	} else {
+		if _, err := db.Db().Exec("UPDATE `budgets` SET amount=?,carry_over=? WHERE id=?",
+			b.Amount, b.CarryOver, existing.ID,
+		); err != nil {
+			return errors.Wrapf(err, "failed to update budget")
+		}
+		b.ID = existing.ID
+		log.Infof("Updated budget(%s)", b.ID)
+	}
+	return nil
+} //Budget.Save()
+
+func DeleteBudget(accountID, month string) error {
+	result, err := db.Db().Exec("DELETE FROM `budgets` WHERE account_id=? AND month=?", accountID, month)
+	if err != nil {
+		return errors.Wrapf(err, "failed to delete budget")
+	}
+	if nr, _ := result.RowsAffected(); nr != 1 {
+		return notFoundf("no budget for account(%s) in %s", accountID, month)
+	}
+	return nil
+}
+
+//CopyBudgets copies the budgets of the previous month into month and returns
+//the copied budgets. Existing budgets in month are only replaced with overwrite.
+func CopyBudgets(month string, overwrite bool) ([]Budget, error) {
+	if _, err := ParseMonth(month); err != nil {
+		return nil, err
+	}
+	prev, err := GetBudgets(previousMonth(month))
+	if err != nil {
+		return nil, err
+	}
+	copied := []Budget{}
+	for _, b := range prev {
+		if !overwrite {
+			existing, err := GetBudget(b.AccountID, month)
+			if err != nil {
+				return copied, err
+			}
+			if existing != nil {
+				continue
+			}
+		}
+		b.ID = ""
+		b.Month = month
+		if err := b.Save(); err != nil {
+			return copied, err
+		}
+		copied = append(copied, b)
+	}
+	return copied, nil
+} //CopyBudgets()
+
+//BudgetLine compares the budget of an account in a month with the actual
+//rolled up amount posted to the account and its sub accounts
+type BudgetLine struct {
+	Budget
+	CarriedOver Amount  `json:"carried_over"` //left over from the previous month (negative if overspent)
+	Available   Amount  `json:"available"`    //amount + carried over
+	Actual      Amount  `json:"actual"`       //normal balance of postings in the month
+	Variance    Amount  `json:"variance"`     //available - actual, negative when over budget
+	PercentUsed float64 `json:"percent_used"` //actual as % of available
+}
+
+//GetBudgetReport compares the budgets of month with the actual amounts
+func GetBudgetReport(month string) ([]BudgetLine, error) {
+	monthStart, err := ParseMonth(month)
+	if err != nil {
+		return nil, err
+	}
+	//budgets up to month are needed to carry over amounts
+	var budgets []Budget
+	if err := db.Db().Select(&budgets, budgetSelect+
+		" WHERE b.account_id IN (SELECT account_id FROM `budgets` WHERE month=?) AND b.month<=?"+
+		" ORDER BY b.month",
+		month, month,
+	); err != nil {
+		return nil, errors.Wrapf(err, "failed to get budgets")
+	}
+	if len(budgets) == 0 {
+		return []BudgetLine{}, nil
+	}
+	firstMonth, err := ParseMonth(budgets[0].Month)
+	if err != nil {
+		return nil, err
+	}
+	actuals, err := getMonthlyActuals(firstMonth, monthStart.AddDate(0, 1, 0).Add(-time.Second))
+	if err != nil {
+		return nil, err
+	}
+	return CompareBudgets(month, budgets, actuals), nil
+} //GetBudgetReport()
+
+//getMonthlyActuals sums the normal balance of postings per month and account,
+//rolled up to all parent accounts
+func getMonthlyActuals(from, to time.Time) (map[string]map[string]Amount, error) {
+	accByID, err := getAllAccounts()
+	if err != nil {
+		return nil, err
+	}
+	var rows []struct {
+		AccountID string     `db:"account_id"`
+		Date      db.SqlTime `db:"date"`
+		Amount    Amount     `db:"amount"`
+	}
+	if err := db.Db().Select(&rows, "SELECT p.account_id,t.date,p.amount"+
+		" FROM `postings` AS p"+
+		" JOIN `transactions` AS t ON t.id=p.transaction_id"+
+		" WHERE t.date>=? AND t.date<=?",
+		db.SqlTime(from),
+		db.SqlTime(to),
+	); err != nil {
+		return nil, errors.Wrapf(err, "failed to get postings")
+	}
+	actuals := map[string]map[string]Amount{}
+	for _, row := range rows {
+		month := time.Time(row.Date).Local().Format(MonthFormat)
+		if actuals[month] == nil {
+			actuals[month] = map[string]Amount{}
+		}
+		seen := map[string]bool{}
+		for id := row.AccountID; id != "" && !seen[id]; id = accByID[id].ParentID {
+			seen[id] = true
+			acc, ok := accByID[id]
+			if !ok {
+				break
+			}
+			actuals[month][id] = actuals[month][id].Add(acc.Type.NormalBalance(row.Amount))
+		}
+	}
+	return actuals, nil
+} //getMonthlyActuals()
+
+//CompareBudgets makes the lines for month from the budgets sorted by month
+//and the actual amounts per month and account id. The amount left over in
+//a month is carried into the next month when that month's budget has CarryOver.
+func CompareBudgets(month string, budgets []Budget, actuals map[string]map[string]Amount) []BudgetLine {
+	lineByAccount := map[string]BudgetLine{}
+	for _, b := range budgets {
+		if b.Month > month {
+			continue
+		}
+		line := BudgetLine{Budget: b}
+		if prev, ok := lineByAccount[b.AccountID]; ok && b.CarryOver && prev.Month == previousMonth(b.Month) {
+			line.CarriedOver = prev.Variance
+		}
+		line.Available = b.Amount.Add(line.CarriedOver)
+		line.Actual = actuals[b.Month][b.AccountID]
+		line.Variance = line.Available.Sub(line.Actual)
+		if !line.Available.IsZero() {
+			line.PercentUsed = float64(line.Actual.MilliCents()) * 100 / float64(line.Available.MilliCents())
+		}
+		lineByAccount[b.AccountID] = line
+	}
+	list := []BudgetLine{}
+	for _, line := range lineByAccount {
+		if line.Month == month {
+			list = append(list, line)
+		}
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].AccountName < list[j].AccountName })
+	return list
+} //CompareBudgets()
+
+//mergeBudgets moves the budgets of account fromID to toID,
+//adding the amounts where both have a budget in the same month
+func mergeBudgets(tx *sqlx.Tx, fromID, toID string) error {
+	var list []Budget
+	if err := tx.Select(&list, budgetSelect+" WHERE b.account_id=?", fromID); err != nil {
+		return errors.Wrapf(err, "failed to get budgets")
+	}
+	for _, from := range list {
+		var to Budget
+		if err := tx.Get(&to, budgetSelect+" WHERE b.account_id=? AND b.month=?", toID, from.Month); err != nil {
+			if err != sql.ErrNoRows {
+				return errors.Wrapf(err, "failed to get budget")
+			}
+			if _, err := tx.Exec("UPDATE `budgets` SET account_id=? WHERE id=?", toID, from.ID); err != nil {
+				return errors.Wrapf(err, "failed to move budget")
+			}
+			continue
+		}
+		if _, err := tx.Exec("UPDATE `budgets` SET amount=?,carry_over=? WHERE id=?",
+			to.Amount.Add(from.Amount), to.CarryOver || from.CarryOver, to.ID,
+		); err != nil {
+			return errors.Wrapf(err, "failed to update budget")
+		}
+		if _, err := tx.Exec("DELETE FROM `budgets` WHERE id=?", from.ID); err != nil {
+			return errors.Wrapf(err, "failed to delete budget")
+		}
+	}
+	return nil
+} //mergeBudgets()
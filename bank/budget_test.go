@@ -0,0 +1,41 @@
+package bank_test
+
+import (
+	"testing"
+
+	"github.com/jansemmelink/money/bank"
+)
+
+func TestCompareBudgets(t *testing.T) {
+	budgets := []bank.Budget{
+		{AccountID: "g", AccountName: "Groceries", Month: "2021-01", Amount: amount(t, "1000")},
+		{AccountID: "d", AccountName: "Diesel", Month: "2021-01", Amount: amount(t, "500")},
+		{AccountID: "g", AccountName: "Groceries", Month: "2021-02", Amount: amount(t, "1000"), CarryOver: true},
+		{AccountID: "d", AccountName: "Diesel", Month: "2021-02", Amount: amount(t, "500")},
+		{AccountID: "g", AccountName: "Groceries", Month: "2021-03", Amount: amount(t, "1000"), CarryOver: true},
+	}
+	actuals := map[string]map[string]bank.Amount{
+		"2021-01": {"g": amount(t, "800"), "d": amount(t, "600")},
+		"2021-02": {"g": amount(t, "1100"), "d": amount(t, "400")},
+		"2021-03": {"g": amount(t, "550")},
+	}
+	feb := bank.CompareBudgets("2021-02", budgets, actuals)
+	if len(feb) != 2 || feb[0].AccountName != "Diesel" || feb[1].AccountName != "Groceries" {
+		t.Fatalf("feb lines %+v", feb)
+	}
+	//diesel does not carry over the overspending of january
+	if d := feb[0]; d.CarriedOver.String() != "0.00" || d.Variance.String() != "100.00" || d.PercentUsed != 80 {
+		t.Errorf("diesel %+v", d)
+	}
+	//groceries carries 200 from january
+	if g := feb[1]; g.CarriedOver.String() != "200.00" || g.Available.String() != "1200.00" || g.Variance.String() != "100.00" {
+		t.Errorf("groceries %+v", g)
+	}
+	mar := bank.CompareBudgets("2021-03", budgets, actuals)
+	if len(mar) != 1 {
+		t.Fatalf("mar lines %+v", mar)
+	}
+	if g := mar[0]; g.CarriedOver.String() != "100.00" || g.Available.String() != "1100.00" || g.PercentUsed != 50 {
+		t.Errorf("groceries %+v", g)
+	}
+}
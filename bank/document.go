@@ -0,0 +1,338 @@
+package bank
+
+import (
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"io"
+	"io/ioutil"
+	"mime"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/go-msvc/errors"
+	"github.com/google/uuid"
+	"github.com/jansemmelink/money/db"
+	"github.com/jmoiron/sqlx"
+)
+
+//DocumentStore keeps files on disk named by the sha256 hash of their content,
+//so the same file uploaded twice is stored once
+type DocumentStore struct {
+	Dir string
+}
+
+//Documents is the store used for transaction documents, in MONEY_DOCUMENTS_DIR (default ./documents)
+var Documents = DocumentStore{Dir: os.Getenv("MONEY_DOCUMENTS_DIR")}
+
+func (s DocumentStore) dir() string {
+	if s.Dir == "" {
+		return "documents"
+	}
+	return s.Dir
+}
+
+//Path of the file with the hash, in a sub directory named by the first 2 characters
+func (s DocumentStore) Path(hash string) string {
+	if len(hash) < 2 {
+		return filepath.Join(s.dir(), hash)
+	}
+	return filepath.Join(s.dir(), hash[0:2], hash)
+}
+
+//Put writes the content to the store and returns its hash and size
+func (s DocumentStore) Put(r io.Reader) (hash string, size int64, err error) {
+	if err := os.MkdirAll(s.dir(), 0750); err != nil {
+		return "", 0, errors.Wrapf(err, "failed to create documents dir")
+	}
+	f, err := ioutil.TempFile(s.dir(), ".upload-")
+	if err != nil {
+		return "", 0, errors.Wrapf(err, "failed to create file")
+	}
+	defer os.Remove(f.Name()) //fails harmlessly after the rename
+	h := sha256.New()
+	size, err = io.Copy(io.MultiWriter(f, h), r)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return "", 0, errors.Wrapf(err, "failed to write file")
+	}
+	hash = hex.EncodeToString(h.Sum(nil))
+	path := s.Path(hash)
+	if _, err := os.Stat(path); err == nil {
+		return hash, size, nil //same content already stored
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0750); err != nil {
+		return "", 0, errors.Wrapf(err, "failed to create documents dir")
+	}
+	if err := os.Rename(f.Name(), path); err != nil {
+		return "", 0, errors.Wrapf(err, "failed to store file")
+	}
+	return hash, size, nil
+} //DocumentStore.Put()
+
+func (s DocumentStore) Open(hash string) (*os.File, error) {
+	f, err := os.Open(s.Path(hash))
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to open document")
+	}
+	return f, nil
+}
+
+func (s DocumentStore) Remove(hash string) error {
+	if err := os.Remove(s.Path(hash)); err != nil && !os.IsNotExist(err) {
+		return errors.Wrapf(err, "failed to remove document")
+	}
+	return nil
+}
+
+//DetectMimeType from the file name extension or else the first 512 bytes of content
+func DetectMimeType(name string, head []byte) string {
+	if t := mime.TypeByExtension(filepath.Ext(name)); t != "" {
+		return t
+	}
+	return http.DetectContentType(head)
+}
+
+//Document is a file linked to one or more transactions
+type Document struct {
+	ID       string     `db:"id" json:"id"`
+	Hash     string     `db:"hash" json:"hash"` //sha256 of the content
+	Name     string     `db:"name" json:"name"` //file name when uploaded
+	MimeType string     `db:"mime_type" json:"mime_type"`
+	Size     int64      `db:"size" json:"size"`
+	Uploaded db.SqlTime `db:"uploaded" json:"uploaded"`
+}
+
+const documentSelect = "SELECT d.id,d.hash,d.name,d.mime_type,d.size,d.uploaded FROM `documents` AS d"
+
+//GetDocuments lists the documents of a transaction, or all documents if transactionID is ""
+func GetDocuments(transactionID string) ([]Document, error) {
+	sql := documentSelect
+	args := []interface{}{}
+	if transactionID != "" {
+		sql += " JOIN `transaction_documents` AS td ON td.document_id=d.id WHERE td.transaction_id=?"
+		args = append(args, transactionID)
+	}
+	list := []Document{}
+	if err := db.Db().Select(&list, sql+" ORDER BY d.uploaded,d.name", args...); err != nil {
+		return nil, errors.Wrapf(err, "failed to get documents")
+	}
+	return list, nil
+}
+
+//GetDocument returns nil,nil when not found
+func GetDocument(id string) (*Document, error) {
+	return getDocumentWhere(db.Db(), "d.id=?", id)
+}
+
+func getDocumentWhere(q sqlx.Queryer, where string, arg interface{}) (*Document, error) {
+	var d Document
+	if err := sqlx.Get(q, &d, documentSelect+" WHERE "+where, arg); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, errors.Wrapf(err, "failed to select document")
+	}
+	return &d, nil
+}
+
+//DocumentUpload is the content of a file to add to a transaction
+type DocumentUpload struct {
+	Name     string
+	MimeType string //detected from the name and content when ""
+	Content  io.Reader
+}
+
+//AddDocument stores the content and links it to the transaction.
+//When mimeType is "" it is detected from the name and content.
+func AddDocument(transactionID string, name string, mimeType string, r io.Reader) (*Document, error) {
+	list, err := AddDocuments(transactionID, []DocumentUpload{{Name: name, MimeType: mimeType, Content: r}})
+	if err != nil {
+		return nil, err
+	}
+	return &list[0], nil
+}
+
+//AddDocuments stores the files and links all of them to the transaction,
+//or none of them when one fails
+func AddDocuments(transactionID string, uploads []DocumentUpload) (list []Document, err error) {
+	t, err := GetTransaction(transactionID)
+	if err != nil {
+		return nil, err
+	}
+	if t == nil {
+		return nil, notFoundf("transaction(%s) not found", transactionID)
+	}
+	//files stored before failing are removed unless already used
+	hashes := []string{}
+	defer func() {
+		if err != nil {
+			removeUnusedFiles(hashes)
+		}
+	}()
+	sizes := []int64{}
+	for _, u := range uploads {
+		hash, size, err := Documents.Put(u.Content)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to store %s", u.Name)
+		}
+		hashes = append(hashes, hash)
+		sizes = append(sizes, size)
+	}
+
+	tx, err := db.Db().Beginx()
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to begin db transaction")
+	}
+	defer func() {
+		if err != nil {
+			tx.Rollback()
+		}
+	}()
+	inserted := []Document{}
+	for i, u := range uploads {
+		var d *Document
+		if d, err = getDocumentWhere(tx, "d.hash=?", hashes[i]); err != nil {
+			return nil, err
+		}
+		if d == nil {
+			name := limitStringLen(filepath.Base(u.Name), 200)
+			mimeType := u.MimeType
+			if mimeType == "" || mimeType == "application/octet-stream" {
+				if mimeType, err = detectStoredMimeType(name, hashes[i]); err != nil {
+					return nil, err
+				}
+			}
+			d = &Document{
+				ID:       uuid.New().String(),
+				Hash:     hashes[i],
+				Name:     name,
+				MimeType: limitStringLen(mimeType, 100),
+				Size:     sizes[i],
+				Uploaded: db.SqlTime(time.Now()),
+			}
+			if _, err = tx.Exec("INSERT INTO `documents` SET id=?,hash=?,name=?,mime_type=?,size=?,uploaded=?",
+				d.ID, d.Hash, d.Name, d.MimeType, d.Size, d.Uploaded,
+			); err != nil {
+				return nil, errors.Wrapf(err, "failed to insert document")
+			}
+			inserted = append(inserted, *d)
+		}
+		if _, err = tx.Exec("INSERT IGNORE INTO `transaction_documents` SET transaction_id=?,document_id=?", transactionID, d.ID); err != nil {
+			return nil, errors.Wrapf(err, "failed to link document")
+		}
+		list = append(list, *d)
+	}
+	if err = tx.Commit(); err != nil {
+		return nil, errors.Wrapf(err, "failed to commit")
+	}
+	for _, d := range inserted {
+		log.Infof("Inserted document(%s) %s", d.ID, d.Name)
+	}
+	return list, nil
+} //AddDocuments()
+
+//removeUnusedFiles removes the stored files that no document refers to
+func removeUnusedFiles(hashes []string) {
+	for _, hash := range hashes {
+		var nr int
+		if err := db.Db().Get(&nr, "SELECT COUNT(*) FROM `documents` WHERE hash=?", hash); err != nil {
+			log.Errorf("failed to count documents with hash %s: %+v", hash, err)
+			continue
+		}
+		if nr == 0 {
+			if err := Documents.Remove(hash); err != nil {
+				log.Errorf("%+v", err)
+			}
+		}
+	}
+}
+
+//PruneDocuments deletes the documents (with their files) that are no longer
+//linked to any transaction, e.g. after deleting their statement
+func PruneDocuments() (nrDeleted int, err error) {
+	var list []Document
+	if err := db.Db().Select(&list, documentSelect+" WHERE d.id NOT IN (SELECT document_id FROM `transaction_documents`)"); err != nil {
+		return 0, errors.Wrapf(err, "failed to get unlinked documents")
+	}
+	for _, d := range list {
+		//skip it when linked again in the meantime
+		result, err := db.Db().Exec("DELETE FROM `documents` WHERE id=? AND id NOT IN (SELECT document_id FROM `transaction_documents`)", d.ID)
+		if err != nil {
+			return nrDeleted, errors.Wrapf(err, "failed to delete document")
+		}
+		if nr, _ := result.RowsAffected(); nr != 1 {
+			continue
+		}
+		if err := Documents.Remove(d.Hash); err != nil {
+			return nrDeleted, err
+		}
+		log.Infof("Deleted unlinked document(%s) %s", d.ID, d.Name)
+		nrDeleted++
+	}
+	return nrDeleted, nil
+} //PruneDocuments()
+
+func detectStoredMimeType(name string, hash string) (string, error) {
+	f, err := Documents.Open(hash)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	head := make([]byte, 512)
+	n, err := io.ReadFull(f, head)
+	if err != nil && err != io.ErrUnexpectedEOF && err != io.EOF {
+		return "", errors.Wrapf(err, "failed to read document")
+	}
+	return DetectMimeType(name, head[:n]), nil
+}
+
+//UnlinkDocument removes the document from the transaction, and deletes
+//it (with its file) when it is not linked to any other transaction
+func UnlinkDocument(transactionID, documentID string) (err error) {
+	d, err := GetDocument(documentID)
+	if err != nil {
+		return err
+	}
+	if d == nil {
+		return notFoundf("document(%s) not found", documentID)
+	}
+	tx, err := db.Db().Beginx()
+	if err != nil {
+		return errors.Wrapf(err, "failed to begin db transaction")
+	}
+	defer func() {
+		if err != nil {
+			tx.Rollback()
+		}
+	}()
+	result, err := tx.Exec("DELETE FROM `transaction_documents` WHERE transaction_id=? AND document_id=?", transactionID, documentID)
+	if err != nil {
+		return errors.Wrapf(err, "failed to unlink document")
+	}
+	if nr, _ := result.RowsAffected(); nr != 1 {
+		err = notFoundf("document(%s) not linked to transaction(%s)", documentID, transactionID)
+		return err
+	}
+	var nrLinks int
+	if err = tx.Get(&nrLinks, "SELECT COUNT(*) FROM `transaction_documents` WHERE document_id=?", documentID); err != nil {
+		return errors.Wrapf(err, "failed to count document links")
+	}
+	if nrLinks == 0 {
+		if _, err = tx.Exec("DELETE FROM `documents` WHERE id=?", documentID); err != nil {
+			return errors.Wrapf(err, "failed to delete document")
+		}
+	}
+	if err = tx.Commit(); err != nil {
+		return errors.Wrapf(err, "failed to commit")
+	}
+	if nrLinks == 0 {
+		log.Infof("Deleted document(%s) %s", d.ID, d.Name)
+		return Documents.Remove(d.Hash)
+	}
+	return nil
+} //UnlinkDocument()
@@ -0,0 +1,61 @@
+package bank_test
+
+import (
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/jansemmelink/money/bank"
+)
+
+func TestDocumentStore(t *testing.T) {
+	s := bank.DocumentStore{Dir: t.TempDir()}
+	hash, size, err := s.Put(strings.NewReader("receipt"))
+	assert(t, err)
+	if size != 7 || hash != "6f32860910ca0fb2a20c7fda143666b09dbf8db5238195c90a586fb542ff0cad" {
+		t.Fatalf("put %s %d", hash, size)
+	}
+	//same content is stored once
+	again, _, err := s.Put(strings.NewReader("receipt"))
+	assert(t, err)
+	if again != hash {
+		t.Fatalf("hash %s != %s", again, hash)
+	}
+	other, _, err := s.Put(strings.NewReader("invoice"))
+	assert(t, err)
+	if other == hash {
+		t.Fatalf("different content with the same hash")
+	}
+	f, err := s.Open(hash)
+	assert(t, err)
+	content, err := ioutil.ReadAll(f)
+	f.Close()
+	assert(t, err)
+	if string(content) != "receipt" {
+		t.Fatalf("content \"%s\"", content)
+	}
+	assert(t, s.Remove(hash))
+	if _, err := os.Stat(s.Path(hash)); !os.IsNotExist(err) {
+		t.Fatalf("not removed: %v", err)
+	}
+	if _, err := os.Stat(s.Path(other)); err != nil {
+		t.Fatalf("other removed: %v", err)
+	}
+}
+
+func TestDetectMimeType(t *testing.T) {
+	for _, c := range []struct {
+		name string
+		head string
+		mime string
+	}{
+		{"receipt.pdf", "", "application/pdf"},
+		{"scan", "%PDF-1.4", "application/pdf"},
+		{"photo", "\xff\xd8\xff\xe0", "image/jpeg"},
+	} {
+		if m := bank.DetectMimeType(c.name, []byte(c.head)); m != c.mime {
+			t.Errorf("DetectMimeType(%s)=%s != %s", c.name, m, c.mime)
+		}
+	}
+}
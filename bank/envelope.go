@@ -0,0 +1,359 @@
+package bank
+
+import (
+	"database/sql"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/go-msvc/errors"
+	"github.com/google/uuid"
+	"github.com/jansemmelink/money/db"
+)
+
+//ToBeBudgeted is the name of the money received as income
+//that is not yet allocated to an envelope
+const ToBeBudgeted = "To be budgeted"
+
+//Envelope holds money allocated for spending on an expense account
+//and its sub accounts (zero based budgeting). Income goes to ToBeBudgeted,
+//is moved into envelopes and spending draws them down.
+type Envelope struct {
+	ID          string `db:"id" json:"id"`
+	Name        string `db:"name" json:"name"`
+	AccountID   string `db:"account_id" json:"account_id"`
+	AccountName string `db:"account_name" json:"account_name"`
+}
+
+const envelopeSelect = "SELECT e.id,e.name,e.account_id,a.name AS account_name" +
+	" FROM `envelopes` AS e" +
+	" JOIN `accounts` AS a ON a.id=e.account_id"
+
+func GetEnvelopes() ([]Envelope, error) {
+	var list []Envelope
+	if err := db.Db().Select(&list, envelopeSelect+" ORDER BY e.name"); err != nil {
+		return nil, errors.Wrapf(err, "failed to get envelopes")
+	}
+	return list, nil
+}
+
+func getEnvelopeWhere(where string, arg interface{}) (*Envelope, error) {
+	var e Envelope
+	if err := db.Db().Get(&e, envelopeSelect+" WHERE "+where, arg); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, errors.Wrapf(err, "failed to select envelope")
+	}
+	return &e, nil
+}
+
+//GetEnvelope returns nil,nil when not found
+func GetEnvelope(id string) (*Envelope, error) {
+	return getEnvelopeWhere("e.id=?", id)
+}
+
+//GetEnvelopeByName returns nil,nil when not found
+func GetEnvelopeByName(name string) (*Envelope, error) {
+	return getEnvelopeWhere("e.name=?", name)
+}
+
+//Save validates that the account is an expense account not in the
+//account tree of another envelope, so spending is drawn from one envelope only
+func (e *Envelope) Save() error {
+	if e.Name == "" {
+		return invalidf("name", "missing name")
+	}
+	if strings.EqualFold(e.Name, ToBeBudgeted) {
+		return invalidf("name", "envelope cannot be named \"%s\"", ToBeBudgeted)
+	}
+	accByID, err := getAllAccounts()
+	if err != nil {
+		return err
+	}
+	acc, ok := accByID[e.AccountID]
+	if !ok {
+		return invalidf("account_id", "account(%s) not found", e.AccountID)
+	}
+	if acc.Type != AccountTypeExpense {
+		return invalidf("account_id", "account %s is %s instead of %s", acc.Path, acc.Type, AccountTypeExpense)
+	}
+	others, err := GetEnvelopes()
+	if err != nil {
+		return err
+	}
+	for _, other := range others {
+		if other.ID == e.ID {
+			continue
+		}
+		otherPath := accByID[other.AccountID].Path
+		if acc.Path == otherPath ||
+			strings.HasPrefix(acc.Path, otherPath+PathSeparator) ||
+			strings.HasPrefix(otherPath, acc.Path+PathSeparator) {
+			return invalidf("account_id", "account %s overlaps with envelope %s on %s", acc.Path, other.Name, otherPath)
+		}
+	}
+	e.AccountName = acc.Name
+
+	if e.ID == "" {
+		id := uuid.New().String()
+		if _, err := db.Db().Exec("INSERT INTO `envelopes` SET id=?,name=?,account_id=?", id, e.Name, e.AccountID); err != nil {
+			return errors.Wrapf(err, "failed to insert envelope")
+		}
+		e.ID = id
+		log.Infof("Inserted envelope(%s)", e.ID)
+	} else {
+		if _, err := db.Db().Exec("UPDATE `envelopes` SET name=?,account_id=? WHERE id=?", e.Name, e.AccountID, e.ID); err != nil {
+			return errors.Wrapf(err, "failed to update envelope")
+		}
+		log.Infof("Updated envelope(%s)", e.ID)
+	}
+	return nil
+} //Envelope.Save()
+
+//DeleteEnvelope returns the money allocated to it to ToBeBudgeted and leaves
+//the balances of other envelopes as they were, see DeletedEnvelopeMoves.
+//Spending on its accounts is no longer drawn from any envelope.
+func DeleteEnvelope(id string) (err error) {
+	tx, err := db.Db().Beginx()
+	if err != nil {
+		return errors.Wrapf(err, "failed to begin db transaction")
+	}
+	defer func() {
+		if err != nil {
+			tx.Rollback()
+		}
+	}()
+	var moves []EnvelopeMove
+	if err = tx.Select(&moves, "SELECT id,date"+
+		",IFNULL(from_envelope_id,'') AS from_envelope_id"+
+		",IFNULL(to_envelope_id,'') AS to_envelope_id"+
+		",amount,IFNULL(notes,'') AS notes"+
+		" FROM `envelope_moves` WHERE from_envelope_id=? OR to_envelope_id=?",
+		id, id,
+	); err != nil {
+		return errors.Wrapf(err, "failed to get envelope moves")
+	}
+	updated, deleted := DeletedEnvelopeMoves(moves, id)
+	for _, m := range deleted {
+		if _, err = tx.Exec("DELETE FROM `envelope_moves` WHERE id=?", m.ID); err != nil {
+			return errors.Wrapf(err, "failed to delete envelope move")
+		}
+	}
+	for _, m := range updated {
+		if _, err = tx.Exec("UPDATE `envelope_moves` SET from_envelope_id=?,to_envelope_id=? WHERE id=?",
+			nullIfEmpty(m.FromEnvelopeID),
+			nullIfEmpty(m.ToEnvelopeID),
+			m.ID,
+		); err != nil {
+			return errors.Wrapf(err, "failed to update envelope move")
+		}
+	}
+	result, err := tx.Exec("DELETE FROM `envelopes` WHERE id=?", id)
+	if err != nil {
+		return errors.Wrapf(err, "failed to delete envelope")
+	}
+	if nr, _ := result.RowsAffected(); nr != 1 {
+		return notFoundf("envelope(%s) not found", id)
+	}
+	if err = tx.Commit(); err != nil {
+		return errors.Wrapf(err, "failed to commit")
+	}
+	log.Infof("Deleted envelope(%s): deleted %d moves, moved %d to %s", id, len(deleted), len(updated), ToBeBudgeted)
+	return nil
+} //DeleteEnvelope()
+
+//DeletedEnvelopeMoves changes the moves of a deleted envelope into moves of
+//ToBeBudgeted: moves between the envelope and ToBeBudgeted are deleted and
+//moves to or from other envelopes are updated to ToBeBudgeted instead.
+func DeletedEnvelopeMoves(moves []EnvelopeMove, id string) (updated, deleted []EnvelopeMove) {
+	for _, m := range moves {
+		switch {
+		case m.FromEnvelopeID == id && m.ToEnvelopeID == "",
+			m.FromEnvelopeID == "" && m.ToEnvelopeID == id:
+			deleted = append(deleted, m)
+		case m.FromEnvelopeID == id:
+			m.FromEnvelopeID = ""
+			updated = append(updated, m)
+		case m.ToEnvelopeID == id:
+			m.ToEnvelopeID = ""
+			updated = append(updated, m)
+		}
+	}
+	return updated, deleted
+}
+
+//EnvelopeMove moves money between envelopes, where "" is ToBeBudgeted
+type EnvelopeMove struct {
+	ID             string     `db:"id" json:"id"`
+	Date           db.SqlTime `db:"date" json:"date"`
+	FromEnvelopeID string     `db:"from_envelope_id" json:"from_envelope_id,omitempty"`
+	ToEnvelopeID   string     `db:"to_envelope_id" json:"to_envelope_id,omitempty"`
+	Amount         Amount     `db:"amount" json:"amount"`
+	Notes          string     `db:"notes" json:"notes,omitempty"`
+}
+
+//MoveToEnvelope moves amount from one envelope to another, with "" for ToBeBudgeted
+func MoveToEnvelope(date time.Time, fromEnvelopeID, toEnvelopeID string, amount Amount, notes string) (*EnvelopeMove, error) {
+	if fromEnvelopeID == toEnvelopeID {
+		return nil, invalidf("to_envelope_id", "cannot move to the same envelope")
+	}
+	if amount.MilliCents() <= 0 {
+		return nil, invalidf("amount", "amount %s must be > 0", amount)
+	}
+	for _, id := range []string{fromEnvelopeID, toEnvelopeID} {
+		if id == "" {
+			continue
+		}
+		if e, err := GetEnvelope(id); err != nil {
+			return nil, err
+		} else if e == nil {
+			return nil, notFoundf("envelope(%s) not found", id)
+		}
+	}
+	m := EnvelopeMove{
+		ID:             uuid.New().String(),
+		Date:           db.SqlTime(date),
+		FromEnvelopeID: fromEnvelopeID,
+		ToEnvelopeID:   toEnvelopeID,
+		Amount:         amount,
+		Notes:          notes,
+	}
+	if _, err := db.Db().Exec("INSERT INTO `envelope_moves` SET id=?,date=?,from_envelope_id=?,to_envelope_id=?,amount=?,notes=?",
+		m.ID,
+		m.Date,
+		nullIfEmpty(m.FromEnvelopeID),
+		nullIfEmpty(m.ToEnvelopeID),
+		m.Amount,
+		nullIfEmpty(limitStringLen(m.Notes, 200)),
+	); err != nil {
+		return nil, errors.Wrapf(err, "failed to insert envelope move")
+	}
+	log.Infof("Moved %s from envelope(%s) to envelope(%s)", m.Amount, m.FromEnvelopeID, m.ToEnvelopeID)
+	return &m, nil
+} //MoveToEnvelope()
+
+//getEnvelopeMoves up to the end of date to, oldest first
+func getEnvelopeMoves(to time.Time) ([]EnvelopeMove, error) {
+	var list []EnvelopeMove
+	if err := db.Db().Select(&list, "SELECT id,date"+
+		",IFNULL(from_envelope_id,'') AS from_envelope_id"+
+		",IFNULL(to_envelope_id,'') AS to_envelope_id"+
+		",amount,IFNULL(notes,'') AS notes"+
+		" FROM `envelope_moves` WHERE date<=? ORDER BY date,id",
+		db.SqlTime(to),
+	); err != nil {
+		return nil, errors.Wrapf(err, "failed to get envelope moves")
+	}
+	return list, nil
+}
+
+//EnvelopeLine is the activity of an envelope (or ToBeBudgeted) in a period
+type EnvelopeLine struct {
+	EnvelopeID  string `json:"envelope_id,omitempty"` //"" for ToBeBudgeted
+	Name        string `json:"name"`
+	AccountName string `json:"account_name,omitempty"`
+	Opening     Amount `json:"opening"` //balance before the period, negative when overspent
+	Income      Amount `json:"income"`  //income received (ToBeBudgeted only)
+	Moved       Amount `json:"moved"`   //net amount moved in (+) or out (-)
+	Spent       Amount `json:"spent"`   //spent on the envelope accounts
+	Balance     Amount `json:"balance"` //opening + income + moved - spent
+}
+
+//EnvelopeActivity is the income and spending rolled up per account in a period
+type EnvelopeActivity struct {
+	Income Amount            //normal balance of all income accounts
+	Spent  map[string]Amount //normal balance per expense account id, rolled up
+}
+
+//EnvelopeReport lists ToBeBudgeted followed by all envelopes
+type EnvelopeReport struct {
+	From  string         `json:"from"`
+	To    string         `json:"to"`
+	Lines []EnvelopeLine `json:"lines"`
+}
+
+//GetEnvelopeReport reports the envelopes from..to (end of day) from the
+//transactions and envelope moves. Balances include all activity before from,
+//so overspending rolls forward as a negative balance.
+func GetEnvelopeReport(from, to time.Time) (*EnvelopeReport, error) {
+	envelopes, err := GetEnvelopes()
+	if err != nil {
+		return nil, err
+	}
+	moves, err := getEnvelopeMoves(to)
+	if err != nil {
+		return nil, err
+	}
+	before, err := getEnvelopeActivity(time.Time{}, from.Add(-time.Second))
+	if err != nil {
+		return nil, err
+	}
+	during, err := getEnvelopeActivity(from, to)
+	if err != nil {
+		return nil, err
+	}
+	r := NewEnvelopeReport(from, envelopes, moves, before, during)
+	r.From = from.Format("2006-01-02")
+	r.To = to.Format("2006-01-02")
+	return &r, nil
+}
+
+func getEnvelopeActivity(from, to time.Time) (EnvelopeActivity, error) {
+	a := EnvelopeActivity{Spent: map[string]Amount{}}
+	totals, err := GetPeriodTotals(from, to, "")
+	if err != nil {
+		return a, err
+	}
+	for _, node := range RollUp(totals) {
+		switch node.Account.Type {
+		case AccountTypeIncome:
+			if node.Depth == 0 {
+				a.Income = a.Income.Add(node.RollUp.NormalBalance())
+			}
+		case AccountTypeExpense:
+			a.Spent[node.Account.ID] = node.RollUp.NormalBalance()
+		}
+	}
+	return a, nil
+}
+
+//NewEnvelopeReport calculates the envelope lines for the period starting at
+//from, with moves up to the end of the period and activity before and during it
+func NewEnvelopeReport(from time.Time, envelopes []Envelope, moves []EnvelopeMove, before, during EnvelopeActivity) EnvelopeReport {
+	tbb := &EnvelopeLine{Name: ToBeBudgeted}
+	lineByID := map[string]*EnvelopeLine{"": tbb}
+	lines := []*EnvelopeLine{}
+	for _, e := range envelopes {
+		line := &EnvelopeLine{EnvelopeID: e.ID, Name: e.Name, AccountName: e.AccountName}
+		line.Opening = line.Opening.Sub(before.Spent[e.AccountID])
+		line.Spent = during.Spent[e.AccountID]
+		lineByID[e.ID] = line
+		lines = append(lines, line)
+	}
+	sort.Slice(lines, func(i, j int) bool { return lines[i].Name < lines[j].Name })
+	tbb.Opening = before.Income
+	tbb.Income = during.Income
+
+	for _, m := range moves {
+		fromLine, fromOK := lineByID[m.FromEnvelopeID]
+		toLine, toOK := lineByID[m.ToEnvelopeID]
+		if !fromOK || !toOK {
+			continue //deleted envelope
+		}
+		if time.Time(m.Date).Before(from) {
+			fromLine.Opening = fromLine.Opening.Sub(m.Amount)
+			toLine.Opening = toLine.Opening.Add(m.Amount)
+		} else {
+			fromLine.Moved = fromLine.Moved.Sub(m.Amount)
+			toLine.Moved = toLine.Moved.Add(m.Amount)
+		}
+	}
+
+	r := EnvelopeReport{Lines: []EnvelopeLine{}}
+	for _, line := range append([]*EnvelopeLine{tbb}, lines...) {
+		line.Balance = line.Opening.Add(line.Income).Add(line.Moved).Sub(line.Spent)
+		r.Lines = append(r.Lines, *line)
+	}
+	return r
+} //NewEnvelopeReport()
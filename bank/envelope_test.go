@@ -0,0 +1,99 @@
+package bank_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/jansemmelink/money/bank"
+	"github.com/jansemmelink/money/db"
+)
+
+func TestEnvelopeReport(t *testing.T) {
+	date := func(s string) db.SqlTime {
+		d, err := time.Parse("2006-01-02", s)
+		assert(t, err)
+		return db.SqlTime(d)
+	}
+	envelopes := []bank.Envelope{
+		{ID: "g", Name: "Groceries", AccountID: "1"},
+		{ID: "f", Name: "Fuel", AccountID: "2"},
+	}
+	moves := []bank.EnvelopeMove{
+		{Date: date("2021-01-01"), ToEnvelopeID: "g", Amount: amount(t, "1000")},
+		{Date: date("2021-01-01"), ToEnvelopeID: "f", Amount: amount(t, "500")},
+		{Date: date("2021-02-01"), ToEnvelopeID: "g", Amount: amount(t, "1000")},
+		{Date: date("2021-02-10"), FromEnvelopeID: "f", ToEnvelopeID: "g", Amount: amount(t, "100")},
+	}
+	//january: income 2000, groceries overspent by 200
+	before := bank.EnvelopeActivity{
+		Income: amount(t, "2000"),
+		Spent:  map[string]bank.Amount{"1": amount(t, "1200"), "2": amount(t, "300")},
+	}
+	during := bank.EnvelopeActivity{
+		Income: amount(t, "1500"),
+		Spent:  map[string]bank.Amount{"1": amount(t, "700")},
+	}
+	feb, _ := time.Parse("2006-01-02", "2021-02-01")
+	r := bank.NewEnvelopeReport(feb, envelopes, moves, before, during)
+	if len(r.Lines) != 3 {
+		t.Fatalf("%d lines", len(r.Lines))
+	}
+	for i, want := range []struct {
+		name                                   string
+		opening, income, moved, spent, balance string
+	}{
+		{bank.ToBeBudgeted, "500.00", "1500.00", "-1000.00", "0.00", "1000.00"},
+		{"Fuel", "200.00", "0.00", "-100.00", "0.00", "100.00"},
+		{"Groceries", "-200.00", "0.00", "1100.00", "700.00", "200.00"},
+	} {
+		l := r.Lines[i]
+		if l.Name != want.name ||
+			l.Opening.String() != want.opening ||
+			l.Income.String() != want.income ||
+			l.Moved.String() != want.moved ||
+			l.Spent.String() != want.spent ||
+			l.Balance.String() != want.balance {
+			t.Errorf("line[%d] %+v != %+v", i, l, want)
+		}
+	}
+}
+
+func TestDeletedEnvelopeMoves(t *testing.T) {
+	jan, _ := time.Parse("2006-01-02", "2021-01-01")
+	envelopes := []bank.Envelope{
+		{ID: "g", Name: "Groceries", AccountID: "1"},
+		{ID: "f", Name: "Fuel", AccountID: "2"},
+	}
+	moves := []bank.EnvelopeMove{
+		{ID: "1", Date: db.SqlTime(jan), ToEnvelopeID: "g", Amount: amount(t, "1000")},
+		{ID: "2", Date: db.SqlTime(jan), ToEnvelopeID: "f", Amount: amount(t, "500")},
+		{ID: "3", Date: db.SqlTime(jan), FromEnvelopeID: "f", ToEnvelopeID: "g", Amount: amount(t, "100")},
+		{ID: "4", Date: db.SqlTime(jan), FromEnvelopeID: "g", ToEnvelopeID: "f", Amount: amount(t, "30")},
+		{ID: "5", Date: db.SqlTime(jan), FromEnvelopeID: "f", Amount: amount(t, "20")},
+	}
+	activity := bank.EnvelopeActivity{
+		Income: amount(t, "2000"),
+		Spent:  map[string]bank.Amount{"1": amount(t, "600"), "2": amount(t, "300")},
+	}
+	before := bank.NewEnvelopeReport(jan, envelopes, moves, bank.EnvelopeActivity{}, activity)
+
+	updated, deleted := bank.DeletedEnvelopeMoves(moves, "f")
+	if len(updated) != 2 || len(deleted) != 2 {
+		t.Fatalf("updated %+v deleted %+v", updated, deleted)
+	}
+	movesAfter := []bank.EnvelopeMove{moves[0]}
+	movesAfter = append(movesAfter, updated...)
+	after := bank.NewEnvelopeReport(jan, envelopes[:1], movesAfter, bank.EnvelopeActivity{}, activity)
+	if len(after.Lines) != 2 {
+		t.Fatalf("%d lines", len(after.Lines))
+	}
+
+	//groceries keeps its balance and fuel's 500-20 moved in, less 100-30 moved
+	//on to groceries, returns to be budgeted
+	if after.Lines[1].Name != "Groceries" || after.Lines[1].Balance != before.Lines[2].Balance {
+		t.Errorf("groceries %+v != %+v", after.Lines[1], before.Lines[2])
+	}
+	if before.Lines[0].Balance.String() != "520.00" || after.Lines[0].Balance.String() != "930.00" {
+		t.Errorf("to be budgeted %s -> %s", before.Lines[0].Balance, after.Lines[0].Balance)
+	}
+}
@@ -0,0 +1,30 @@
+package bank
+
+import "fmt"
+
+//NotFoundError is returned when the record to act on does not exist
+type NotFoundError struct {
+	Message string
+}
+
+func (e NotFoundError) Error() string {
+	return e.Message
+}
+
+func notFoundf(format string, args ...interface{}) error {
+	return NotFoundError{Message: fmt.Sprintf(format, args...)}
+}
+
+//ValidationError is returned when a field of the record to save is not valid
+type ValidationError struct {
+	Field   string //name of the field in the api request
+	Message string
+}
+
+func (e ValidationError) Error() string {
+	return e.Message
+}
+
+func invalidf(field string, format string, args ...interface{}) error {
+	return ValidationError{Field: field, Message: fmt.Sprintf(format, args...)}
+}
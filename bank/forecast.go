@@ -0,0 +1,210 @@
+package bank
+
+import (
+	"sort"
+	"time"
+
+	"github.com/go-msvc/errors"
+)
+
+//nr of days of past spending to average
+const discretionaryDays = 90
+
+//ForecastItem is an expected transaction on a bank account
+type ForecastItem struct {
+	Date        time.Time `json:"-"`
+	Amount      Amount    `json:"amount"`
+	Description string    `json:"description"`
+}
+
+//ForecastDay is the projected balance at the end of a day
+type ForecastDay struct {
+	Date          string   `json:"date"`
+	Expected      Amount   `json:"expected"`      //sum of expected items
+	Items         []string `json:"items"`         //descriptions of expected items
+	Discretionary Amount   `json:"discretionary"` //average daily spending not expected
+	Balance       Amount   `json:"balance"`
+	Below         bool     `json:"below"` //balance below the threshold
+}
+
+//BankForecast projects the balance of a bank account from its latest balance
+type BankForecast struct {
+	BankAccount   BankAccount   `json:"bank_account"`
+	Start         string        `json:"start"` //today, with the balance of all transactions
+	StartBalance  Amount        `json:"start_balance"`
+	DailySpend    Amount        `json:"daily_spend"`
+	Threshold     Amount        `json:"threshold"`
+	Days          []ForecastDay `json:"days"`
+	FirstBelow    string        `json:"first_below,omitempty"` //first date below threshold
+	LowestBalance Amount        `json:"lowest_balance"`
+	LowestOn      string        `json:"lowest_on"`
+}
+
+//Forecast projects the balance for nrDays after start with the expected items
+//on their dates and dailySpend (negative for spending) on every day
+func Forecast(start time.Time, balance Amount, nrDays int, items []ForecastItem, dailySpend Amount, threshold Amount) []ForecastDay {
+	sort.SliceStable(items, func(i, j int) bool { return items[i].Date.Before(items[j].Date) })
+	startDay := time.Date(start.Year(), start.Month(), start.Day(), 0, 0, 0, 0, start.Location())
+	days := []ForecastDay{}
+	next := 0
+	for i := 1; i <= nrDays; i++ {
+		date := startDay.AddDate(0, 0, i)
+		end := date.AddDate(0, 0, 1)
+		day := ForecastDay{Date: date.Format("2006-01-02"), Items: []string{}, Discretionary: dailySpend}
+		//items before the first day are overdue and expected on the first day
+		for next < len(items) && items[next].Date.Before(end) {
+			day.Expected = day.Expected.Add(items[next].Amount)
+			day.Items = append(day.Items, items[next].Description)
+			next++
+		}
+		balance = balance.Add(day.Expected).Add(dailySpend)
+		day.Balance = balance
+		day.Below = balance.MilliCents() < threshold.MilliCents()
+		days = append(days, day)
+	}
+	return days
+} //Forecast()
+
+//GetForecasts projects the balances of all bank accounts for nrDays
+func GetForecasts(nrDays int, threshold Amount) ([]BankForecast, error) {
+	baList, err := GetBankAccounts()
+	if err != nil {
+		return nil, err
+	}
+	series, err := GetRecurring(DefaultRecurringOptions)
+	if err != nil {
+		return nil, err
+	}
+	//the forecast starts today and ends after the last forecast day
+	start := time.Now()
+	end := time.Date(start.Year(), start.Month(), start.Day(), 0, 0, 0, 0, start.Location()).AddDate(0, 0, nrDays+1)
+	scheduled, err := GetScheduledInstances(time.Time{}, end)
+	if err != nil {
+		return nil, err
+	}
+	ownAccountIDs := map[string]bool{}
+	for _, ba := range baList {
+		ownAccountIDs[ba.AccountID] = true
+	}
+	list := []BankForecast{}
+	for _, ba := range baList {
+		f, err := getForecast(ba, series, scheduled, ownAccountIDs, start, end, nrDays, threshold)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to forecast %s %s", ba.BankName, ba.AccountNumber)
+		}
+		list = append(list, *f)
+	}
+	return list, nil
+}
+
+func getForecast(ba BankAccount, series []RecurringSeries, scheduled []ScheduledInstance, ownAccountIDs map[string]bool, start, end time.Time, nrDays int, threshold Amount) (*BankForecast, error) {
+	balance, err := GetBalance(ba.AccountID, time.Time{})
+	if err != nil {
+		return nil, err
+	}
+	txList, err := GetTransactions(TransactionFilter{AccountID: ba.AccountID})
+	if err != nil {
+		return nil, err
+	}
+
+	//scheduled transactions not yet matched are expected on their due
+	//dates unless more than their days tolerance ago
+	items := []ForecastItem{}
+	scheduledIDs := map[string]bool{}
+	for _, i := range scheduled {
+		if i.Scheduled.BankAccountID != ba.ID {
+			continue
+		}
+		if i.TransactionID != "" {
+			scheduledIDs[i.TransactionID] = true
+			continue
+		}
+		if i.DueDate.Before(end) && !i.DueDate.AddDate(0, 0, i.Scheduled.DaysTolerance).Before(start) {
+			items = append(items, ForecastItem{Date: i.DueDate, Amount: i.Scheduled.Amount, Description: "scheduled " + i.Scheduled.Name})
+		}
+	}
+
+	//recurring series that were not missed are expected to continue,
+	//except those already scheduled
+	recurringIDs := map[string]bool{}
+	for _, s := range series {
+		if s.BankAccountID != ba.ID {
+			continue
+		}
+		isScheduled := false
+		for _, id := range s.TransactionIDs {
+			recurringIDs[id] = true
+			isScheduled = isScheduled || scheduledIDs[id]
+		}
+		if s.Status == RecurringMissed || isScheduled {
+			continue
+		}
+		for d := s.NextTime(); d.Before(end); d = s.Interval.Next(d) {
+			items = append(items, ForecastItem{Date: d, Amount: s.NextAmount, Description: string(s.Interval) + " " + s.Merchant})
+		}
+	}
+
+	//average spending that is not recurring or scheduled
+	expectedIDs := map[string]bool{}
+	for id := range recurringIDs {
+		expectedIDs[id] = true
+	}
+	for id := range scheduledIDs {
+		expectedIDs[id] = true
+	}
+	dailySpend := DailySpend(txList, ba.ID, expectedIDs, ownAccountIDs, start)
+
+	f := &BankForecast{
+		BankAccount:  ba,
+		Start:        start.Format("2006-01-02"),
+		StartBalance: balance.Balance,
+		DailySpend:   dailySpend,
+		Threshold:    threshold,
+		Days:         Forecast(start, balance.Balance, nrDays, items, dailySpend, threshold),
+	}
+	f.LowestBalance = f.StartBalance
+	f.LowestOn = f.Start
+	for _, day := range f.Days {
+		if day.Below && f.FirstBelow == "" {
+			f.FirstBelow = day.Date
+		}
+		if day.Balance.MilliCents() < f.LowestBalance.MilliCents() {
+			f.LowestBalance = day.Balance
+			f.LowestOn = day.Date
+		}
+	}
+	return f, nil
+} //getForecast()
+
+//DailySpend is the average amount paid per day (negative) from the bank account
+//over the discretionaryDays before start, or over the days since its first
+//transaction when there is less history, excluding the transactions in excludeIDs
+//and transfers to the accounts of own bank accounts in ownAccountIDs
+func DailySpend(txList []TransactionRecord, bankAccountID string, excludeIDs map[string]bool, ownAccountIDs map[string]bool, start time.Time) Amount {
+	since := start.AddDate(0, 0, -discretionaryDays)
+	var first time.Time
+	spent := Amount{}
+	for _, tx := range txList {
+		date := time.Time(tx.Date)
+		if tx.BankAccountID != bankAccountID || date.After(start) {
+			continue
+		}
+		if first.IsZero() || date.Before(first) {
+			first = date
+		}
+		if tx.Amount.MilliCents() < 0 && !excludeIDs[tx.ID] && !ownAccountIDs[tx.CounterAccountID()] && !date.Before(since) {
+			spent = spent.Add(tx.Amount)
+		}
+	}
+	if first.IsZero() {
+		return Amount{}
+	}
+	nrDays := discretionaryDays
+	if first.After(since) {
+		startDay := time.Date(start.Year(), start.Month(), start.Day(), 0, 0, 0, 0, start.Location())
+		if nrDays = daysBetween(startDay, first); nrDays < 1 {
+			nrDays = 1
+		}
+	}
+	return Amount{mc: spent.MilliCents() / int64(nrDays)}
+} //DailySpend()
@@ -0,0 +1,67 @@
+package bank_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/jansemmelink/money/bank"
+	"github.com/jansemmelink/money/db"
+)
+
+func TestForecast(t *testing.T) {
+	date := func(s string) time.Time {
+		d, err := time.ParseInLocation("2006-01-02", s, time.Local)
+		assert(t, err)
+		return d
+	}
+	items := []bank.ForecastItem{
+		{Date: date("2021-03-25"), Amount: amount(t, "20000"), Description: "monthly SALARY"},
+		{Date: date("2021-03-21"), Amount: amount(t, "-899.12"), Description: "monthly OUTSURANCE"},
+		{Date: date("2021-03-21"), Amount: amount(t, "-5000"), Description: "monthly BOND"},
+	}
+	days := bank.Forecast(date("2021-03-19"), amount(t, "1000"), 7, items, amount(t, "-100"), amount(t, "0"))
+	if len(days) != 7 {
+		t.Fatalf("%d days", len(days))
+	}
+	for i, want := range []struct {
+		date, balance string
+		nrItems       int
+		below         bool
+	}{
+		{"2021-03-20", "900.00", 0, false},
+		{"2021-03-21", "-5099.12", 2, true},
+		{"2021-03-22", "-5199.12", 0, true},
+		{"2021-03-23", "-5299.12", 0, true},
+		{"2021-03-24", "-5399.12", 0, true},
+		{"2021-03-25", "14500.88", 1, false},
+		{"2021-03-26", "14400.88", 0, false},
+	} {
+		d := days[i]
+		if d.Date != want.date || d.Balance.String() != want.balance || len(d.Items) != want.nrItems || d.Below != want.below {
+			t.Errorf("day[%d] %+v != %+v", i, d, want)
+		}
+	}
+}
+
+func TestDailySpend(t *testing.T) {
+	start := time.Date(2021, 3, 31, 12, 0, 0, 0, time.Local)
+	day := func(d int) db.SqlTime {
+		return db.SqlTime(time.Date(2021, 3, d, 0, 0, 0, 0, time.Local))
+	}
+	txList := []bank.TransactionRecord{
+		{ID: "1", BankAccountID: "cheque", Date: day(1), Amount: amount(t, "5000")},
+		{ID: "2", BankAccountID: "cheque", Date: day(11), Amount: amount(t, "-600")},
+		{ID: "3", BankAccountID: "cheque", Date: day(21), Amount: amount(t, "-2400")},
+		{ID: "bond", BankAccountID: "cheque", Date: day(25), Amount: amount(t, "-5000")},
+		{ID: "savings", BankAccountID: "cheque", Date: day(27), Amount: amount(t, "-1500"), DtAccountID: "savings-account"},
+		{ID: "other", BankAccountID: "credit", Date: day(26), Amount: amount(t, "-1000")},
+	}
+	//30 days of history since the first transaction instead of 90
+	//the transfer to the savings bank account is not spending
+	if s := bank.DailySpend(txList, "cheque", map[string]bool{"bond": true}, map[string]bool{"savings-account": true}, start); s.String() != "-100.00" {
+		t.Fatalf("daily spend %s", s)
+	}
+	if s := bank.DailySpend(nil, "cheque", nil, nil, start); !s.IsZero() {
+		t.Fatalf("daily spend %s without transactions", s)
+	}
+}
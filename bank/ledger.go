@@ -0,0 +1,203 @@
+package bank
+
+import (
+	"strings"
+	"time"
+
+	"github.com/go-msvc/errors"
+	"github.com/jansemmelink/money/db"
+)
+
+//AccountBalance is the balance of an account at the end of a date
+type AccountBalance struct {
+	Account        Account `json:"account"`
+	AsOf           string  `json:"as_of,omitempty"` //"" includes all transactions
+	Debits         Amount  `json:"debits"`
+	Credits        Amount  `json:"credits"`
+	NrTransactions int     `json:"nr_transactions"`
+	Balance        Amount  `json:"balance"` //with the sign normal for the account type
+}
+
+//GetBalance sums the postings of an account up to asOf (zero for all)
+func GetBalance(accountID string, asOf time.Time) (*AccountBalance, error) {
+	acc, err := GetAccount(accountID)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to get account")
+	}
+	if acc == nil {
+		return nil, notFoundf("account(%s) not found", accountID)
+	}
+	sql := "SELECT p.account_id,p.amount FROM `postings` AS p" +
+		" JOIN `transactions` AS t ON t.id=p.transaction_id" +
+		" WHERE p.account_id=?"
+	args := []interface{}{acc.ID}
+	if !asOf.IsZero() {
+		sql += " AND t.date<=?"
+		args = append(args, db.SqlTime(asOf))
+	}
+	var rows []postingRow
+	if err := db.Db().Select(&rows, sql, args...); err != nil {
+		return nil, errors.Wrapf(err, "failed to get postings of account(%s)", acc.ID)
+	}
+	total := AccountTotal{Account: *acc}
+	for _, row := range rows {
+		total.add(row.Amount)
+	}
+	b := &AccountBalance{
+		Account:        *acc,
+		Debits:         total.Debits,
+		Credits:        total.Credits,
+		NrTransactions: total.NrTransactions,
+		Balance:        total.NormalBalance(),
+	}
+	if !asOf.IsZero() {
+		b.AsOf = asOf.Format("2006-01-02")
+	}
+	return b, nil
+} //GetBalance()
+
+//LedgerEntry is one posting to the account with the balance after it
+type LedgerEntry struct {
+	TransactionID      string `db:"transaction_id" json:"transaction_id"`
+	Date               string `db:"-" json:"date"`
+	Details            string `db:"details" json:"details,omitempty"`
+	Notes              string `db:"notes" json:"notes,omitempty"`
+	CounterAccountID   string `db:"counter_account_id" json:"counter_account_id,omitempty"` //"" when split
+	CounterAccountName string `db:"-" json:"counter_account_name"`
+	Debit              Amount `db:"-" json:"debit"`
+	Credit             Amount `db:"-" json:"credit"`
+	Balance            Amount `db:"-" json:"balance"`
+}
+
+//Ledger lists the postings to an account in a period with running balance.
+//Balances have the sign normal for the account type.
+type Ledger struct {
+	Account        Account       `json:"account"`
+	From           string        `json:"from,omitempty"`
+	To             string        `json:"to,omitempty"`
+	OpeningBalance Amount        `json:"opening_balance"`
+	Entries        []LedgerEntry `json:"entries"`
+	ClosingBalance Amount        `json:"closing_balance"`
+}
+
+//NewLedger starts a ledger with the balance before the first entry
+func NewLedger(acc Account, openingBalance Amount) *Ledger {
+	return &Ledger{
+		Account:        acc,
+		OpeningBalance: openingBalance,
+		Entries:        []LedgerEntry{},
+		ClosingBalance: openingBalance,
+	}
+}
+
+//Add appends an entry for a posting amount (debit > 0, credit < 0)
+//and updates the running balance
+func (l *Ledger) Add(e LedgerEntry, amount Amount) {
+	if amount.MilliCents() > 0 {
+		e.Debit = amount
+	} else {
+		e.Credit = amount.Neg()
+	}
+	l.ClosingBalance = l.ClosingBalance.Add(l.Account.Type.NormalBalance(amount))
+	e.Balance = l.ClosingBalance
+	l.Entries = append(l.Entries, e)
+}
+
+type ledgerRow struct {
+	LedgerEntry
+	Date      db.SqlTime `db:"date"`
+	Amount    Amount     `db:"amount"`
+	NrCounter int        `db:"nr_counter"`
+}
+
+//LedgerFilter selects the postings listed by GetLedger
+type LedgerFilter struct {
+	From  time.Time //zero for no lower limit
+	To    time.Time //zero for no upper limit
+	Tag   string    //only transactions with this tag
+	Query string    //filter expression on TransactionFields
+}
+
+//GetLedger lists the postings to an account with the counter account of
+//each transaction and the running balance. With a tag or query it only
+//lists postings of the matching transactions, and the running balance
+//is their total from zero.
+func GetLedger(accountID string, filter LedgerFilter) (*Ledger, error) {
+	from, to := filter.From, filter.To
+	var opening Amount
+	if !from.IsZero() && filter.Tag == "" && filter.Query == "" {
+		b, err := GetBalance(accountID, from.Add(-time.Second))
+		if err != nil {
+			return nil, err
+		}
+		opening = b.Balance
+	}
+	acc, err := GetAccount(accountID)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to get account")
+	}
+	if acc == nil {
+		return nil, notFoundf("account(%s) not found", accountID)
+	}
+	accByID, err := getAllAccounts()
+	if err != nil {
+		return nil, err
+	}
+
+	sql := "SELECT p.transaction_id,t.date,p.amount" +
+		",IFNULL(t.statement_details,'') AS details" +
+		",IFNULL(IFNULL(p.notes,t.notes),'') AS notes" +
+		",(SELECT COUNT(*) FROM `postings` AS o WHERE o.transaction_id=p.transaction_id AND o.account_id<>p.account_id) AS nr_counter" +
+		",IFNULL((SELECT MIN(o.account_id) FROM `postings` AS o WHERE o.transaction_id=p.transaction_id AND o.account_id<>p.account_id),'') AS counter_account_id" +
+		" FROM `postings` AS p" +
+		" JOIN `transactions` AS t ON t.id=p.transaction_id" +
+		" WHERE p.account_id=?"
+	args := []interface{}{acc.ID}
+	if !from.IsZero() {
+		sql += " AND t.date>=?"
+		args = append(args, db.SqlTime(from))
+	}
+	if !to.IsZero() {
+		sql += " AND t.date<=?"
+		args = append(args, db.SqlTime(to))
+	}
+	if filter.Tag != "" {
+		sql += " AND " + tagFilter
+		args = append(args, strings.ToLower(filter.Tag))
+	}
+	if filter.Query != "" {
+		q, queryArgs, err := transactionQuery(filter.Query)
+		if err != nil {
+			return nil, err
+		}
+		if q != "" {
+			sql += " AND " + q
+			args = append(args, queryArgs...)
+		}
+	}
+	sql += " ORDER BY t.date,t.id"
+	var rows []ledgerRow
+	if err := db.Db().Select(&rows, sql, args...); err != nil {
+		return nil, errors.Wrapf(err, "failed to get ledger of account(%s)", acc.ID)
+	}
+
+	l := NewLedger(*acc, opening)
+	if !from.IsZero() {
+		l.From = from.Format("2006-01-02")
+	}
+	if !to.IsZero() {
+		l.To = to.Format("2006-01-02")
+	}
+	for _, row := range rows {
+		e := row.LedgerEntry
+		e.Date = row.Date.Date()
+		if row.NrCounter > 1 {
+			e.CounterAccountID = ""
+			e.CounterAccountName = "(split)"
+		} else if counter, ok := accByID[e.CounterAccountID]; ok {
+			e.CounterAccountName = counter.Path
+		}
+		l.Add(e, row.Amount)
+	}
+	return l, nil
+} //GetLedger()
@@ -0,0 +1,29 @@
+package bank_test
+
+import (
+	"testing"
+
+	"github.com/jansemmelink/money/bank"
+)
+
+func TestLedger(t *testing.T) {
+	//credit card is a liability: purchases are credits that increase the balance owed
+	l := bank.NewLedger(bank.Account{Name: "Credit Card", Type: bank.AccountTypeLiability}, amount(t, "100"))
+	l.Add(bank.LedgerEntry{Details: "Spar"}, amount(t, "-250.50"))
+	l.Add(bank.LedgerEntry{Details: "Payment"}, amount(t, "300"))
+	if len(l.Entries) != 2 {
+		t.Fatalf("%d entries", len(l.Entries))
+	}
+	for i, want := range []struct{ debit, credit, balance string }{
+		{"0.00", "250.50", "350.50"},
+		{"300.00", "0.00", "50.50"},
+	} {
+		e := l.Entries[i]
+		if e.Debit.String() != want.debit || e.Credit.String() != want.credit || e.Balance.String() != want.balance {
+			t.Errorf("entry[%d] dt=%s ct=%s balance=%s != %+v", i, e.Debit, e.Credit, e.Balance, want)
+		}
+	}
+	if l.ClosingBalance.String() != "50.50" {
+		t.Errorf("closing balance %s", l.ClosingBalance)
+	}
+}
@@ -0,0 +1,146 @@
+package bank
+
+import (
+	"strings"
+
+	"github.com/go-msvc/errors"
+	"github.com/jansemmelink/money/db"
+)
+
+//MergePreview describes what MergeAccounts changes
+type MergePreview struct {
+	From           Account `json:"from"`
+	To             Account `json:"to"`
+	NrTransactions int     `json:"nr_transactions"`  //transactions with postings on from
+	NrDebits       int     `json:"nr_debits"`        //postings debiting from
+	NrCredits      int     `json:"nr_credits"`       //postings crediting from
+	NrRules        int     `json:"nr_rules"`         //rules assigning from
+	NrBankAccounts int     `json:"nr_bank_accounts"` //bank accounts linked to from
+	NrSubAccounts  int     `json:"nr_sub_accounts"`  //accounts directly under from
+	NrBudgets      int     `json:"nr_budgets"`       //budgets of from, added to those of to in the same month
+	NrEnvelopes    int     `json:"nr_envelopes"`     //envelope of from, moved to account to
+	FromBalance    Amount  `json:"from_balance"`     //balance moved to account to
+	ToBalance      Amount  `json:"to_balance"`       //balance of account to before the merge
+	MergedBalance  Amount  `json:"merged_balance"`   //balance of account to after the merge
+}
+
+//PreviewMerge returns the changes MergeAccounts(fromID, toID) will make without making them
+func PreviewMerge(fromID, toID string) (*MergePreview, error) {
+	if fromID == toID {
+		return nil, errors.Errorf("cannot merge account into itself")
+	}
+	from, err := GetAccount(fromID)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to get account(%s)", fromID)
+	}
+	if from == nil {
+		return nil, notFoundf("account(%s) not found", fromID)
+	}
+	to, err := GetAccount(toID)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to get account(%s)", toID)
+	}
+	if to == nil {
+		return nil, notFoundf("account(%s) not found", toID)
+	}
+
+	if strings.HasPrefix(to.Path, from.Path+PathSeparator) {
+		return nil, errors.Errorf("cannot merge account(%s) into its sub account(%s)", from.Path, to.Path)
+	}
+
+	p := MergePreview{From: *from, To: *to}
+	fromTotal, err := getAccountTotal(*from)
+	if err != nil {
+		return nil, err
+	}
+	toTotal, err := getAccountTotal(*to)
+	if err != nil {
+		return nil, err
+	}
+	//balances are shown with the sign normal for the type of account to
+	p.FromBalance = to.Type.NormalBalance(fromTotal.Balance())
+	p.ToBalance = toTotal.NormalBalance()
+	p.MergedBalance = p.ToBalance.Add(p.FromBalance)
+	for _, c := range []struct {
+		nr  *int
+		sql string
+	}{
+		{&p.NrTransactions, "SELECT COUNT(DISTINCT transaction_id) FROM `postings` WHERE account_id=?"},
+		{&p.NrDebits, "SELECT COUNT(*) FROM `postings` WHERE account_id=? AND amount NOT LIKE '-%'"},
+		{&p.NrCredits, "SELECT COUNT(*) FROM `postings` WHERE account_id=? AND amount LIKE '-%'"},
+		{&p.NrRules, "SELECT COUNT(*) FROM `rules` WHERE account_id=?"},
+		{&p.NrBankAccounts, "SELECT COUNT(*) FROM `bank_accounts` WHERE account_id=?"},
+		{&p.NrSubAccounts, "SELECT COUNT(*) FROM `accounts` WHERE parent_id=?"},
+		{&p.NrBudgets, "SELECT COUNT(*) FROM `budgets` WHERE account_id=?"},
+		{&p.NrEnvelopes, "SELECT COUNT(*) FROM `envelopes` WHERE account_id=?"},
+	} {
+		if err := db.Db().Get(c.nr, c.sql, from.ID); err != nil {
+			return nil, errors.Wrapf(err, "failed to count references")
+		}
+	}
+	if p.NrEnvelopes > 0 {
+		if e, err := getEnvelopeWhere("e.account_id=?", to.ID); err != nil {
+			return nil, err
+		} else if e != nil {
+			return nil, errors.Errorf("both accounts have envelopes, delete one of them before merging")
+		}
+	}
+	return &p, nil
+} //PreviewMerge()
+
+//MergeAccounts moves all transactions (postings), rules, bank accounts, budgets, envelopes, scheduled transactions and sub accounts of account fromID
+//to account toID then deletes account fromID, all in one db transaction.
+func MergeAccounts(fromID, toID string) (preview *MergePreview, err error) {
+	preview, err = PreviewMerge(fromID, toID)
+	if err != nil {
+		return nil, err
+	}
+	accByID, err := getAllAccounts()
+	if err != nil {
+		return nil, err
+	}
+	subIDs := append(descendantIDs(accByID, fromID), descendantIDs(accByID, toID)...)
+
+	tx, err := db.Db().Beginx()
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to begin db transaction")
+	}
+	defer func() {
+		if err != nil {
+			tx.Rollback()
+		}
+	}()
+
+	for _, update := range []string{
+		"UPDATE `transactions` SET dt_account_id=? WHERE dt_account_id=?",
+		"UPDATE `transactions` SET ct_account_id=? WHERE ct_account_id=?",
+		"UPDATE `postings` SET account_id=? WHERE account_id=?",
+		"UPDATE `rules` SET account_id=? WHERE account_id=?",
+		"UPDATE `bank_accounts` SET account_id=? WHERE account_id=?",
+		"UPDATE `envelopes` SET account_id=? WHERE account_id=?",
+		"UPDATE `scheduled_transactions` SET account_id=? WHERE account_id=?",
+		"UPDATE `accounts` SET parent_id=? WHERE parent_id=?",
+		//collapsed transfers restore this account on undo
+		"UPDATE `transfers` SET out_dt_account_id=? WHERE out_dt_account_id=?",
+	} {
+		if _, err = tx.Exec(update, toID, fromID); err != nil {
+			return nil, errors.Wrapf(err, "failed to merge: %s", update)
+		}
+	}
+	if err = mergeBudgets(tx, fromID, toID); err != nil {
+		return nil, err
+	}
+	//moved sub accounts inherit the type of their new parent
+	if err = setTypeOfAccounts(tx, subIDs, preview.To.Type); err != nil {
+		return nil, errors.Wrapf(err, "failed to update type of sub accounts")
+	}
+	if _, err = tx.Exec("DELETE FROM `accounts` WHERE id=?", fromID); err != nil {
+		return nil, errors.Wrapf(err, "failed to delete account")
+	}
+	if err = tx.Commit(); err != nil {
+		return nil, errors.Wrapf(err, "failed to commit")
+	}
+	log.Infof("Merged account(%s) into account(%s): %d transactions moved",
+		preview.From.Name, preview.To.Name, preview.NrTransactions)
+	return preview, nil
+} //MergeAccounts()
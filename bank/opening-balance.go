@@ -0,0 +1,161 @@
+package bank
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/go-msvc/errors"
+	"github.com/jansemmelink/money/db"
+	"github.com/jmoiron/sqlx"
+)
+
+const openingBalancesAccountName = "Opening balances"
+
+//OpeningBalance is the journal entry that debits a bank account with the
+//opening balance of its first statement and credits the equity account
+//"Opening balances", so that the bank account balance matches the statements.
+//It is dated the day before the opening date of the first statement.
+type OpeningBalance struct {
+	BankAccountID string `json:"bank_account_id"`
+	AccountName   string `json:"account_name"`
+	Date          string `json:"date"`
+	Amount        Amount `json:"amount"`
+	TransactionID string `json:"transaction_id,omitempty"`
+
+	//Old* describe the existing entry when it must change
+	OldDate   string `json:"old_date,omitempty"`
+	OldAmount Amount `json:"old_amount"`
+	Changed   bool   `json:"changed"`
+}
+
+//FixOpeningBalances recomputes the opening balance entries of all bank accounts
+//and returns those that changed. With dryRun nothing is updated.
+func FixOpeningBalances(dryRun bool) ([]OpeningBalance, error) {
+	baList, err := GetBankAccounts()
+	if err != nil {
+		return nil, err
+	}
+	list := []OpeningBalance{}
+	for _, ba := range baList {
+		ob, err := fixOpeningBalance(ba, dryRun)
+		if err != nil {
+			return list, errors.Wrapf(err, "failed on bank account %s %s", ba.BankName, ba.AccountNumber)
+		}
+		if ob.Changed {
+			list = append(list, ob)
+		}
+	}
+	return list, nil
+}
+
+//fixOpeningBalance replaces the opening balance entry of the bank account
+//when it does not match the first statement
+func fixOpeningBalance(ba BankAccount, dryRun bool) (ob OpeningBalance, err error) {
+	tx, err := db.Db().Beginx()
+	if err != nil {
+		return ob, errors.Wrapf(err, "failed to begin db transaction")
+	}
+	defer func() {
+		if err != nil {
+			tx.Rollback()
+		}
+	}()
+	if ob, err = postOpeningBalance(tx, ba, dryRun); err != nil {
+		return ob, err
+	}
+	if err = tx.Commit(); err != nil {
+		return ob, errors.Wrapf(err, "failed to commit")
+	}
+	if ob.Changed && !dryRun {
+		//documents linked only to the old entry
+		if _, err := PruneDocuments(); err != nil {
+			log.Errorf("failed to prune documents: %+v", err)
+		}
+	}
+	return ob, nil
+}
+
+//postOpeningBalance is fixOpeningBalance in the db transaction, so that the
+//old entry is only deleted along with posting the new one
+func postOpeningBalance(tx *sqlx.Tx, ba BankAccount, dryRun bool) (OpeningBalance, error) {
+	ob := OpeningBalance{BankAccountID: ba.ID}
+	if ba.Account != nil {
+		ob.AccountName = ba.Account.Name
+	}
+
+	//wanted entry from the first statement
+	var first struct {
+		OpeningDate    db.SqlTime `db:"opening_date"`
+		OpeningBalance Amount     `db:"opening_balance"`
+	}
+	if err := tx.Get(&first, "SELECT opening_date,opening_balance FROM `statements` WHERE bank_account_id=? ORDER BY opening_date LIMIT 1", ba.ID); err != nil {
+		if err != sql.ErrNoRows {
+			return ob, errors.Wrapf(err, "failed to get first statement")
+		}
+	} else {
+		//dated the day before the statement so that the ledger, ordered by
+		//date, lists it before the transactions on the opening date
+		ob.Date = db.SqlTime(time.Time(first.OpeningDate).AddDate(0, 0, -1)).Date()
+		ob.Amount = first.OpeningBalance
+	}
+
+	//existing entry
+	var existing struct {
+		TransactionID string     `db:"transaction_id"`
+		Date          db.SqlTime `db:"date"`
+		Amount        Amount     `db:"amount"`
+	}
+	if err := tx.Get(&existing, "SELECT t.id AS transaction_id,t.date,p.amount"+
+		" FROM `bank_accounts` AS ba"+
+		" JOIN `transactions` AS t ON t.id=ba.opening_transaction_id"+
+		" JOIN `postings` AS p ON p.transaction_id=t.id AND p.account_id=ba.account_id"+
+		" WHERE ba.id=?",
+		ba.ID,
+	); err != nil {
+		if err != sql.ErrNoRows {
+			return ob, errors.Wrapf(err, "failed to get opening balance entry")
+		}
+	} else {
+		ob.TransactionID = existing.TransactionID
+		ob.OldDate = existing.Date.Date()
+		ob.OldAmount = existing.Amount
+		if ob.OldDate == ob.Date && ob.OldAmount == ob.Amount {
+			return ob, nil //up to date
+		}
+	}
+	if ob.TransactionID == "" && ob.Amount.IsZero() {
+		return ob, nil //nothing to post
+	}
+	ob.Changed = true
+	if dryRun {
+		return ob, nil
+	}
+
+	if ob.TransactionID != "" {
+		if _, err := tx.Exec("DELETE FROM `transactions` WHERE id=?", ob.TransactionID); err != nil {
+			return ob, errors.Wrapf(err, "failed to delete old opening balance entry")
+		}
+		ob.TransactionID = ""
+	}
+	if !ob.Amount.IsZero() {
+		equity, err := getOrCreateAccount(openingBalancesAccountName, AccountTypeEquity)
+		if err != nil {
+			return ob, err
+		}
+		date, err := time.ParseInLocation("2006-01-02", ob.Date, time.Local)
+		if err != nil {
+			return ob, errors.Wrapf(err, "invalid opening date")
+		}
+		if ob.TransactionID, err = insertJournalEntry(tx, date, openingBalancesAccountName, []Posting{
+			{AccountID: ba.AccountID, Amount: ob.Amount},
+			{AccountID: equity.ID, Amount: ob.Amount.Neg()},
+		}); err != nil {
+			return ob, errors.Wrapf(err, "failed to post opening balance")
+		}
+	}
+	if _, err := tx.Exec("UPDATE `bank_accounts` SET opening_transaction_id=? WHERE id=?", nullIfEmpty(ob.TransactionID), ba.ID); err != nil {
+		return ob, errors.Wrapf(err, "failed to link opening balance entry")
+	}
+	log.Infof("Opening balance of %s on %s: %s", ob.AccountName, ob.Date, ob.Amount)
+	return ob, nil
+} //postOpeningBalance()
@@ -0,0 +1,31 @@
+package bank
+
+import (
+	"fmt"
+	"strings"
+)
+
+//ParseError is a problem found on a line of a statement file.
+//Line is 0 when it applies to the whole file.
+type ParseError struct {
+	Line    int    `json:"line,omitempty"`
+	Message string `json:"message"`
+}
+
+func (e ParseError) Error() string {
+	if e.Line == 0 {
+		return e.Message
+	}
+	return fmt.Sprintf("line(%d): %s", e.Line, e.Message)
+}
+
+//ParseErrors are all the problems found in a statement file
+type ParseErrors []ParseError
+
+func (list ParseErrors) Error() string {
+	s := []string{}
+	for _, e := range list {
+		s = append(s, e.Error())
+	}
+	return strings.Join(s, "; ")
+}
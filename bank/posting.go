@@ -0,0 +1,231 @@
+package bank
+
+import (
+	"time"
+
+	"github.com/go-msvc/errors"
+	"github.com/google/uuid"
+	"github.com/jansemmelink/money/db"
+	"github.com/jmoiron/sqlx"
+)
+
+//Posting is one leg of a transaction (journal entry).
+//Debits are positive and credits negative so that the postings
+//of a transaction sum to zero.
+type Posting struct {
+	ID            string `db:"id" json:"id"`
+	TransactionID string `db:"transaction_id" json:"transaction_id"`
+	AccountID     string `db:"account_id" json:"account_id"`
+	AccountName   string `db:"account_name" json:"account_name"`
+	Amount        Amount `db:"amount" json:"amount"`
+	Notes         string `db:"notes" json:"notes,omitempty"`
+}
+
+//GetPostings returns the postings of a transaction, debits first
+func GetPostings(transactionID string) ([]Posting, error) {
+	var list []Posting
+	if err := db.Db().Select(&list, "SELECT p.id,p.transaction_id,p.account_id,a.name AS account_name,p.amount,IFNULL(p.notes,'') AS notes"+
+		" FROM `postings` AS p"+
+		" JOIN `accounts` AS a ON a.id=p.account_id"+
+		" WHERE p.transaction_id=?"+
+		" ORDER BY p.amount LIKE '-%',a.name",
+		transactionID,
+	); err != nil {
+		return nil, errors.Wrapf(err, "failed to get postings of transaction(%s)", transactionID)
+	}
+	return list, nil
+}
+
+//ValidatePostings checks that there are at least two non-zero postings summing to zero
+func ValidatePostings(postings []Posting) error {
+	if len(postings) < 2 {
+		return errors.Errorf("%d postings, need at least 2", len(postings))
+	}
+	total := Amount{}
+	for i, p := range postings {
+		if p.AccountID == "" {
+			return errors.Errorf("posting[%d] missing account", i)
+		}
+		if p.Amount.IsZero() {
+			return errors.Errorf("posting[%d] has zero amount", i)
+		}
+		total = total.Add(p.Amount)
+	}
+	if !total.IsZero() {
+		return errors.Errorf("postings sum to %s instead of 0", total)
+	}
+	return nil
+}
+
+//twoLegPostings debits dt and credits ct with the amount without sign
+func twoLegPostings(dtAccountID, ctAccountID string, amount Amount) []Posting {
+	return []Posting{
+		{AccountID: dtAccountID, Amount: amount.Abs()},
+		{AccountID: ctAccountID, Amount: amount.Abs().Neg()},
+	}
+}
+
+func insertPostings(exec sqlx.Execer, transactionID string, postings []Posting) error {
+	for _, p := range postings {
+		if _, err := exec.Exec("INSERT INTO `postings` SET id=?,transaction_id=?,account_id=?,amount=?,notes=?",
+			uuid.New().String(),
+			transactionID,
+			p.AccountID,
+			p.Amount,
+			nullIfEmpty(limitStringLen(p.Notes, 200)),
+		); err != nil {
+			return errors.Wrapf(err, "failed to insert posting")
+		}
+	}
+	return nil
+}
+
+func replacePostings(exec sqlx.Execer, transactionID string, postings []Posting) error {
+	if _, err := exec.Exec("DELETE FROM `postings` WHERE transaction_id=?", transactionID); err != nil {
+		return errors.Wrapf(err, "failed to delete postings")
+	}
+	return insertPostings(exec, transactionID, postings)
+}
+
+//Split is part of the counter side of a bank transaction
+type Split struct {
+	AccountID string `json:"account_id"`
+	Amount    Amount `json:"amount"` //without sign
+	Notes     string `json:"notes,omitempty"`
+}
+
+//SplitTransaction replaces the counter account of a bank transaction with
+//several accounts. The split amounts must add up to the transaction amount.
+//After a split, the counter dt/ct column of the transaction is NULL
+//and only the postings describe the split.
+func SplitTransaction(id string, splits []Split) (err error) {
+	t, err := GetTransaction(id)
+	if err != nil {
+		return errors.Wrapf(err, "failed to get transaction")
+	}
+	if t == nil {
+		return notFoundf("transaction(%s) not found", id)
+	}
+	if t.TransferID != "" {
+		return errors.Errorf("transaction(%s) is collapsed into transfer(%s), undo the transfer first", id, t.TransferID)
+	}
+	//money paid out is credited to the bank and debited to the splits
+	moneyIn := t.Amount.MilliCents() > 0
+	bankAccountID := t.CtAccountID
+	if moneyIn {
+		bankAccountID = t.DtAccountID
+	}
+	if bankAccountID == "" {
+		return errors.Errorf("transaction(%s) has no bank account side", id)
+	}
+
+	postings := []Posting{{AccountID: bankAccountID, Amount: t.Amount}}
+	total := Amount{}
+	for i, s := range splits {
+		if s.Amount.MilliCents() <= 0 {
+			return errors.Errorf("split[%d] amount %s must be > 0", i, s.Amount)
+		}
+		total = total.Add(s.Amount)
+		p := Posting{AccountID: s.AccountID, Amount: s.Amount, Notes: s.Notes}
+		if moneyIn {
+			p.Amount = s.Amount.Neg()
+		}
+		postings = append(postings, p)
+	}
+	if total != t.Amount.Abs() {
+		return errors.Errorf("splits add up to %s instead of %s", total, t.Amount.Abs())
+	}
+	if err := ValidatePostings(postings); err != nil {
+		return errors.Wrapf(err, "invalid split")
+	}
+
+	tx, err := db.Db().Beginx()
+	if err != nil {
+		return errors.Wrapf(err, "failed to begin db transaction")
+	}
+	defer func() {
+		if err != nil {
+			tx.Rollback()
+		}
+	}()
+	counterColumn := "dt_account_id"
+	if moneyIn {
+		counterColumn = "ct_account_id"
+	}
+	if len(splits) == 1 {
+		_, err = tx.Exec("UPDATE `transactions` SET "+counterColumn+"=? WHERE id=?", splits[0].AccountID, t.ID)
+	} else {
+		_, err = tx.Exec("UPDATE `transactions` SET "+counterColumn+"=NULL WHERE id=?", t.ID)
+	}
+	if err != nil {
+		return errors.Wrapf(err, "failed to update transaction")
+	}
+	if err = replacePostings(tx, t.ID, postings); err != nil {
+		return err
+	}
+	if err = tx.Commit(); err != nil {
+		return errors.Wrapf(err, "failed to commit")
+	}
+	log.Infof("Split transaction(%s) into %d", t.ID, len(splits))
+	return nil
+} //SplitTransaction()
+
+//CreateJournalEntry adds a transaction that is not from a bank statement
+//and returns its id
+func CreateJournalEntry(date time.Time, notes string, postings []Posting) (id string, err error) {
+	if err := ValidatePostings(postings); err != nil {
+		return "", errors.Wrapf(err, "invalid journal entry")
+	}
+	tx, err := db.Db().Beginx()
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to begin db transaction")
+	}
+	defer func() {
+		if err != nil {
+			tx.Rollback()
+		}
+	}()
+	if id, err = insertJournalEntry(tx, date, notes, postings); err != nil {
+		return "", err
+	}
+	if err = tx.Commit(); err != nil {
+		return "", errors.Wrapf(err, "failed to commit")
+	}
+	log.Infof("Created journal entry(%s) with %d postings", id, len(postings))
+	return id, nil
+} //CreateJournalEntry()
+
+//insertJournalEntry inserts the transaction with its valid postings and returns its id
+func insertJournalEntry(exec sqlx.Execer, date time.Time, notes string, postings []Posting) (string, error) {
+	total := Amount{}
+	var dtAccountID, ctAccountID interface{}
+	for _, p := range postings {
+		if p.Amount.MilliCents() > 0 {
+			total = total.Add(p.Amount)
+		}
+	}
+	if len(postings) == 2 {
+		for _, p := range postings {
+			if p.Amount.MilliCents() > 0 {
+				dtAccountID = p.AccountID
+			} else {
+				ctAccountID = p.AccountID
+			}
+		}
+	}
+	id := uuid.New().String()
+	if _, err := exec.Exec("INSERT INTO `transactions` SET id=?, date=?, amount=?, dt_account_id=?, ct_account_id=?, notes=?",
+		id,
+		date,
+		total,
+		dtAccountID,
+		ctAccountID,
+		nullIfEmpty(limitStringLen(notes, 200)),
+	); err != nil {
+		return "", errors.Wrapf(err, "failed to insert journal entry")
+	}
+	if err := insertPostings(exec, id, postings); err != nil {
+		return "", err
+	}
+	return id, nil
+} //insertJournalEntry()
@@ -0,0 +1,25 @@
+package bank_test
+
+import (
+	"testing"
+
+	"github.com/jansemmelink/money/bank"
+)
+
+func TestValidatePostings(t *testing.T) {
+	spar := []bank.Posting{
+		{AccountID: "cheque", Amount: amount(t, "-419.98")},
+		{AccountID: "groceries", Amount: amount(t, "300")},
+		{AccountID: "household", Amount: amount(t, "69.99")},
+		{AccountID: "alcohol", Amount: amount(t, "49.99")},
+	}
+	assert(t, bank.ValidatePostings(spar))
+
+	spar[3].Amount = amount(t, "50")
+	if err := bank.ValidatePostings(spar); err == nil {
+		t.Fatalf("accepted postings that do not sum to zero")
+	}
+	if err := bank.ValidatePostings(spar[:1]); err == nil {
+		t.Fatalf("accepted single posting")
+	}
+}
@@ -0,0 +1,285 @@
+package bank
+
+import (
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+)
+
+//RecurringInterval is how often a recurring transaction repeats
+type RecurringInterval string
+
+const (
+	Weekly  RecurringInterval = "weekly"
+	Monthly RecurringInterval = "monthly"
+	Annual  RecurringInterval = "annual"
+)
+
+//recurringIntervals with the range of days between payments and the
+//nr of days after the expected date before a payment is missed
+var recurringIntervals = []struct {
+	interval         RecurringInterval
+	minDays, maxDays int
+	graceDays        int
+}{
+	{Weekly, 6, 8, 3},
+	{Monthly, 26, 35, 7},
+	{Annual, 350, 380, 30},
+}
+
+//Next date after date
+func (i RecurringInterval) Next(date time.Time) time.Time {
+	switch i {
+	case Weekly:
+		return date.AddDate(0, 0, 7)
+	case Monthly:
+		return date.AddDate(0, 1, 0)
+	case Annual:
+		return date.AddDate(1, 0, 0)
+	}
+	return date
+}
+
+//RecurringOptions control FindRecurring
+type RecurringOptions struct {
+	Tolerance float64 //max relative difference between amounts in a series, e.g. 0.2 for 20%
+	MinCount  int     //min nr of payments in a weekly or monthly series (annual needs 2)
+}
+
+var DefaultRecurringOptions = RecurringOptions{Tolerance: 0.2, MinCount: 3}
+
+//PriceChange is a change in the amount of a recurring transaction
+type PriceChange struct {
+	Date    string  `json:"date"`
+	From    Amount  `json:"from"`
+	To      Amount  `json:"to"`
+	Percent float64 `json:"percent"` //increase (+) or decrease (-) of the amount without sign
+}
+
+//Recurring status
+const (
+	RecurringOK      = "ok"
+	RecurringMissed  = "missed"  //expected payment did not appear
+	RecurringChanged = "changed" //last payment differs from the one before
+)
+
+//RecurringSeries is a list of similar transactions on a bank account repeating at an interval
+type RecurringSeries struct {
+	BankAccountID  string            `json:"bank_account_id"`
+	Merchant       string            `json:"merchant"`
+	Interval       RecurringInterval `json:"interval"`
+	Count          int               `json:"count"`
+	First          string            `json:"first"`
+	Last           string            `json:"last"`
+	LastAmount     Amount            `json:"last_amount"`
+	AverageAmount  Amount            `json:"average_amount"`
+	NextDate       string            `json:"next_date"`
+	NextAmount     Amount            `json:"next_amount"`
+	CounterAccount string            `json:"counter_account"`
+	Status         string            `json:"status"`
+	NrMissed       int               `json:"nr_missed,omitempty"`
+	PriceChanges   []PriceChange     `json:"price_changes,omitempty"`
+	TransactionIDs []string          `json:"transaction_ids"`
+
+	next time.Time
+}
+
+//NextTime is the expected date of the next payment
+func (s RecurringSeries) NextTime() time.Time { return s.next }
+
+//Merchant is the leading words of the statement details up to the first word
+//with digits, without card prefixes, e.g. "SASOL MIDRI" from "C*SASOL MIDRI 5222*7143 24 SEP"
+func Merchant(details string) string {
+	words := []string{}
+	for _, w := range strings.Fields(strings.ToUpper(details)) {
+		if strings.IndexFunc(w, unicode.IsDigit) >= 0 {
+			break
+		}
+		if i := strings.LastIndex(w, "*"); i >= 0 {
+			w = w[i+1:]
+		}
+		if w != "" {
+			words = append(words, w)
+		}
+		if len(words) == 3 {
+			break
+		}
+	}
+	return strings.Join(words, " ")
+}
+
+//GetRecurring detects recurring series in all bank statement transactions
+func GetRecurring(opts RecurringOptions) ([]RecurringSeries, error) {
+	txList, err := GetTransactions(TransactionFilter{})
+	if err != nil {
+		return nil, err
+	}
+	return FindRecurring(txList, opts), nil
+}
+
+//FindRecurring groups the bank transactions by bank account, merchant and sign, splits
+//the groups by amount and keeps those that repeat weekly, monthly or annually.
+//A series is missed when the next payment is overdue at the date of the
+//last transaction of its bank account.
+func FindRecurring(txList []TransactionRecord, opts RecurringOptions) []RecurringSeries {
+	if opts.MinCount < 2 {
+		opts.MinCount = 2
+	}
+	lastDate := map[string]time.Time{}
+	groups := map[string][]TransactionRecord{}
+	keys := []string{}
+	for _, tx := range txList {
+		if tx.BankAccountID == "" || tx.Amount.IsZero() {
+			continue
+		}
+		date := time.Time(tx.Date)
+		if date.After(lastDate[tx.BankAccountID]) {
+			lastDate[tx.BankAccountID] = date
+		}
+		merchant := Merchant(tx.StatementDetails)
+		if merchant == "" {
+			merchant = strings.ToUpper(strings.TrimSpace(tx.StatementType))
+		}
+		key := tx.BankAccountID + "|" + merchant + "|" + sign(tx.Amount)
+		if _, ok := groups[key]; !ok {
+			keys = append(keys, key)
+		}
+		groups[key] = append(groups[key], tx)
+	}
+	sort.Strings(keys)
+
+	list := []RecurringSeries{}
+	for _, key := range keys {
+		group := groups[key]
+		sort.SliceStable(group, func(i, j int) bool { return time.Time(group[i].Date).Before(time.Time(group[j].Date)) })
+		merchant := strings.Split(key, "|")[1]
+		for _, cluster := range clusterByAmount(group, opts.Tolerance) {
+			if s, ok := newRecurringSeries(merchant, cluster, opts, lastDate[cluster[0].BankAccountID]); ok {
+				list = append(list, s)
+			}
+		}
+	}
+	sort.SliceStable(list, func(i, j int) bool { return list[i].next.Before(list[j].next) })
+	return list
+} //FindRecurring()
+
+func sign(a Amount) string {
+	if a.MilliCents() < 0 {
+		return "-"
+	}
+	return "+"
+}
+
+//clusterByAmount puts each transaction in the first cluster where the last amount is within tolerance
+func clusterByAmount(group []TransactionRecord, tolerance float64) [][]TransactionRecord {
+	clusters := [][]TransactionRecord{}
+	for _, tx := range group {
+		added := false
+		for i, c := range clusters {
+			if withinTolerance(c[len(c)-1].Amount, tx.Amount, tolerance) {
+				clusters[i] = append(c, tx)
+				added = true
+				break
+			}
+		}
+		if !added {
+			clusters = append(clusters, []TransactionRecord{tx})
+		}
+	}
+	return clusters
+}
+
+func withinTolerance(a, b Amount, tolerance float64) bool {
+	x := float64(a.Abs().MilliCents())
+	y := float64(b.Abs().MilliCents())
+	diff := x - y
+	if diff < 0 {
+		diff = -diff
+	}
+	return diff <= tolerance*x
+}
+
+func newRecurringSeries(merchant string, txList []TransactionRecord, opts RecurringOptions, asOf time.Time) (RecurringSeries, bool) {
+	s := RecurringSeries{}
+	if len(txList) < 2 {
+		return s, false
+	}
+	gaps := []int{}
+	for i := 1; i < len(txList); i++ {
+		gaps = append(gaps, daysBetween(time.Time(txList[i-1].Date), time.Time(txList[i].Date)))
+	}
+	sorted := append([]int{}, gaps...)
+	sort.Ints(sorted)
+	median := sorted[len(sorted)/2]
+	found := false
+	graceDays := 0
+	for _, ri := range recurringIntervals {
+		if median < ri.minDays || median > ri.maxDays {
+			continue
+		}
+		if ri.interval != Annual && len(txList) < opts.MinCount {
+			return s, false
+		}
+		//most gaps must match, allowing for a missed payment in between
+		nrMatched := 0
+		for _, g := range gaps {
+			for n := 1; n <= 3; n++ {
+				if g >= ri.minDays*n && g <= ri.maxDays*n {
+					nrMatched++
+					break
+				}
+			}
+		}
+		if nrMatched*4 < len(gaps)*3 {
+			return s, false
+		}
+		s.Interval = ri.interval
+		graceDays = ri.graceDays
+		found = true
+		break
+	}
+	if !found {
+		return s, false
+	}
+
+	first := txList[0]
+	last := txList[len(txList)-1]
+	s.BankAccountID = last.BankAccountID
+	s.Merchant = merchant
+	s.Count = len(txList)
+	s.First = first.Date.Date()
+	s.Last = last.Date.Date()
+	s.LastAmount = last.Amount
+	s.NextAmount = last.Amount
+	s.CounterAccount = last.CounterAccountName()
+	total := Amount{}
+	for i, tx := range txList {
+		total = total.Add(tx.Amount)
+		s.TransactionIDs = append(s.TransactionIDs, tx.ID)
+		if i > 0 && tx.Amount != txList[i-1].Amount {
+			prev := txList[i-1].Amount.Abs()
+			s.PriceChanges = append(s.PriceChanges, PriceChange{
+				Date:    tx.Date.Date(),
+				From:    txList[i-1].Amount,
+				To:      tx.Amount,
+				Percent: float64(tx.Amount.Abs().MilliCents()-prev.MilliCents()) * 100 / float64(prev.MilliCents()),
+			})
+		}
+	}
+	s.AverageAmount = Amount{mc: total.MilliCents() / int64(len(txList))}
+
+	s.next = s.Interval.Next(time.Time(last.Date))
+	s.Status = RecurringOK
+	for !asOf.IsZero() && asOf.After(s.next.AddDate(0, 0, graceDays)) {
+		s.NrMissed++
+		s.next = s.Interval.Next(s.next)
+	}
+	if s.NrMissed > 0 {
+		s.Status = RecurringMissed
+	} else if len(txList) > 1 && last.Amount != txList[len(txList)-2].Amount {
+		s.Status = RecurringChanged
+	}
+	s.NextDate = s.next.Local().Format("2006-01-02")
+	return s, true
+} //newRecurringSeries()
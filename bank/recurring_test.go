@@ -0,0 +1,73 @@
+package bank_test
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/jansemmelink/money/bank"
+	"github.com/jansemmelink/money/db"
+)
+
+func TestMerchant(t *testing.T) {
+	for details, merchant := range map[string]string{
+		"C*SASOL MIDRI 5222*7143 24 SEP": "SASOL MIDRI",
+		"OUTSURANCE OT11259326   94752Q": "OUTSURANCE",
+		"Netflix.com Los Gatos":          "NETFLIX.COM LOS GATOS",
+		"1234 only digits":               "",
+	} {
+		if m := bank.Merchant(details); m != merchant {
+			t.Errorf("Merchant(%s)=\"%s\" != \"%s\"", details, m, merchant)
+		}
+	}
+}
+
+func TestFindRecurring(t *testing.T) {
+	txList := []bank.TransactionRecord{}
+	add := func(date string, amount string, details string) {
+		d, err := time.Parse("2006-01-02", date)
+		assert(t, err)
+		a, err := bank.NewAmount(amount)
+		assert(t, err)
+		txList = append(txList, bank.TransactionRecord{
+			ID:               fmt.Sprintf("%d", len(txList)+1),
+			Date:             db.SqlTime(d),
+			Amount:           a,
+			BankAccountID:    "cheque",
+			StatementDetails: details,
+		})
+	}
+	//monthly premium with a price increase in january
+	add("2020-10-01", "-899.12", "OUTSURANCE OT11259326 94752Q")
+	add("2020-11-02", "-899.12", "OUTSURANCE OT11259326 94752Q")
+	add("2020-12-01", "-899.12", "OUTSURANCE OT11259326 94752Q")
+	add("2021-01-04", "-950.00", "OUTSURANCE OT11259326 94752Q")
+	add("2021-02-01", "-950.00", "OUTSURANCE OT11259326 94752Q")
+	//weekly that stopped in january
+	add("2020-12-03", "-50", "GYM CLASS 123")
+	add("2020-12-10", "-50", "GYM CLASS 124")
+	add("2020-12-17", "-50", "GYM CLASS 125")
+	add("2020-12-24", "-50", "GYM CLASS 126")
+	//fuel at random intervals and amounts is not recurring
+	add("2020-10-05", "-932", "C*SASOL MIDRI 5222*7143 05 OCT")
+	add("2020-10-09", "-500", "C*SASOL MIDRI 5222*7143 09 OCT")
+	add("2020-12-28", "-700", "C*SASOL MIDRI 5222*7143 28 DEC")
+
+	list := bank.FindRecurring(txList, bank.DefaultRecurringOptions)
+	if len(list) != 2 {
+		t.Fatalf("found %d series: %+v", len(list), list)
+	}
+	gym, premium := list[0], list[1]
+	if gym.Merchant != "GYM CLASS" || gym.Interval != bank.Weekly || gym.Status != bank.RecurringMissed || gym.NrMissed != 5 {
+		t.Errorf("gym %+v", gym)
+	}
+	if premium.Merchant != "OUTSURANCE" || premium.Interval != bank.Monthly || premium.Count != 5 {
+		t.Errorf("premium %+v", premium)
+	}
+	if premium.Status != bank.RecurringOK || premium.NextDate != "2021-03-01" || premium.NextAmount.String() != "-950.00" {
+		t.Errorf("premium next %s %s %s", premium.Status, premium.NextDate, premium.NextAmount)
+	}
+	if len(premium.PriceChanges) != 1 || premium.PriceChanges[0].Date != "2021-01-04" || int(premium.PriceChanges[0].Percent) != 5 {
+		t.Errorf("premium price changes %+v", premium.PriceChanges)
+	}
+}
@@ -0,0 +1,41 @@
+package bank
+
+import (
+	"github.com/go-msvc/errors"
+	"github.com/jansemmelink/money/db"
+)
+
+//SkipReview remembers that the unknown transaction was skipped during review
+//so that the next review can resume after it
+func SkipReview(transactionID string) error {
+	if _, err := db.Db().Exec("INSERT IGNORE INTO `review_skips` SET transaction_id=?", transactionID); err != nil {
+		return errors.Wrapf(err, "failed to insert review skip")
+	}
+	return nil
+}
+
+//GetReviewSkips returns the ids of transactions skipped during review
+func GetReviewSkips() (map[string]bool, error) {
+	var ids []string
+	if err := db.Db().Select(&ids, "SELECT transaction_id FROM `review_skips`"); err != nil {
+		return nil, errors.Wrapf(err, "failed to select review skips")
+	}
+	skipped := map[string]bool{}
+	for _, id := range ids {
+		skipped[id] = true
+	}
+	return skipped, nil
+}
+
+//ClearReviewSkips forgets all skipped transactions so they are reviewed again
+func ClearReviewSkips() error {
+	if _, err := db.Db().Exec("DELETE FROM `review_skips`"); err != nil {
+		return errors.Wrapf(err, "failed to delete review skips")
+	}
+	return nil
+}
+
+//IsUnknown is true while the counter account is still unknown expense/income
+func (t TransactionRecord) IsUnknown() bool {
+	return isUnknownAccountName(t.CounterAccountName())
+}
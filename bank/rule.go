@@ -0,0 +1,285 @@
+package bank
+
+import (
+	"database/sql"
+	"regexp"
+	"strings"
+
+	"github.com/go-msvc/errors"
+	"github.com/google/uuid"
+	"github.com/jansemmelink/money/db"
+)
+
+//Rule assigns the counter account (and optional notes) to bank transactions.
+//All non-empty match fields must match, and rules are tried in order of
+//priority (lowest first) and name, so the first matching rule is applied.
+type Rule struct {
+	ID       string `db:"id" json:"id"`
+	Name     string `db:"name" json:"name"`
+	Priority int    `db:"priority" json:"priority"`
+
+	//match fields
+	BankAccountID   string  `db:"bank_account_id" json:"bank_account_id,omitempty"`
+	StatementType   string  `db:"statement_type" json:"statement_type,omitempty"`     //case insensitive equal
+	StatementCode   string  `db:"statement_code" json:"statement_code,omitempty"`     //case insensitive equal
+	DetailsContains string  `db:"details_contains" json:"details_contains,omitempty"` //case insensitive substring
+	DetailsRegex    string  `db:"details_regex" json:"details_regex,omitempty"`
+	Sign            string  `db:"sign" json:"sign,omitempty"`             //"+" for income, "-" for expenses
+	MinAmount       *Amount `db:"min_amount" json:"min_amount,omitempty"` //compared to amount without sign
+	MaxAmount       *Amount `db:"max_amount" json:"max_amount,omitempty"` //compared to amount without sign
+
+	//assigned when matched
+	AccountID   string `db:"account_id" json:"account_id"`
+	AccountName string `db:"account_name" json:"account_name"`
+	Notes       string `db:"notes" json:"notes,omitempty"`
+
+	regex *regexp.Regexp
+}
+
+const ruleSelect = "SELECT r.id,r.name,r.priority" +
+	",IFNULL(r.bank_account_id,'') AS bank_account_id" +
+	",IFNULL(r.statement_type,'') AS statement_type" +
+	",IFNULL(r.statement_code,'') AS statement_code" +
+	",IFNULL(r.details_contains,'') AS details_contains" +
+	",IFNULL(r.details_regex,'') AS details_regex" +
+	",IFNULL(r.sign,'') AS sign" +
+	",r.min_amount,r.max_amount" +
+	",r.account_id,a.name AS account_name" +
+	",IFNULL(r.notes,'') AS notes" +
+	" FROM `rules` AS r" +
+	" JOIN `accounts` AS a ON a.id=r.account_id"
+
+//GetRules returns all rules in the order they are applied
+func GetRules() ([]Rule, error) {
+	var list []Rule
+	if err := db.Db().Select(&list, ruleSelect+" ORDER BY r.priority,r.name"); err != nil {
+		return nil, errors.Wrapf(err, "failed to get list of rules")
+	}
+	for i := range list {
+		if err := list[i].compile(); err != nil {
+			return nil, errors.Wrapf(err, "invalid rule(%s)", list[i].Name)
+		}
+	}
+	return list, nil
+}
+
+//GetRule returns nil,nil when not found
+func GetRule(id string) (*Rule, error) {
+	var r Rule
+	if err := db.Db().Get(&r, ruleSelect+" WHERE r.id=?", id); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, errors.Wrapf(err, "failed to select rule")
+	}
+	if err := r.compile(); err != nil {
+		return nil, errors.Wrapf(err, "invalid rule(%s)", r.Name)
+	}
+	return &r, nil
+}
+
+func (r *Rule) compile() error {
+	r.regex = nil
+	if r.DetailsRegex != "" {
+		regex, err := regexp.Compile("(?i)" + r.DetailsRegex)
+		if err != nil {
+			return errors.Wrapf(err, "invalid details_regex")
+		}
+		r.regex = regex
+	}
+	return nil
+}
+
+func (r *Rule) Validate() error {
+	if r.Name == "" {
+		return errors.Errorf("missing name")
+	}
+	if r.AccountID == "" {
+		return errors.Errorf("missing account_id")
+	}
+	if r.Sign != "" && r.Sign != "+" && r.Sign != "-" {
+		return errors.Errorf("invalid sign \"%s\" expects \"+\" or \"-\"", r.Sign)
+	}
+	if r.MinAmount != nil && r.MaxAmount != nil && r.MinAmount.MilliCents() > r.MaxAmount.MilliCents() {
+		return errors.Errorf("min_amount %s > max_amount %s", r.MinAmount, r.MaxAmount)
+	}
+	if r.BankAccountID == "" &&
+		r.StatementType == "" &&
+		r.StatementCode == "" &&
+		r.DetailsContains == "" &&
+		r.DetailsRegex == "" &&
+		r.MinAmount == nil &&
+		r.MaxAmount == nil {
+		return errors.Errorf("rule does not match on anything")
+	}
+	return r.compile()
+}
+
+//Matches is true when the statement transaction of the bank account matches all fields of the rule
+func (r Rule) Matches(bankAccountID string, tx Transaction) bool {
+	if r.BankAccountID != "" && r.BankAccountID != bankAccountID {
+		return false
+	}
+	if r.StatementType != "" && !strings.EqualFold(strings.TrimSpace(r.StatementType), strings.TrimSpace(tx.Type)) {
+		return false
+	}
+	if r.StatementCode != "" && !strings.EqualFold(strings.TrimSpace(r.StatementCode), strings.TrimSpace(tx.Code)) {
+		return false
+	}
+	if r.DetailsContains != "" && !strings.Contains(strings.ToUpper(tx.Details), strings.ToUpper(r.DetailsContains)) {
+		return false
+	}
+	if r.DetailsRegex != "" {
+		if r.regex == nil {
+			if err := r.compile(); err != nil {
+				return false
+			}
+		}
+		if !r.regex.MatchString(tx.Details) {
+			return false
+		}
+	}
+	switch r.Sign {
+	case "+":
+		if tx.Amount.MilliCents() <= 0 {
+			return false
+		}
+	case "-":
+		if tx.Amount.MilliCents() >= 0 {
+			return false
+		}
+	}
+	if r.MinAmount != nil && tx.Amount.Abs().MilliCents() < r.MinAmount.MilliCents() {
+		return false
+	}
+	if r.MaxAmount != nil && tx.Amount.Abs().MilliCents() > r.MaxAmount.MilliCents() {
+		return false
+	}
+	return true
+} //Rule.Matches()
+
+//MatchRule returns the first matching rule from the list, or nil if none matched
+func MatchRule(rules []Rule, bankAccountID string, tx Transaction) *Rule {
+	for i := range rules {
+		if rules[i].Matches(bankAccountID, tx) {
+			return &rules[i]
+		}
+	}
+	return nil
+}
+
+func (r *Rule) Save() error {
+	if err := r.Validate(); err != nil {
+		return errors.Wrapf(err, "invalid rule")
+	}
+	args := []interface{}{
+		r.Name,
+		r.Priority,
+		nullIfEmpty(r.BankAccountID),
+		nullIfEmpty(r.StatementType),
+		nullIfEmpty(r.StatementCode),
+		nullIfEmpty(r.DetailsContains),
+		nullIfEmpty(r.DetailsRegex),
+		nullIfEmpty(r.Sign),
+		r.MinAmount,
+		r.MaxAmount,
+		r.AccountID,
+		nullIfEmpty(limitStringLen(r.Notes, 200)),
+	}
+	set := " name=?,priority=?,bank_account_id=?,statement_type=?,statement_code=?,details_contains=?,details_regex=?" +
+		",sign=?,min_amount=?,max_amount=?,account_id=?,notes=?"
+	if r.ID == "" {
+		id := uuid.New().String()
+		if _, err := db.Db().Exec("INSERT INTO `rules` SET id=?,"+set, append([]interface{}{id}, args...)...); err != nil {
+			return errors.Wrapf(err, "failed to insert rule")
+		}
+		r.ID = id
+		log.Infof("Inserted rule(%s)", r.ID)
+	} else {
+		if _, err := db.Db().Exec("UPDATE `rules` SET"+set+" WHERE id=?", append(args, r.ID)...); err != nil {
+			return errors.Wrapf(err, "failed to update rule")
+		}
+		log.Infof("Updated rule(%s)", r.ID)
+	}
+	return nil
+} //Rule.Save()
+
+func DeleteRule(id string) error {
+	result, err := db.Db().Exec("DELETE FROM `rules` WHERE id=?", id)
+	if err != nil {
+		return errors.Wrapf(err, "failed to delete rule")
+	}
+	if nr, _ := result.RowsAffected(); nr != 1 {
+		return notFoundf("rule(%s) not found", id)
+	}
+	return nil
+}
+
+//RuleMatch is a transaction with the rule that matched it
+type RuleMatch struct {
+	Transaction TransactionRecord `json:"transaction"`
+	Rule        Rule              `json:"rule"`
+}
+
+//ApplyRules matches the rules to all transactions still on the unknown
+//expense/income accounts. With dryRun the matches are only returned,
+//else the matched transactions are updated.
+func ApplyRules(dryRun bool) ([]RuleMatch, error) {
+	rules, err := GetRules()
+	if err != nil {
+		return nil, err
+	}
+	return applyRules(rules, dryRun)
+}
+
+//ApplyRule is ApplyRules with only the one rule, e.g. a new rule
+//that does not have to be saved before the dry run
+func ApplyRule(rule Rule, dryRun bool) ([]RuleMatch, error) {
+	return applyRules([]Rule{rule}, dryRun)
+}
+
+func applyRules(rules []Rule, dryRun bool) ([]RuleMatch, error) {
+	matches := []RuleMatch{}
+	if len(rules) == 0 {
+		return matches, nil
+	}
+	unknownTxList, err := GetUnknownTransactions(0)
+	if err != nil {
+		return nil, err
+	}
+	for _, tx := range unknownTxList {
+		rule := MatchRule(rules, tx.BankAccountID, tx.StatementTransaction())
+		if rule == nil {
+			continue
+		}
+		tx.SetCounterAccount(Account{ID: rule.AccountID, Name: rule.AccountName})
+		if rule.Notes != "" && tx.Notes == "" {
+			tx.Notes = rule.Notes
+		}
+		if !dryRun {
+			if err := tx.Save(); err != nil {
+				return matches, errors.Wrapf(err, "failed to update transaction(%s)", tx.ID)
+			}
+		}
+		matches = append(matches, RuleMatch{Transaction: tx, Rule: *rule})
+	}
+	return matches, nil
+} //applyRules()
+
+//GetUnknownTransactions returns transactions still on the unknown expense/income accounts,
+//oldest first
+func GetUnknownTransactions(limit int) ([]TransactionRecord, error) {
+	sql := transactionRecordSelect +
+		" WHERE (dt.name=? OR ct.name=?) AND t.transfer_id IS NULL" +
+		" ORDER BY t.date,t.id"
+	args := []interface{}{unknownExpenseAccountName, unknownIncomeAccountName}
+	if limit > 0 {
+		sql += " LIMIT ?"
+		args = append(args, limit)
+	}
+	var list []TransactionRecord
+	if err := db.Db().Select(&list, sql, args...); err != nil {
+		return nil, errors.Wrapf(err, "failed to get unknown transactions")
+	}
+	return list, nil
+}
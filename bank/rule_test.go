@@ -0,0 +1,39 @@
+package bank_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/jansemmelink/money/bank"
+)
+
+func TestRuleMatches(t *testing.T) {
+	max := amount(t, "1000")
+	diesel := bank.Rule{Name: "diesel", DetailsRegex: "sasol|engen", Sign: "-", MaxAmount: &max}
+	spar := bank.Rule{Name: "spar", DetailsContains: "spar midstrea", BankAccountID: "cheque"}
+
+	tx := bank.NewTransaction(time.Now(), amount(t, "-932"), "TJEKKAART-AANKOOP", "C*SASOL MIDRI", "6076")
+	if !diesel.Matches("cheque", tx) {
+		t.Fatalf("diesel did not match %+v", tx)
+	}
+	if spar.Matches("cheque", tx) {
+		t.Fatalf("spar matched %+v", tx)
+	}
+
+	tx.Amount = amount(t, "-1024.12")
+	if diesel.Matches("cheque", tx) {
+		t.Fatalf("diesel matched above max %+v", tx)
+	}
+	tx.Amount = amount(t, "259.37")
+	if diesel.Matches("cheque", tx) {
+		t.Fatalf("diesel matched income %+v", tx)
+	}
+
+	tx = bank.NewTransaction(time.Now(), amount(t, "-211.22"), "TJEKKAART-AANKOOP", "Spar Midstrea 5222*7143", "6076")
+	if r := bank.MatchRule([]bank.Rule{diesel, spar}, "cheque", tx); r == nil || r.Name != "spar" {
+		t.Fatalf("spar not matched: %+v", r)
+	}
+	if r := bank.MatchRule([]bank.Rule{diesel, spar}, "credit", tx); r != nil {
+		t.Fatalf("matched other bank account: %+v", r)
+	}
+}
@@ -0,0 +1,331 @@
+package bank
+
+import (
+	"database/sql"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/go-msvc/errors"
+	"github.com/google/uuid"
+	"github.com/jansemmelink/money/db"
+	"github.com/jmoiron/sqlx"
+)
+
+//Recurrence of a scheduled transaction
+type Recurrence string
+
+const (
+	RecurrenceOnce      Recurrence = "once"
+	RecurrenceWeekly    Recurrence = "weekly"
+	RecurrenceMonthly   Recurrence = "monthly"
+	RecurrenceQuarterly Recurrence = "quarterly"
+	RecurrenceAnnually  Recurrence = "annually"
+)
+
+func ParseRecurrence(s string) (Recurrence, error) {
+	switch r := Recurrence(strings.ToLower(s)); r {
+	case RecurrenceOnce, RecurrenceWeekly, RecurrenceMonthly, RecurrenceQuarterly, RecurrenceAnnually:
+		return r, nil
+	}
+	return "", errors.Errorf("invalid recurrence \"%s\" (expects %s|%s|%s|%s|%s)", s,
+		RecurrenceOnce, RecurrenceWeekly, RecurrenceMonthly, RecurrenceQuarterly, RecurrenceAnnually)
+}
+
+//ScheduledTransaction is a payment (amount < 0) or receipt (amount > 0) on
+//a bank account expected on the start date and then every nr of weeks,
+//months, quarters or years until the optional end date
+type ScheduledTransaction struct {
+	ID              string      `db:"id" json:"id"`
+	Name            string      `db:"name" json:"name"`
+	BankAccountID   string      `db:"bank_account_id" json:"bank_account_id"`
+	AccountID       string      `db:"account_id" json:"account_id"` //counter account assigned when matched
+	AccountName     string      `db:"account_name" json:"account_name"`
+	Amount          Amount      `db:"amount" json:"amount"` //with the sign as on the bank statement
+	DetailsContains string      `db:"details_contains" json:"details_contains,omitempty"`
+	StartDate       db.SqlTime  `db:"start_date" json:"start_date"`
+	EndDate         *db.SqlTime `db:"end_date" json:"end_date,omitempty"`
+	Recurrence      Recurrence  `db:"recurrence" json:"recurrence"`
+	Every           int         `db:"every" json:"every"`
+	DaysTolerance   int         `db:"days_tolerance" json:"days_tolerance"`     //match bank transactions this nr of days before/after the due date
+	AmountTolerance int         `db:"amount_tolerance" json:"amount_tolerance"` //match amounts this % more or less
+	Notes           string      `db:"notes" json:"notes,omitempty"`
+}
+
+const scheduledSelect = "SELECT s.id,s.name,s.bank_account_id,s.account_id,a.name AS account_name,s.amount" +
+	",IFNULL(s.details_contains,'') AS details_contains" +
+	",s.start_date,s.end_date,s.recurrence,s.every,s.days_tolerance,s.amount_tolerance" +
+	",IFNULL(s.notes,'') AS notes" +
+	" FROM `scheduled_transactions` AS s" +
+	" JOIN `accounts` AS a ON a.id=s.account_id"
+
+func GetScheduledTransactions() ([]ScheduledTransaction, error) {
+	var list []ScheduledTransaction
+	if err := db.Db().Select(&list, scheduledSelect+" ORDER BY s.name"); err != nil {
+		return nil, errors.Wrapf(err, "failed to get scheduled transactions")
+	}
+	return list, nil
+}
+
+//GetScheduledTransaction returns nil,nil when not found
+func GetScheduledTransaction(id string) (*ScheduledTransaction, error) {
+	var s ScheduledTransaction
+	if err := db.Db().Get(&s, scheduledSelect+" WHERE s.id=?", id); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, errors.Wrapf(err, "failed to select scheduled transaction")
+	}
+	return &s, nil
+}
+
+func (s *ScheduledTransaction) Validate() error {
+	if s.Name == "" {
+		return invalidf("name", "missing name")
+	}
+	if s.BankAccountID == "" {
+		return invalidf("bank_account_id", "missing bank_account_id")
+	}
+	if s.AccountID == "" {
+		return invalidf("account_id", "missing account_id")
+	}
+	if s.Amount.IsZero() {
+		return invalidf("amount", "missing amount")
+	}
+	if time.Time(s.StartDate).IsZero() {
+		return invalidf("start_date", "missing start_date")
+	}
+	if s.EndDate != nil && time.Time(*s.EndDate).Before(time.Time(s.StartDate)) {
+		return invalidf("end_date", "end_date is before start_date")
+	}
+	if _, err := ParseRecurrence(string(s.Recurrence)); err != nil {
+		return invalidf("recurrence", "%s", err)
+	}
+	if s.Every < 1 {
+		s.Every = 1
+	}
+	if s.DaysTolerance < 0 {
+		return invalidf("days_tolerance", "negative tolerance")
+	}
+	if s.AmountTolerance < 0 {
+		return invalidf("amount_tolerance", "negative tolerance")
+	}
+	return nil
+}
+
+func (s *ScheduledTransaction) Save() error {
+	if err := s.Validate(); err != nil {
+		return errors.Wrapf(err, "invalid scheduled transaction")
+	}
+	args := []interface{}{
+		s.Name,
+		s.BankAccountID,
+		s.AccountID,
+		s.Amount,
+		nullIfEmpty(s.DetailsContains),
+		s.StartDate,
+		s.EndDate,
+		s.Recurrence,
+		s.Every,
+		s.DaysTolerance,
+		s.AmountTolerance,
+		nullIfEmpty(limitStringLen(s.Notes, 200)),
+	}
+	set := " name=?,bank_account_id=?,account_id=?,amount=?,details_contains=?,start_date=?,end_date=?" +
+		",recurrence=?,every=?,days_tolerance=?,amount_tolerance=?,notes=?"
+	if s.ID == "" {
+		id := uuid.New().String()
+		if _, err := db.Db().Exec("INSERT INTO `scheduled_transactions` SET id=?,"+set, append([]interface{}{id}, args...)...); err != nil {
+			return errors.Wrapf(err, "failed to insert scheduled transaction")
+		}
+		s.ID = id
+		log.Infof("Inserted scheduled transaction(%s)", s.ID)
+	} else {
+		if _, err := db.Db().Exec("UPDATE `scheduled_transactions` SET"+set+" WHERE id=?", append(args, s.ID)...); err != nil {
+			return errors.Wrapf(err, "failed to update scheduled transaction")
+		}
+		log.Infof("Updated scheduled transaction(%s)", s.ID)
+	}
+	return nil
+} //ScheduledTransaction.Save()
+
+func DeleteScheduledTransaction(id string) error {
+	result, err := db.Db().Exec("DELETE FROM `scheduled_transactions` WHERE id=?", id)
+	if err != nil {
+		return errors.Wrapf(err, "failed to delete scheduled transaction")
+	}
+	if nr, _ := result.RowsAffected(); nr != 1 {
+		return notFoundf("scheduled transaction(%s) not found", id)
+	}
+	return nil
+}
+
+//addMonths keeps the day of the month, or the last day in shorter months
+func addMonths(t time.Time, n int) time.Time {
+	first := time.Date(t.Year(), t.Month()+time.Month(n), 1, t.Hour(), t.Minute(), t.Second(), 0, t.Location())
+	lastDay := first.AddDate(0, 1, -1).Day()
+	day := t.Day()
+	if day > lastDay {
+		day = lastDay
+	}
+	return first.AddDate(0, 0, day-1)
+}
+
+//Due lists the due dates from..to (inclusive)
+func (s ScheduledTransaction) Due(from, to time.Time) []time.Time {
+	start := time.Time(s.StartDate).Local()
+	every := s.Every
+	if every < 1 {
+		every = 1
+	}
+	list := []time.Time{}
+	for i := 0; ; i++ {
+		var due time.Time
+		switch s.Recurrence {
+		case RecurrenceWeekly:
+			due = start.AddDate(0, 0, 7*every*i)
+		case RecurrenceMonthly:
+			due = addMonths(start, every*i)
+		case RecurrenceQuarterly:
+			due = addMonths(start, 3*every*i)
+		case RecurrenceAnnually:
+			due = addMonths(start, 12*every*i)
+		default:
+			if i > 0 {
+				return list
+			}
+			due = start
+		}
+		if due.After(to) || (s.EndDate != nil && due.After(time.Time(*s.EndDate))) {
+			return list
+		}
+		if !due.Before(from) {
+			list = append(list, due)
+		}
+	}
+} //ScheduledTransaction.Due()
+
+//ScheduledInstance is one due date of a scheduled transaction,
+//fulfilled when matched to a bank transaction
+type ScheduledInstance struct {
+	Scheduled     ScheduledTransaction `json:"scheduled"`
+	DueDate       time.Time            `json:"due_date"`
+	TransactionID string               `json:"transaction_id,omitempty"` //"" when not yet matched
+}
+
+//Matches is true when the bank transaction is within the date and amount
+//tolerance of the instance and the details contain the expected text
+func (i ScheduledInstance) Matches(bankAccountID string, tx Transaction) bool {
+	s := i.Scheduled
+	if i.TransactionID != "" || s.BankAccountID != bankAccountID {
+		return false
+	}
+	if (s.Amount.MilliCents() < 0) != (tx.Amount.MilliCents() < 0) {
+		return false
+	}
+	if !withinTolerance(s.Amount, tx.Amount, float64(s.AmountTolerance)/100) {
+		return false
+	}
+	if daysBetween(i.DueDate, tx.Date) > s.DaysTolerance {
+		return false
+	}
+	if s.DetailsContains != "" && !strings.Contains(strings.ToUpper(tx.Details), strings.ToUpper(s.DetailsContains)) {
+		return false
+	}
+	return true
+}
+
+//MatchScheduled returns the index of the unmatched instance closest in date
+//that matches the transaction, or -1 if none matched
+func MatchScheduled(instances []ScheduledInstance, bankAccountID string, tx Transaction) int {
+	best := -1
+	for i, inst := range instances {
+		if !inst.Matches(bankAccountID, tx) {
+			continue
+		}
+		if best < 0 || daysBetween(inst.DueDate, tx.Date) < daysBetween(instances[best].DueDate, tx.Date) {
+			best = i
+		}
+	}
+	return best
+}
+
+//GetScheduledInstances lists the instances due from..to by date
+func GetScheduledInstances(from, to time.Time) ([]ScheduledInstance, error) {
+	list, err := GetScheduledTransactions()
+	if err != nil {
+		return nil, err
+	}
+	var matches []struct {
+		ScheduledID   string     `db:"scheduled_id"`
+		DueDate       db.SqlTime `db:"due_date"`
+		TransactionID string     `db:"transaction_id"`
+	}
+	if err := db.Db().Select(&matches, "SELECT scheduled_id,due_date,transaction_id FROM `scheduled_matches` WHERE due_date>=? AND due_date<=?",
+		db.SqlTime(from), db.SqlTime(to),
+	); err != nil {
+		return nil, errors.Wrapf(err, "failed to get scheduled matches")
+	}
+	matched := map[string]string{}
+	for _, m := range matches {
+		matched[m.ScheduledID+"|"+time.Time(m.DueDate).Local().Format("2006-01-02")] = m.TransactionID
+	}
+	instances := []ScheduledInstance{}
+	for _, s := range list {
+		for _, due := range s.Due(from, to) {
+			instances = append(instances, ScheduledInstance{
+				Scheduled:     s,
+				DueDate:       due,
+				TransactionID: matched[s.ID+"|"+due.Format("2006-01-02")],
+			})
+		}
+	}
+	sort.SliceStable(instances, func(i, j int) bool { return instances[i].DueDate.Before(instances[j].DueDate) })
+	return instances, nil
+} //GetScheduledInstances()
+
+//GetUnmatchedScheduled lists the instances due up to asOf (beyond their
+//days tolerance) that were never matched to a bank transaction, of one
+//bank account unless bankAccountID is ""
+func GetUnmatchedScheduled(bankAccountID string, asOf time.Time) ([]ScheduledInstance, error) {
+	instances, err := GetScheduledInstances(time.Time{}, asOf)
+	if err != nil {
+		return nil, err
+	}
+	list := []ScheduledInstance{}
+	for _, i := range instances {
+		if i.TransactionID == "" &&
+			(bankAccountID == "" || i.Scheduled.BankAccountID == bankAccountID) &&
+			i.DueDate.AddDate(0, 0, i.Scheduled.DaysTolerance).Before(asOf) {
+			list = append(list, i)
+		}
+	}
+	return list, nil
+}
+
+//getUnmatchedInstances are those of one bank account due from..to
+func getUnmatchedInstances(bankAccountID string, from, to time.Time) ([]ScheduledInstance, error) {
+	instances, err := GetScheduledInstances(from, to)
+	if err != nil {
+		return nil, err
+	}
+	list := []ScheduledInstance{}
+	for _, i := range instances {
+		if i.TransactionID == "" && i.Scheduled.BankAccountID == bankAccountID {
+			list = append(list, i)
+		}
+	}
+	return list, nil
+}
+
+func insertScheduledMatch(exec sqlx.Execer, i ScheduledInstance, transactionID string) error {
+	if _, err := exec.Exec("INSERT INTO `scheduled_matches` SET id=?,scheduled_id=?,due_date=?,transaction_id=?",
+		uuid.New().String(),
+		i.Scheduled.ID,
+		db.SqlTime(i.DueDate),
+		transactionID,
+	); err != nil {
+		return errors.Wrapf(err, "failed to insert scheduled match")
+	}
+	return nil
+}
@@ -0,0 +1,89 @@
+package bank_test
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jansemmelink/money/bank"
+	"github.com/jansemmelink/money/db"
+)
+
+func TestScheduledDue(t *testing.T) {
+	date := func(s string) time.Time {
+		d, err := time.ParseInLocation("2006-01-02", s, time.Local)
+		assert(t, err)
+		return d
+	}
+	end := db.SqlTime(date("2021-04-01"))
+	for _, c := range []struct {
+		recurrence bank.Recurrence
+		every      int
+		start      string
+		end        *db.SqlTime
+		due        string
+	}{
+		{bank.RecurrenceOnce, 1, "2021-02-15", nil, "2021-02-15"},
+		{bank.RecurrenceWeekly, 2, "2021-01-25", &end, "2021-02-08,2021-02-22,2021-03-08,2021-03-22"},
+		//day 31 is the last day in shorter months
+		{bank.RecurrenceMonthly, 1, "2020-12-31", nil, "2021-02-28,2021-03-31,2021-04-30"},
+		{bank.RecurrenceQuarterly, 1, "2020-11-30", &end, "2021-02-28"},
+		{bank.RecurrenceAnnually, 1, "2020-02-29", nil, "2021-02-28"},
+		{bank.RecurrenceWeekly, 1, "2021-03-20", &end, "2021-03-20,2021-03-27"},
+	} {
+		s := bank.ScheduledTransaction{
+			Recurrence: c.recurrence,
+			Every:      c.every,
+			StartDate:  db.SqlTime(date(c.start)),
+			EndDate:    c.end,
+		}
+		list := []string{}
+		for _, due := range s.Due(date("2021-02-01"), date("2021-04-30")) {
+			list = append(list, due.Format("2006-01-02"))
+		}
+		if strings.Join(list, ",") != c.due {
+			t.Errorf("%s every %d from %s: due %v != %s", c.recurrence, c.every, c.start, list, c.due)
+		}
+	}
+}
+
+func TestMatchScheduled(t *testing.T) {
+	date := func(s string) time.Time {
+		d, err := time.ParseInLocation("2006-01-02", s, time.Local)
+		assert(t, err)
+		return d
+	}
+	rent := bank.ScheduledTransaction{
+		ID:              "rent",
+		BankAccountID:   "cheque",
+		Amount:          amount(t, "-8000"),
+		DetailsContains: "landlord",
+		DaysTolerance:   5,
+		AmountTolerance: 10,
+	}
+	instances := []bank.ScheduledInstance{
+		{Scheduled: rent, DueDate: date("2021-01-01"), TransactionID: "paid"},
+		{Scheduled: rent, DueDate: date("2021-02-01")},
+		{Scheduled: rent, DueDate: date("2021-03-01")},
+	}
+	for _, c := range []struct {
+		bankAccountID string
+		tx            bank.Transaction
+		index         int
+	}{
+		{"cheque", bank.Transaction{Date: date("2021-02-03"), Amount: amount(t, "-8000"), Details: "LANDLORD FEB"}, 1},
+		{"cheque", bank.Transaction{Date: date("2021-02-25"), Amount: amount(t, "-8500"), Details: "Landlord Mar"}, 2},
+		//already matched
+		{"cheque", bank.Transaction{Date: date("2021-01-01"), Amount: amount(t, "-8000"), Details: "LANDLORD JAN"}, -1},
+		//too late, too much, wrong details, sign or bank account
+		{"cheque", bank.Transaction{Date: date("2021-02-10"), Amount: amount(t, "-8000"), Details: "LANDLORD"}, -1},
+		{"cheque", bank.Transaction{Date: date("2021-02-01"), Amount: amount(t, "-9000"), Details: "LANDLORD"}, -1},
+		{"cheque", bank.Transaction{Date: date("2021-02-01"), Amount: amount(t, "-8000"), Details: "SCHOOL"}, -1},
+		{"cheque", bank.Transaction{Date: date("2021-02-01"), Amount: amount(t, "8000"), Details: "LANDLORD"}, -1},
+		{"savings", bank.Transaction{Date: date("2021-02-01"), Amount: amount(t, "-8000"), Details: "LANDLORD"}, -1},
+	} {
+		if i := bank.MatchScheduled(instances, c.bankAccountID, c.tx); i != c.index {
+			t.Errorf("%s %s %s %s matched %d != %d", c.bankAccountID, c.tx.Date.Format("2006-01-02"), c.tx.Amount, c.tx.Details, i, c.index)
+		}
+	}
+}
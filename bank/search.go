@@ -0,0 +1,88 @@
+package bank
+
+import (
+	"strings"
+	"unicode"
+
+	"github.com/go-msvc/errors"
+	"github.com/jansemmelink/money/db"
+)
+
+//SearchTerm is one word, prefix (word*) or "quoted phrase" in a search
+type SearchTerm struct {
+	Text   string
+	Prefix bool
+	Phrase bool
+}
+
+//ParseSearch splits the query into terms that must all match
+func ParseSearch(q string) ([]SearchTerm, error) {
+	terms := []SearchTerm{}
+	for q = strings.TrimSpace(q); q != ""; q = strings.TrimSpace(q) {
+		if q[0] == '"' {
+			end := strings.Index(q[1:], "\"")
+			if end < 0 {
+				return nil, errors.Errorf("missing closing quote in %s", q)
+			}
+			if text := strings.Join(strings.Fields(q[1:end+1]), " "); text != "" {
+				terms = append(terms, SearchTerm{Text: text, Phrase: true})
+			}
+			q = q[end+2:]
+			continue
+		}
+		word := q
+		if i := strings.IndexFunc(q, unicode.IsSpace); i >= 0 {
+			word = q[:i]
+		}
+		q = q[len(word):]
+		t := SearchTerm{Text: strings.Trim(word, "\"*"), Prefix: strings.HasSuffix(word, "*")}
+		if t.Text != "" {
+			terms = append(terms, t)
+		}
+	}
+	return terms, nil
+} //ParseSearch()
+
+//Against is the boolean mode full-text expression that requires the term.
+//Words and phrases are quoted so that words split by punctuation match as
+//a phrase. A quoted phrase cannot end in *, so each word of a prefix is
+//required with only the last one as prefix.
+func (t SearchTerm) Against() string {
+	if t.Prefix {
+		words := strings.FieldsFunc(t.Text, func(r rune) bool {
+			return !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '_'
+		})
+		if len(words) == 0 {
+			return ""
+		}
+		return "+" + strings.Join(words, " +") + "*"
+	}
+	return "+\"" + strings.Replace(t.Text, "\"", "", -1) + "\""
+}
+
+//TagLike is the LIKE pattern for tag names matching the term
+func (t SearchTerm) TagLike() string {
+	pattern := db.LikeEscape(strings.ToLower(t.Text))
+	if t.Prefix {
+		pattern += "%"
+	}
+	return pattern
+}
+
+//searchFilter is the SQL condition and args for transactions t matching
+//all the terms in the statement details, notes or tags
+func searchFilter(terms []SearchTerm) (string, []interface{}) {
+	filters := []string{}
+	args := []interface{}{}
+	for _, t := range terms {
+		tagFilter := "t.id IN (SELECT tt.transaction_id FROM `transaction_tags` AS tt JOIN `tags` AS tg ON tg.id=tt.tag_id WHERE tg.name LIKE ?)"
+		if against := t.Against(); against != "" {
+			filters = append(filters, "(MATCH(t.statement_details,t.notes) AGAINST(? IN BOOLEAN MODE) OR "+tagFilter+")")
+			args = append(args, against, t.TagLike())
+		} else {
+			filters = append(filters, tagFilter)
+			args = append(args, t.TagLike())
+		}
+	}
+	return strings.Join(filters, " AND "), args
+}
@@ -0,0 +1,66 @@
+package bank_test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/jansemmelink/money/bank"
+)
+
+func TestParseSearch(t *testing.T) {
+	for q, expected := range map[string]string{
+		`sasol`:                       `[{sasol false false}]`,
+		`  sas* "school  fees" 2021 `: `[{sas true false} {school fees false true} {2021 false false}]`,
+		`holiday-2021 ""`:             `[{holiday-2021 false false}]`,
+		`"unterminated`:               `error`,
+		`C*SASOL`:                     `[{C*SASOL false false}]`,
+		`*`:                           `[]`,
+	} {
+		terms, err := bank.ParseSearch(q)
+		result := fmt.Sprintf("%v", terms)
+		if err != nil {
+			result = "error"
+		}
+		if result != expected {
+			t.Errorf("ParseSearch(%s)=%s != %s", q, result, expected)
+		}
+	}
+}
+
+func TestSearchTerm(t *testing.T) {
+	for _, c := range []struct {
+		term    bank.SearchTerm
+		against string
+		tagLike string
+	}{
+		{bank.SearchTerm{Text: "sasol"}, `+"sasol"`, `sasol`},
+		{bank.SearchTerm{Text: "Tax-Ded", Prefix: true}, `+Tax +Ded*`, `tax-ded%`},
+		{bank.SearchTerm{Text: "school fees", Phrase: true}, `+"school fees"`, `school fees`},
+		{bank.SearchTerm{Text: "100%_off"}, `+"100%_off"`, `100\%\_off`},
+	} {
+		if a := c.term.Against(); a != c.against {
+			t.Errorf("%+v against %s != %s", c.term, a, c.against)
+		}
+		if l := c.term.TagLike(); l != c.tagLike {
+			t.Errorf("%+v tag like %s != %s", c.term, l, c.tagLike)
+		}
+	}
+}
+
+func TestParseTag(t *testing.T) {
+	for s, expected := range map[string]string{
+		"holiday-2021":    "holiday-2021",
+		" Tax-Deductible": "tax-deductible",
+		"car:service":     "car:service",
+		"two words":       "error",
+		"":                "error",
+	} {
+		name, err := bank.ParseTag(s)
+		if err != nil {
+			name = "error"
+		}
+		if name != expected {
+			t.Errorf("ParseTag(%s)=%s != %s", s, name, expected)
+		}
+	}
+}
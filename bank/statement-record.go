@@ -0,0 +1,99 @@
+package bank
+
+import (
+	"database/sql"
+
+	"github.com/go-msvc/errors"
+	"github.com/jansemmelink/money/db"
+)
+
+//StatementRecord is an imported statement as stored in the db
+type StatementRecord struct {
+	ID             string     `db:"id" json:"id"`
+	BankAccountID  string     `db:"bank_account_id" json:"bank_account_id"`
+	BankName       string     `db:"bank_name" json:"bank_name"`
+	AccountNumber  string     `db:"account_number" json:"account_number"`
+	OpeningDate    db.SqlTime `db:"opening_date" json:"opening_date"`
+	OpeningBalance Amount     `db:"opening_balance" json:"opening_balance"`
+	ClosingDate    db.SqlTime `db:"closing_date" json:"closing_date"`
+	ClosingBalance Amount     `db:"closing_balance" json:"closing_balance"`
+	NrTransactions int        `db:"nr_transactions" json:"nr_transactions"`
+}
+
+const statementRecordSelect = "SELECT s.id,s.bank_account_id,ba.bank_name,ba.account_number" +
+	",s.opening_date,s.opening_balance,s.closing_date,s.closing_balance" +
+	",(SELECT COUNT(*) FROM `transactions` AS t WHERE t.statement_id=s.id) AS nr_transactions" +
+	" FROM `statements` AS s" +
+	" JOIN `bank_accounts` AS ba ON ba.id=s.bank_account_id"
+
+//GetStatements lists statements of one bank account, or all when bankAccountID is ""
+func GetStatements(bankAccountID string) ([]StatementRecord, error) {
+	sql := statementRecordSelect
+	args := []interface{}{}
+	if bankAccountID != "" {
+		sql += " WHERE s.bank_account_id=?"
+		args = append(args, bankAccountID)
+	}
+	sql += " ORDER BY ba.bank_name,ba.account_number,s.opening_date"
+	var list []StatementRecord
+	if err := db.Db().Select(&list, sql, args...); err != nil {
+		return nil, errors.Wrapf(err, "failed to get list of statements")
+	}
+	return list, nil
+}
+
+//GetStatement returns nil,nil when not found
+func GetStatement(id string) (*StatementRecord, error) {
+	var s StatementRecord
+	if err := db.Db().Get(&s, statementRecordSelect+" WHERE s.id=?", id); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, errors.Wrapf(err, "failed to select statement")
+	}
+	return &s, nil
+}
+
+//DeleteStatement deletes the statement with all its transactions
+//so that the dates it covered can be imported again.
+//Transfers with transactions of the statement are undone first and
+//documents only linked to its transactions are deleted after.
+func DeleteStatement(id string) (nrTransactions int64, err error) {
+	tx, err := db.Db().Beginx()
+	if err != nil {
+		return 0, errors.Wrapf(err, "failed to begin db transaction")
+	}
+	defer func() {
+		if err != nil {
+			tx.Rollback()
+		}
+	}()
+
+	if err = undoTransfers(tx, id); err != nil {
+		return 0, err
+	}
+	result, err := tx.Exec("DELETE FROM `transactions` WHERE statement_id=?", id)
+	if err != nil {
+		return 0, errors.Wrapf(err, "failed to delete statement transactions")
+	}
+	nrTransactions, _ = result.RowsAffected()
+
+	result, err = tx.Exec("DELETE FROM `statements` WHERE id=?", id)
+	if err != nil {
+		return 0, errors.Wrapf(err, "failed to delete statement")
+	}
+	if nr, _ := result.RowsAffected(); nr != 1 {
+		err = notFoundf("statement(%s) not found", id)
+		return 0, err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return 0, errors.Wrapf(err, "failed to commit")
+	}
+	log.Infof("Deleted statement(%s) with %d transactions", id, nrTransactions)
+	//documents of the deleted transactions are removed unless also linked elsewhere
+	if _, err := PruneDocuments(); err != nil {
+		log.Errorf("failed to prune documents: %+v", err)
+	}
+	return nrTransactions, nil
+} //DeleteStatement()
@@ -0,0 +1,69 @@
+package bank
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/go-msvc/errors"
+	"github.com/google/uuid"
+	"github.com/jansemmelink/money/db"
+)
+
+//uploads not confirmed within this time are deleted
+const statementUploadExpiry = 24 * time.Hour
+
+//StatementUpload is a statement file kept until its import is confirmed
+type StatementUpload struct {
+	ID       string     `db:"id" json:"id"`
+	Name     string     `db:"name" json:"name"`
+	Format   string     `db:"format" json:"format"`
+	Content  []byte     `db:"content" json:"-"`
+	Uploaded db.SqlTime `db:"uploaded" json:"uploaded"`
+}
+
+//SaveStatementUpload stores a new upload and deletes expired ones
+func SaveStatementUpload(name string, format string, content []byte) (*StatementUpload, error) {
+	if _, err := db.Db().Exec("DELETE FROM `statement_uploads` WHERE uploaded<?", db.SqlTime(time.Now().Add(-statementUploadExpiry))); err != nil {
+		return nil, errors.Wrapf(err, "failed to delete expired statement uploads")
+	}
+	u := StatementUpload{
+		ID:       uuid.New().String(),
+		Name:     limitStringLen(name, 200),
+		Format:   format,
+		Content:  content,
+		Uploaded: db.SqlTime(time.Now()),
+	}
+	if _, err := db.Db().Exec("INSERT INTO `statement_uploads` SET id=?,name=?,format=?,content=?,uploaded=?",
+		u.ID,
+		u.Name,
+		u.Format,
+		u.Content,
+		u.Uploaded,
+	); err != nil {
+		return nil, errors.Wrapf(err, "failed to insert statement upload")
+	}
+	log.Infof("Uploaded statement(%s) %s", u.ID, u.Name)
+	return &u, nil
+}
+
+//GetStatementUpload returns nil,nil when not found or expired
+func GetStatementUpload(id string) (*StatementUpload, error) {
+	var u StatementUpload
+	if err := db.Db().Get(&u, "SELECT id,name,format,content,uploaded FROM `statement_uploads` WHERE id=? AND uploaded>=?",
+		id,
+		db.SqlTime(time.Now().Add(-statementUploadExpiry)),
+	); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, errors.Wrapf(err, "failed to select statement upload")
+	}
+	return &u, nil
+}
+
+func DeleteStatementUpload(id string) error {
+	if _, err := db.Db().Exec("DELETE FROM `statement_uploads` WHERE id=?", id); err != nil {
+		return errors.Wrapf(err, "failed to delete statement upload")
+	}
+	return nil
+}
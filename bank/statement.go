@@ -16,9 +16,6 @@ var log = logger.New("money").New("statement")
 const (
 	unknownExpenseAccountName = "Unknown expense"
 	unknownIncomeAccountName  = "Unknown income"
-
-	accountTypeExpense = "expense"
-	accountTypeIncome  = "income"
 )
 
 type IStatement interface {
@@ -40,7 +37,8 @@ type IStatement interface {
 
 	Validate() error
 
-	ImportToDb() (string, error) //return statements.id from db
+	Preview() (*ImportPreview, error) //what ImportToDb will do
+	ImportToDb() (string, error)      //return statements.id from db
 }
 
 func NewStatement(bankName string) IStatement {
@@ -140,8 +138,186 @@ func (s statement) CloseDate() time.Time {
 func (s statement) CloseBalance() Amount        { return s.closingBalance }
 func (s statement) Transactions() []Transaction { return s.transactions }
 
+//ImportTransaction is how a statement transaction will be imported
+type ImportTransaction struct {
+	Date        string `json:"date"`
+	Amount      Amount `json:"amount"`
+	Type        string `json:"type,omitempty"`
+	Code        string `json:"code,omitempty"`
+	Details     string `json:"details,omitempty"`
+	AccountID   string `json:"account_id,omitempty"` //counter account, "" if it will be created
+	AccountName string `json:"account_name,omitempty"`
+	Notes       string `json:"notes,omitempty"`
+	Rule        string `json:"rule,omitempty"`       //name of the rule that set the account
+	Scheduled   string `json:"scheduled,omitempty"`  //name of the scheduled transaction it fulfils
+	SkippedBy   string `json:"skipped_by,omitempty"` //id of the statement that already covers the date
+
+	tx        Transaction
+	scheduled *ScheduledInstance
+}
+
+//ImportPreview describes what ImportToDb will do, without changing the db
+type ImportPreview struct {
+	BankName      string              `json:"bank_name"`
+	BranchName    string              `json:"branch_name"`
+	BranchCode    string              `json:"branch_code"`
+	AccountNumber string              `json:"account_number"`
+	BankAccountID string              `json:"bank_account_id,omitempty"` //"" for a new bank account
+	OpenDate      string              `json:"open_date"`
+	OpenBalance   Amount              `json:"open_balance"`
+	CloseDate     string              `json:"close_date"`
+	CloseBalance  Amount              `json:"close_balance"`
+	NrImported    int                 `json:"nr_imported"`
+	NrSkipped     int                 `json:"nr_skipped"`
+	Transactions  []ImportTransaction `json:"transactions"`
+}
+
+func (s statement) Preview() (*ImportPreview, error) {
+	if len(s.transactions) == 0 {
+		return nil, errors.Errorf("no transactions in statement")
+	}
+	bankAccount, err := GetBankAccount(s.bankName, s.accNumber)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to look for bank account")
+	}
+	list, err := s.plan(bankAccount)
+	if err != nil {
+		return nil, err
+	}
+	p := &ImportPreview{
+		BankName:      s.bankName,
+		BranchName:    s.branchName,
+		BranchCode:    s.branchCode,
+		AccountNumber: s.accNumber,
+		OpenDate:      s.OpenDate().Format("2006-01-02"),
+		OpenBalance:   s.openingBalance,
+		CloseDate:     s.CloseDate().Format("2006-01-02"),
+		CloseBalance:  s.closingBalance,
+		Transactions:  list,
+	}
+	if bankAccount != nil {
+		p.BankAccountID = bankAccount.ID
+	}
+	for _, t := range list {
+		if t.SkippedBy != "" {
+			p.NrSkipped++
+		} else {
+			p.NrImported++
+		}
+	}
+	return p, nil
+} //statement.Preview()
+
+//plan assigns the counter account of each transaction and skips dates
+//already imported from other statements of the bank account, which is
+//nil when it does not yet exist
+func (s statement) plan(bankAccount *BankAccount) ([]ImportTransaction, error) {
+	accByID, err := getAllAccounts()
+	if err != nil {
+		return nil, err
+	}
+	//default unknown income/expense accounts, created when importing
+	unknownAccountID := map[string]string{}
+	for _, acc := range accByID {
+		if acc.Name == unknownExpenseAccountName || acc.Name == unknownIncomeAccountName {
+			unknownAccountID[acc.Name] = acc.ID
+		}
+	}
+
+	//rules assign more specific accounts than unknown income/expense
+	rules, err := GetRules()
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to get rules")
+	}
+
+	bankAccountID := ""
+	scheduled := []ScheduledInstance{}
+	var ostList []overlappingStatement
+	if bankAccount != nil {
+		bankAccountID = bankAccount.ID
+
+		//scheduled transactions expected in this period are fulfilled by
+		//matching bank transactions, with a month margin for late payments
+		scheduled, err = getUnmatchedInstances(bankAccount.ID, s.OpenDate().AddDate(0, -1, 0), s.CloseDate().AddDate(0, 1, 0))
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to get scheduled transactions")
+		}
+
+		//list existing statements overlapping this date range
+		if err := db.Db().Select(&ostList, "SELECT `id`,`opening_date`,`closing_date` FROM `statements`"+
+			" WHERE `bank_account_id`=?"+
+			" AND `opening_date` >= ?"+
+			" AND `closing_date` <= ?",
+			bankAccount.ID,
+			s.OpenDate(),
+			s.CloseDate(),
+		); err != nil && err != sql.ErrNoRows {
+			return nil, errors.Wrapf(err, "failed to list overlapping statements")
+		}
+		log.Infof("%d overlapping statements:", len(ostList))
+		for _, ost := range ostList {
+			log.Infof("  overlapping stm: %+v", ost)
+		}
+	}
+
+	list := []ImportTransaction{}
+	for _, tx := range s.transactions {
+		t := ImportTransaction{
+			Date:    tx.Date.Format("2006-01-02"),
+			Amount:  tx.Amount,
+			Type:    tx.Type,
+			Code:    tx.Code,
+			Details: tx.Details,
+			tx:      tx,
+		}
+
+		//skip if date is covered by overlapping statement
+		for _, ost := range ostList {
+			if !tx.Date.Before(time.Time(ost.OpeningDate)) && !tx.Date.After(time.Time(ost.ClosingDate)) {
+				t.SkippedBy = ost.ID
+				break
+			}
+		}
+		if t.SkippedBy != "" {
+			list = append(list, t)
+			continue
+		}
+
+		//the other account is not yet known
+		if tx.Amount.MilliCents() > 0 {
+			t.AccountID, t.AccountName = unknownAccountID[unknownIncomeAccountName], unknownIncomeAccountName
+		} else {
+			t.AccountID, t.AccountName = unknownAccountID[unknownExpenseAccountName], unknownExpenseAccountName
+		}
+		if rule := MatchRule(rules, bankAccountID, tx); rule != nil {
+			t.AccountID = rule.AccountID
+			t.Notes = rule.Notes
+			t.Rule = rule.Name
+			log.Debugf("Rule(%s) matched %s %s %s", rule.Name, tx.Date, tx.Amount, tx.Details)
+		}
+		//a scheduled transaction is more specific than a rule
+		if i := MatchScheduled(scheduled, bankAccountID, tx); i >= 0 {
+			instance := scheduled[i]
+			t.scheduled = &instance
+			t.Scheduled = instance.Scheduled.Name
+			t.AccountID = instance.Scheduled.AccountID
+			if instance.Scheduled.Notes != "" {
+				t.Notes = instance.Scheduled.Notes
+			}
+			//each instance is fulfilled by one transaction
+			scheduled = append(scheduled[:i:i], scheduled[i+1:]...)
+			log.Debugf("Scheduled(%s) matched %s %s %s", instance.Scheduled.Name, tx.Date, tx.Amount, tx.Details)
+		}
+		if acc, ok := accByID[t.AccountID]; ok {
+			t.AccountName = acc.Path
+		}
+		list = append(list, t)
+	}
+	return list, nil
+} //statement.plan()
+
 //after loading complete statement, call this to import it into the db
-func (s statement) ImportToDb() (string, error) {
+func (s statement) ImportToDb() (statementID string, err error) {
 	if s.databaseID != "" {
 		return "", errors.Errorf("statement(%s) already in db", s.databaseID)
 	}
@@ -155,12 +331,13 @@ func (s statement) ImportToDb() (string, error) {
 		return "", errors.Wrapf(err, "failed to look for bank account")
 	}
 
-	if bankAccount == nil {
+	newBankAccount := bankAccount == nil
+	if newBankAccount {
 		//not found, create new account and bank account
 		account := Account{
 			//ID:   uuid.New().String(),
-			Name: s.bankName + ":" + s.accNumber,
-			Type: "asset",
+			Name: s.bankName + " " + s.accNumber, //not PathSeparator
+			Type: AccountTypeAsset,
 		}
 		bankAccount = &BankAccount{
 			//ID:            uuid.New().String(),
@@ -181,62 +358,48 @@ func (s statement) ImportToDb() (string, error) {
 
 	//get default unknown income/expence accounts to credit/debit
 	//for all transactions in this statements
-	unknownExpenseAccount, err := getOrCreateAccount(unknownExpenseAccountName, accountTypeExpense)
-	if err != nil {
+	if _, err := getOrCreateAccount(unknownExpenseAccountName, AccountTypeExpense); err != nil {
 		return "", errors.Wrapf(err, "failed to get default account")
 	}
-	unknownIncomeAccount, err := getOrCreateAccount(unknownIncomeAccountName, accountTypeIncome)
-	if err != nil {
+	if _, err := getOrCreateAccount(unknownIncomeAccountName, AccountTypeIncome); err != nil {
 		return "", errors.Wrapf(err, "failed to get default account")
 	}
 
-	//list existing statements overlapping this date range
-	var ostList []overlappingStatement
-	if err := db.Db().Select(&ostList, "SELECT `id`,`opening_date`,`closing_date` FROM `statements`"+
-		" WHERE `bank_account_id`=?"+
-		" AND `opening_date` >= ?"+
-		" AND `closing_date` <= ?",
-		bankAccount.ID,
-		s.OpenDate(),
-		s.CloseDate(),
-	); err != nil && err != sql.ErrNoRows {
-		return "", errors.Wrapf(err, "failed to list overlapping statements")
-	}
-	log.Infof("%d overlapping statements:", len(ostList))
-	for _, ost := range ostList {
-		log.Infof("  overlapping stm: %+v", ost)
-	}
-
-	//add transactions
-	statementID := ""
-	for _, tx := range s.transactions {
+	list, err := s.plan(bankAccount)
+	if err != nil {
+		return "", err
+	}
+
+	//add the statement with all its transactions or nothing
+	tx, err := db.Db().Beginx()
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to begin db transaction")
+	}
+	defer func() {
+		if err != nil {
+			tx.Rollback()
+		}
+	}()
+	for _, t := range list {
+		stmtTx := t.tx
+		if t.SkippedBy != "" {
+			log.Infof("Date %s skipped, included in statement(%s)", stmtTx.Date, t.SkippedBy)
+			continue
+		}
+
 		//debit or credit the bank account
-		//the other account is not yet known
 		var dtAccountID string
 		var ctAccountID string
-		if tx.Amount.MilliCents() > 0 {
+		if stmtTx.Amount.MilliCents() > 0 {
 			//dt the bank account
 			dtAccountID = bankAccount.Account.ID
-			ctAccountID = unknownIncomeAccount.ID
+			ctAccountID = t.AccountID
 		} else {
 			//ct the bank account
-			dtAccountID = unknownExpenseAccount.ID
+			dtAccountID = t.AccountID
 			ctAccountID = bankAccount.Account.ID
 		}
 
-		//skip of date is covered by overlapping statement
-		overlap := false
-		for _, ost := range ostList {
-			if !tx.Date.Before(time.Time(ost.OpeningDate)) && !tx.Date.After(time.Time(ost.ClosingDate)) {
-				log.Infof("Date %s skipped, included in statement(%s)", tx.Date, ost.ID)
-				overlap = true
-				break
-			}
-		}
-		if overlap {
-			continue
-		}
-
 		//create statement before importing the first transaction
 		if statementID == "" {
 			statementID = uuid.New().String()
@@ -252,7 +415,7 @@ func (s statement) ImportToDb() (string, error) {
 				closingDate,
 				s.closingBalance,
 			}
-			if result, err := db.Db().Exec(sql, args...); err != nil {
+			if result, err := tx.Exec(sql, args...); err != nil {
 				return "", errors.Wrapf(err, "failed to insert statement record")
 			} else {
 				nrRows, _ := result.RowsAffected()
@@ -267,20 +430,17 @@ func (s statement) ImportToDb() (string, error) {
 			"  id=?, date=?, amount=?, dt_account_id=?, ct_account_id=?, statement_id=?, statement_type=?, statement_code=?, statement_details=?, notes=?"
 		args := []interface{}{
 			transactionID,
-			tx.Date,
-			tx.Amount,
+			stmtTx.Date,
+			stmtTx.Amount,
 			dtAccountID,
 			ctAccountID,
 			statementID,
-			limitStringLen(tx.Type, 200),
-			limitStringLen(tx.Details, 200),
-			tx.Code,
-			nil,
+			limitStringLen(stmtTx.Type, 200),
+			limitStringLen(stmtTx.Code, 200),
+			limitStringLen(stmtTx.Details, 200),
+			nullIfEmpty(t.Notes),
 		}
-		if result, err := db.Db().Exec(sql, args...); err != nil {
-			// if err == sql.ErrDup {
-
-			// }
+		if result, err := tx.Exec(sql, args...); err != nil {
 			return "", errors.Wrapf(err, "failed to insert statement record")
 		} else {
 			nrRows, _ := result.RowsAffected()
@@ -288,6 +448,25 @@ func (s statement) ImportToDb() (string, error) {
 				return "", errors.Errorf("inserted %d instead of 1 row", nrRows)
 			}
 		}
+		if err := insertPostings(tx, transactionID, twoLegPostings(dtAccountID, ctAccountID, stmtTx.Amount)); err != nil {
+			return "", errors.Wrapf(err, "failed to insert transaction postings")
+		}
+		if t.scheduled != nil {
+			if err := insertScheduledMatch(tx, *t.scheduled, transactionID); err != nil {
+				return "", err
+			}
+		}
+	}
+
+	//the balance before the first statement of a new bank account is
+	//posted against equity so that the account balance matches the bank
+	if newBankAccount && statementID != "" {
+		if _, err = postOpeningBalance(tx, *bankAccount, false); err != nil {
+			return "", errors.Wrapf(err, "failed to post opening balance")
+		}
+	}
+	if err = tx.Commit(); err != nil {
+		return "", errors.Wrapf(err, "failed to commit")
 	}
 	return statementID, nil
 } //statement.ImportToDB()
@@ -299,8 +478,17 @@ func limitStringLen(s string, maxLen int) string {
 	return s
 }
 
-func getOrCreateAccount(name string, accountType string) (*Account, error) {
-	acc, _ := GetAccountByName(name)
+//nil is written as NULL into the db
+func nullIfEmpty(s string) interface{} {
+	if s == "" {
+		return nil
+	}
+	return s
+}
+
+//getOrCreateAccount returns the root account with the name, creating it when not found
+func getOrCreateAccount(name string, accountType AccountType) (*Account, error) {
+	acc, _ := getAccountWhere("name=? AND parent_id IS NULL", name)
 	if acc != nil {
 		log.Infof("Existing %s account %+v", name, acc)
 		return acc, nil
@@ -0,0 +1,139 @@
+package bank
+
+import (
+	"sort"
+	"time"
+
+	"github.com/jansemmelink/money/classify"
+)
+
+//AccountSuggestion is a likely counter account for a transaction
+type AccountSuggestion struct {
+	Account    Account `json:"account"`
+	Confidence float64 `json:"confidence"`
+}
+
+//Suggester suggests counter accounts learned from categorised transactions
+type Suggester struct {
+	classifier *classify.Classifier
+	accByID    map[string]Account
+	trained    []trainedTransaction
+}
+
+type trainedTransaction struct {
+	tx     TransactionRecord
+	tokens map[string]bool
+}
+
+//TrainSuggester learns from all statement transactions whose counter account
+//is no longer unknown expense/income
+func TrainSuggester() (*Suggester, error) {
+	accByID, err := getAllAccounts()
+	if err != nil {
+		return nil, err
+	}
+	txList, err := GetTransactions(TransactionFilter{})
+	if err != nil {
+		return nil, err
+	}
+	s := &Suggester{
+		classifier: classify.New(),
+		accByID:    accByID,
+	}
+	for _, tx := range txList {
+		s.Learn(tx)
+	}
+	log.Infof("Trained suggester from %d transactions", s.classifier.NrDocs())
+	return s, nil
+} //TrainSuggester()
+
+//Learn adds a categorised transaction to the trained examples
+func (s *Suggester) Learn(tx TransactionRecord) {
+	if tx.StatementID == "" || tx.IsSplit() || tx.IsUnknown() {
+		return
+	}
+	if _, ok := s.accByID[tx.CounterAccountID()]; !ok {
+		s.accByID[tx.CounterAccountID()] = Account{ID: tx.CounterAccountID(), Name: tx.CounterAccountName()}
+	}
+	s.classifier.Train(tx.CounterAccountID(), TransactionFeatures(tx.StatementTransaction()))
+	s.trained = append(s.trained, trainedTransaction{tx: tx, tokens: tokenSet(tx.StatementDetails)})
+}
+
+func (s *Suggester) NrTrained() int { return s.classifier.NrDocs() }
+
+//Suggest returns up to n counter accounts for the transaction, most likely first
+func (s *Suggester) Suggest(tx Transaction, n int) []AccountSuggestion {
+	list := []AccountSuggestion{}
+	for _, sug := range s.classifier.Suggest(TransactionFeatures(tx), n) {
+		acc, ok := s.accByID[sug.Label]
+		if !ok {
+			continue //deleted since training
+		}
+		list = append(list, AccountSuggestion{Account: acc, Confidence: sug.Confidence})
+	}
+	return list
+}
+
+//Similar returns up to n categorised transactions with the most
+//statement details words in common with tx, most similar first
+func (s *Suggester) Similar(tx Transaction, n int) []TransactionRecord {
+	tokens := tokenSet(tx.Details)
+	type scored struct {
+		tx    TransactionRecord
+		score float64
+	}
+	list := []scored{}
+	for _, t := range s.trained {
+		common := 0
+		for token := range tokens {
+			if t.tokens[token] {
+				common++
+			}
+		}
+		if common == 0 {
+			continue
+		}
+		//jaccard index
+		score := float64(common) / float64(len(tokens)+len(t.tokens)-common)
+		list = append(list, scored{tx: t.tx, score: score})
+	}
+	sort.SliceStable(list, func(i, j int) bool {
+		if list[i].score != list[j].score {
+			return list[i].score > list[j].score
+		}
+		return time.Time(list[i].tx.Date).After(time.Time(list[j].tx.Date)) //most recent first
+	})
+	similar := []TransactionRecord{}
+	for i := 0; i < len(list) && i < n; i++ {
+		similar = append(similar, list[i].tx)
+	}
+	return similar
+} //Suggester.Similar()
+
+func tokenSet(text string) map[string]bool {
+	set := map[string]bool{}
+	for _, t := range classify.Tokens(text) {
+		set[t] = true
+	}
+	return set
+}
+
+//TransactionFeatures are the tokens of the statement details and type
+//with the sign and order of magnitude of the amount
+func TransactionFeatures(tx Transaction) []string {
+	features := classify.Tokens(tx.Details)
+	for _, t := range classify.Tokens(tx.Type) {
+		features = append(features, "type:"+t)
+	}
+	if tx.Amount.MilliCents() < 0 {
+		features = append(features, "sign:-")
+	} else {
+		features = append(features, "sign:+")
+	}
+	features = append(features, "amount:"+classify.AmountBucket(tx.Amount.MilliCents()/1000))
+	return features
+}
+
+func isUnknownAccountName(name string) bool {
+	return name == unknownExpenseAccountName || name == unknownIncomeAccountName
+}
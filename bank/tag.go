@@ -0,0 +1,133 @@
+package bank
+
+import (
+	"strings"
+
+	"github.com/go-msvc/errors"
+	"github.com/google/uuid"
+	"github.com/jansemmelink/money/db"
+	"github.com/jmoiron/sqlx"
+)
+
+//Tag is a label like "holiday-2021" on any nr of transactions
+type Tag struct {
+	ID             string `db:"id" json:"id"`
+	Name           string `db:"name" json:"name"`
+	NrTransactions int    `db:"nr_transactions" json:"nr_transactions"`
+}
+
+//ParseTag returns the lower case tag name, which may contain letters,
+//digits and any of "-_.:" but no spaces
+func ParseTag(s string) (string, error) {
+	name := strings.ToLower(strings.TrimSpace(s))
+	if name == "" {
+		return "", errors.Errorf("missing tag")
+	}
+	if len(name) > 100 {
+		return "", errors.Errorf("tag \"%s\" longer than 100", s)
+	}
+	for _, r := range name {
+		if !(r >= 'a' && r <= 'z' || r >= '0' && r <= '9' || strings.ContainsRune("-_.:", r)) {
+			return "", errors.Errorf("invalid tag \"%s\" (expects letters, digits and -_.:)", s)
+		}
+	}
+	return name, nil
+}
+
+//GetTags lists all tags by name with the nr of transactions tagged
+func GetTags() ([]Tag, error) {
+	list := []Tag{}
+	if err := db.Db().Select(&list, "SELECT tg.id,tg.name,COUNT(tt.transaction_id) AS nr_transactions FROM `tags` AS tg"+
+		" LEFT JOIN `transaction_tags` AS tt ON tt.tag_id=tg.id"+
+		" GROUP BY tg.id,tg.name ORDER BY tg.name",
+	); err != nil {
+		return nil, errors.Wrapf(err, "failed to get tags")
+	}
+	return list, nil
+}
+
+//TagTransaction adds the tags to the transaction, creating new tags
+func TagTransaction(transactionID string, names ...string) error {
+	t, err := GetTransaction(transactionID)
+	if err != nil {
+		return err
+	}
+	if t == nil {
+		return notFoundf("transaction(%s) not found", transactionID)
+	}
+	for _, n := range names {
+		name, err := ParseTag(n)
+		if err != nil {
+			return err
+		}
+		if _, err := db.Db().Exec("INSERT IGNORE INTO `tags` SET id=?,name=?", uuid.New().String(), name); err != nil {
+			return errors.Wrapf(err, "failed to insert tag")
+		}
+		if _, err := db.Db().Exec("INSERT IGNORE INTO `transaction_tags` (transaction_id,tag_id)"+
+			" SELECT ?,id FROM `tags` WHERE name=?", transactionID, name); err != nil {
+			return errors.Wrapf(err, "failed to tag transaction")
+		}
+	}
+	return nil
+}
+
+//UntagTransaction removes the tag from the transaction, the tag itself remains
+func UntagTransaction(transactionID string, name string) error {
+	result, err := db.Db().Exec("DELETE tt FROM `transaction_tags` AS tt JOIN `tags` AS tg ON tg.id=tt.tag_id"+
+		" WHERE tt.transaction_id=? AND tg.name=?", transactionID, strings.ToLower(name))
+	if err != nil {
+		return errors.Wrapf(err, "failed to untag transaction")
+	}
+	if nr, _ := result.RowsAffected(); nr != 1 {
+		return notFoundf("transaction(%s) not tagged \"%s\"", transactionID, name)
+	}
+	return nil
+}
+
+//DeleteTag removes the tag from all transactions
+func DeleteTag(name string) error {
+	result, err := db.Db().Exec("DELETE FROM `tags` WHERE name=?", strings.ToLower(name))
+	if err != nil {
+		return errors.Wrapf(err, "failed to delete tag")
+	}
+	if nr, _ := result.RowsAffected(); nr != 1 {
+		return notFoundf("tag \"%s\" not found", name)
+	}
+	return nil
+}
+
+//tagFilter is the SQL condition for transactions t with the tag
+const tagFilter = "t.id IN (SELECT tt.transaction_id FROM `transaction_tags` AS tt JOIN `tags` AS tg ON tg.id=tt.tag_id WHERE tg.name=?)"
+
+//setTags loads the tags of the listed transactions, selected in chunks
+//to stay within the limit on the nr of placeholders in a query
+func setTags(list []TransactionRecord) error {
+	const chunkSize = 1000
+	tags := map[string][]string{}
+	for start := 0; start < len(list); start += chunkSize {
+		ids := []string{}
+		for i := start; i < len(list) && i < start+chunkSize; i++ {
+			ids = append(ids, list[i].ID)
+		}
+		sql, args, err := sqlx.In("SELECT tt.transaction_id,tg.name FROM `transaction_tags` AS tt"+
+			" JOIN `tags` AS tg ON tg.id=tt.tag_id"+
+			" WHERE tt.transaction_id IN (?) ORDER BY tg.name", ids)
+		if err != nil {
+			return errors.Wrapf(err, "failed to select tags")
+		}
+		var rows []struct {
+			TransactionID string `db:"transaction_id"`
+			Name          string `db:"name"`
+		}
+		if err := db.Db().Select(&rows, sql, args...); err != nil {
+			return errors.Wrapf(err, "failed to get tags")
+		}
+		for _, row := range rows {
+			tags[row.TransactionID] = append(tags[row.TransactionID], row.Name)
+		}
+	}
+	for i := range list {
+		list[i].Tags = tags[list[i].ID]
+	}
+	return nil
+} //setTags()
@@ -0,0 +1,28 @@
+package bank
+
+import (
+	"github.com/jansemmelink/money/query"
+)
+
+//TransactionFields are the names used in transaction filter expressions,
+//e.g. `amount < -500 and details ~ "sasol" and account = "Groceries"`,
+//for transactions selected as t
+var TransactionFields = query.Fields{
+	"id":           {SQL: "t.id"},
+	"date":         {SQL: "t.date", Type: query.Date},
+	"amount":       {SQL: "CAST(t.amount AS DECIMAL(20,2))", Type: query.Number}, //with the sign as on the bank statement
+	"details":      {SQL: "IFNULL(t.statement_details,'')"},
+	"notes":        {SQL: "IFNULL(t.notes,'')"},
+	"type":         {SQL: "IFNULL(t.statement_type,'')"},
+	"code":         {SQL: "IFNULL(t.statement_code,'')"},
+	"statement":    {SQL: "IFNULL(t.statement_id,'')"},
+	"account":      {SQL: "qa.name", In: "t.id IN (SELECT qp.transaction_id FROM `postings` AS qp JOIN `accounts` AS qa ON qa.id=qp.account_id WHERE %s)"},
+	"account_id":   {SQL: "qp.account_id", In: "t.id IN (SELECT qp.transaction_id FROM `postings` AS qp WHERE %s)"},
+	"tag":          {SQL: "qt.name", In: "t.id IN (SELECT qtt.transaction_id FROM `transaction_tags` AS qtt JOIN `tags` AS qt ON qt.id=qtt.tag_id WHERE %s)"},
+	"bank_account": {SQL: "qb.account_number", In: "t.statement_id IN (SELECT qs.id FROM `statements` AS qs JOIN `bank_accounts` AS qb ON qb.id=qs.bank_account_id WHERE %s)"},
+}
+
+//transactionQuery is the SQL condition and args for the filter expression
+func transactionQuery(expr string) (string, []interface{}, error) {
+	return query.Compile(expr, TransactionFields)
+}
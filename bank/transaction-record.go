@@ -0,0 +1,276 @@
+package bank
+
+import (
+	"database/sql"
+	"encoding/base64"
+	"strings"
+	"time"
+
+	"github.com/go-msvc/errors"
+	"github.com/jansemmelink/money/db"
+)
+
+//TransactionRecord is a transaction stored in the db.
+//Amount keeps the sign from the bank statement while dt/ct indicate
+//the direction, so the value posted to either account is Amount.Abs().
+type TransactionRecord struct {
+	ID               string     `db:"id" json:"id"`
+	Date             db.SqlTime `db:"date" json:"date"`
+	Amount           Amount     `db:"amount" json:"amount"`
+	DtAccountID      string     `db:"dt_account_id" json:"dt_account_id"`
+	DtAccountName    string     `db:"dt_account_name" json:"dt_account_name"`
+	CtAccountID      string     `db:"ct_account_id" json:"ct_account_id"`
+	CtAccountName    string     `db:"ct_account_name" json:"ct_account_name"`
+	StatementID      string     `db:"statement_id" json:"statement_id,omitempty"`
+	BankAccountID    string     `db:"bank_account_id" json:"bank_account_id,omitempty"`
+	StatementType    string     `db:"statement_type" json:"statement_type,omitempty"`
+	StatementCode    string     `db:"statement_code" json:"statement_code,omitempty"`
+	StatementDetails string     `db:"statement_details" json:"statement_details,omitempty"`
+	Notes            string     `db:"notes" json:"notes,omitempty"`
+	TransferID       string     `db:"transfer_id" json:"transfer_id,omitempty"` //set on the incoming transaction of a collapsed transfer, which has no postings
+	Tags             []string   `db:"-" json:"tags,omitempty"`
+}
+
+const transactionRecordSelect = "SELECT t.id,t.date,t.amount" +
+	",IFNULL(t.dt_account_id,'') AS dt_account_id,IFNULL(dt.name,'') AS dt_account_name" +
+	",IFNULL(t.ct_account_id,'') AS ct_account_id,IFNULL(ct.name,'') AS ct_account_name" +
+	",IFNULL(t.statement_id,'') AS statement_id" +
+	",IFNULL(s.bank_account_id,'') AS bank_account_id" +
+	",IFNULL(t.statement_type,'') AS statement_type" +
+	",IFNULL(t.statement_code,'') AS statement_code" +
+	",IFNULL(t.statement_details,'') AS statement_details" +
+	",IFNULL(t.notes,'') AS notes" +
+	",IFNULL(t.transfer_id,'') AS transfer_id" +
+	" FROM `transactions` AS t" +
+	" LEFT JOIN `accounts` AS dt ON dt.id=t.dt_account_id" +
+	" LEFT JOIN `accounts` AS ct ON ct.id=t.ct_account_id" +
+	" LEFT JOIN `statements` AS s ON s.id=t.statement_id"
+
+//CounterAccountID is the account on the other side of the bank account:
+//money received (amount > 0) debits the bank and credits the counter account.
+func (t TransactionRecord) CounterAccountID() string {
+	if t.Amount.MilliCents() > 0 {
+		return t.CtAccountID
+	}
+	return t.DtAccountID
+}
+
+func (t TransactionRecord) CounterAccountName() string {
+	if t.Amount.MilliCents() > 0 {
+		return t.CtAccountName
+	}
+	return t.DtAccountName
+}
+
+//StatementTransaction is the transaction as it appeared on the bank statement
+func (t TransactionRecord) StatementTransaction() Transaction {
+	return NewTransaction(time.Time(t.Date), t.Amount, t.StatementType, t.StatementDetails, t.StatementCode)
+}
+
+//SetCounterAccount replaces the counter account, keeping the bank side as is
+func (t *TransactionRecord) SetCounterAccount(acc Account) {
+	if t.Amount.MilliCents() > 0 {
+		t.CtAccountID = acc.ID
+		t.CtAccountName = acc.Name
+	} else {
+		t.DtAccountID = acc.ID
+		t.DtAccountName = acc.Name
+	}
+}
+
+type TransactionFilter struct {
+	AccountID   string    //dt or ct account
+	StatementID string    //only from this statement, including incoming transactions of collapsed transfers
+	From        time.Time //zero for no lower limit
+	To          time.Time //zero for no upper limit
+	Details     string    //part of statement details or notes
+	Tag         string    //only transactions with this tag
+	Search      string    //words, prefixes (word*) and "phrases" in statement details, notes or tags
+	Query       string    //filter expression on TransactionFields
+	MinAmount   *Amount   //amounts have the sign as on the bank statement
+	MaxAmount   *Amount   //e.g. -500 for payments of 500 or more
+	After       string    //cursor of the last transaction on the previous page
+	Limit       int
+}
+
+//TransactionCursor identifies the position of the transaction in the list
+//ordered by date and id, so the next page starts after it even when
+//transactions before it were changed or deleted
+func TransactionCursor(t TransactionRecord) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(t.Date.String() + "|" + t.ID))
+}
+
+//ParseTransactionCursor returns the date and id in the cursor
+func ParseTransactionCursor(cursor string) (db.SqlTime, string, error) {
+	b, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return db.SqlTime{}, "", errors.Errorf("invalid cursor")
+	}
+	parts := strings.SplitN(string(b), "|", 2)
+	if len(parts) != 2 || parts[1] == "" {
+		return db.SqlTime{}, "", errors.Errorf("invalid cursor")
+	}
+	date, err := time.ParseInLocation("2006-01-02 15:04:05", parts[0], time.UTC)
+	if err != nil {
+		return db.SqlTime{}, "", errors.Errorf("invalid cursor")
+	}
+	return db.SqlTime(date), parts[1], nil
+}
+
+func GetTransactions(filter TransactionFilter) ([]TransactionRecord, error) {
+	sql := transactionRecordSelect
+	args := []interface{}{}
+	filters := []string{}
+	if filter.AccountID != "" {
+		filters = append(filters, "t.id IN (SELECT transaction_id FROM `postings` WHERE account_id=?)")
+		args = append(args, filter.AccountID)
+	}
+	if filter.StatementID != "" {
+		filters = append(filters, "t.statement_id=?")
+		args = append(args, filter.StatementID)
+	} else {
+		//collapsed into a transfer, only listed with their statement
+		filters = append(filters, "t.transfer_id IS NULL")
+	}
+	if !filter.From.IsZero() {
+		filters = append(filters, "t.date>=?")
+		args = append(args, db.SqlTime(filter.From))
+	}
+	if !filter.To.IsZero() {
+		filters = append(filters, "t.date<=?")
+		args = append(args, db.SqlTime(filter.To))
+	}
+	if filter.Details != "" {
+		filters = append(filters, "(t.statement_details like ? OR t.notes like ?)")
+		args = append(args, "%"+db.LikeEscape(filter.Details)+"%", "%"+db.LikeEscape(filter.Details)+"%")
+	}
+	if filter.Tag != "" {
+		filters = append(filters, tagFilter)
+		args = append(args, strings.ToLower(filter.Tag))
+	}
+	if filter.Search != "" {
+		terms, err := ParseSearch(filter.Search)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid search")
+		}
+		if len(terms) > 0 {
+			search, searchArgs := searchFilter(terms)
+			filters = append(filters, search)
+			args = append(args, searchArgs...)
+		}
+	}
+	if filter.MinAmount != nil {
+		filters = append(filters, "CAST(t.amount AS DECIMAL(20,2))>=CAST(? AS DECIMAL(20,2))")
+		args = append(args, *filter.MinAmount)
+	}
+	if filter.MaxAmount != nil {
+		filters = append(filters, "CAST(t.amount AS DECIMAL(20,2))<=CAST(? AS DECIMAL(20,2))")
+		args = append(args, *filter.MaxAmount)
+	}
+	if filter.After != "" {
+		date, id, err := ParseTransactionCursor(filter.After)
+		if err != nil {
+			return nil, err
+		}
+		filters = append(filters, "(t.date>? OR (t.date=? AND t.id>?))")
+		args = append(args, date, date, id)
+	}
+	if filter.Query != "" {
+		q, queryArgs, err := transactionQuery(filter.Query)
+		if err != nil {
+			return nil, err
+		}
+		if q != "" {
+			filters = append(filters, q)
+			args = append(args, queryArgs...)
+		}
+	}
+	if len(filters) > 0 {
+		sql += " WHERE " + strings.Join(filters, " AND ")
+	}
+	sql += " ORDER BY t.date,t.id"
+	if filter.Limit > 0 {
+		sql += " LIMIT ?"
+		args = append(args, filter.Limit)
+	}
+	var list []TransactionRecord
+	if err := db.Db().Select(&list, sql, args...); err != nil {
+		return nil, errors.Wrapf(err, "failed to get list of transactions")
+	}
+	if err := setTags(list); err != nil {
+		return nil, err
+	}
+	return list, nil
+} //GetTransactions()
+
+//GetTransaction returns nil,nil when not found
+func GetTransaction(id string) (*TransactionRecord, error) {
+	var t TransactionRecord
+	if err := db.Db().Get(&t, transactionRecordSelect+" WHERE t.id=?", id); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, errors.Wrapf(err, "failed to select transaction")
+	}
+	list := []TransactionRecord{t}
+	if err := setTags(list); err != nil {
+		return nil, err
+	}
+	return &list[0], nil
+}
+
+//IsSplit is true when the counter side is split over several accounts
+//that are only described by the postings
+func (t TransactionRecord) IsSplit() bool {
+	return t.CounterAccountID() == ""
+}
+
+//Save updates the accounts and notes of an existing transaction.
+//The postings are replaced to debit/credit the two accounts,
+//except for split transactions where only the notes are updated.
+func (t *TransactionRecord) Save() (err error) {
+	if t.ID == "" {
+		return errors.Errorf("missing id")
+	}
+	if t.TransferID != "" {
+		return errors.Errorf("transaction(%s) is collapsed into transfer(%s), undo the transfer first", t.ID, t.TransferID)
+	}
+	if t.IsSplit() {
+		if _, err := db.Db().Exec("UPDATE `transactions` SET notes=? WHERE id=?",
+			nullIfEmpty(limitStringLen(t.Notes, 200)),
+			t.ID,
+		); err != nil {
+			return errors.Wrapf(err, "failed to update transaction")
+		}
+		log.Infof("Updated split transaction(%s) notes", t.ID)
+		return nil
+	}
+	if t.DtAccountID == "" || t.CtAccountID == "" {
+		return errors.Errorf("missing dt/ct account")
+	}
+	tx, err := db.Db().Beginx()
+	if err != nil {
+		return errors.Wrapf(err, "failed to begin db transaction")
+	}
+	defer func() {
+		if err != nil {
+			tx.Rollback()
+		}
+	}()
+	if _, err = tx.Exec("UPDATE `transactions` SET dt_account_id=?,ct_account_id=?,notes=? WHERE id=?",
+		t.DtAccountID,
+		t.CtAccountID,
+		nullIfEmpty(limitStringLen(t.Notes, 200)),
+		t.ID,
+	); err != nil {
+		return errors.Wrapf(err, "failed to update transaction")
+	}
+	if err = replacePostings(tx, t.ID, twoLegPostings(t.DtAccountID, t.CtAccountID, t.Amount)); err != nil {
+		return err
+	}
+	if err = tx.Commit(); err != nil {
+		return errors.Wrapf(err, "failed to commit")
+	}
+	log.Infof("Updated transaction(%s)", t.ID)
+	return nil
+} //TransactionRecord.Save()
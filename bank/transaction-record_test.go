@@ -0,0 +1,25 @@
+package bank_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/jansemmelink/money/bank"
+	"github.com/jansemmelink/money/db"
+)
+
+func TestTransactionCursor(t *testing.T) {
+	date := time.Date(2021, 2, 3, 0, 0, 0, 0, time.UTC)
+	tx := bank.TransactionRecord{ID: "4b8e2c1e-0d6b-4d43-9b1a-0f3e2d4c5b6a", Date: db.SqlTime(date)}
+	cursor := bank.TransactionCursor(tx)
+	d, id, err := bank.ParseTransactionCursor(cursor)
+	assert(t, err)
+	if !time.Time(d).Equal(date) || id != tx.ID {
+		t.Fatalf("cursor %s parsed as %s %s", cursor, d, id)
+	}
+	for _, invalid := range []string{"", "!", "MjAyMS0wMi0wMw", "eHx5"} {
+		if _, _, err := bank.ParseTransactionCursor(invalid); err == nil {
+			t.Errorf("parsed invalid cursor \"%s\"", invalid)
+		}
+	}
+}
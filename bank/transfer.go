@@ -0,0 +1,241 @@
+package bank
+
+import (
+	"database/sql"
+	"sort"
+	"time"
+
+	"github.com/go-msvc/errors"
+	"github.com/google/uuid"
+	"github.com/jansemmelink/money/db"
+	"github.com/jmoiron/sqlx"
+)
+
+//TransferCandidate is a pair of unknown transactions on different bank accounts
+//with opposite amounts that is likely a transfer between own accounts
+type TransferCandidate struct {
+	Out  TransactionRecord `json:"out"` //money leaving a bank account (amount < 0)
+	In   TransactionRecord `json:"in"`  //same money arriving in another bank account
+	Days int               `json:"days"`
+}
+
+//FindTransfers matches unknown transactions into transfer candidates
+//where the dates are at most maxDays apart
+func FindTransfers(maxDays int) ([]TransferCandidate, error) {
+	txList, err := GetUnknownTransactions(0)
+	if err != nil {
+		return nil, err
+	}
+	return MatchTransfers(txList, maxDays), nil
+}
+
+//MatchTransfers pairs outgoing and incoming transactions of the same amount
+//on different bank accounts. Each transaction is used at most once,
+//preferring the pairs closest in date.
+func MatchTransfers(txList []TransactionRecord, maxDays int) []TransferCandidate {
+	all := []TransferCandidate{}
+	for _, out := range txList {
+		if out.Amount.MilliCents() >= 0 || out.BankAccountID == "" {
+			continue
+		}
+		for _, in := range txList {
+			if in.Amount.MilliCents() != -out.Amount.MilliCents() || in.BankAccountID == "" || in.BankAccountID == out.BankAccountID {
+				continue
+			}
+			days := daysBetween(time.Time(out.Date), time.Time(in.Date))
+			if days > maxDays {
+				continue
+			}
+			all = append(all, TransferCandidate{Out: out, In: in, Days: days})
+		}
+	}
+	sort.SliceStable(all, func(i, j int) bool { return all[i].Days < all[j].Days })
+
+	used := map[string]bool{}
+	list := []TransferCandidate{}
+	for _, c := range all {
+		if used[c.Out.ID] || used[c.In.ID] {
+			continue
+		}
+		used[c.Out.ID] = true
+		used[c.In.ID] = true
+		list = append(list, c)
+	}
+	sort.SliceStable(list, func(i, j int) bool {
+		return time.Time(list[i].Out.Date).Before(time.Time(list[j].Out.Date))
+	})
+	return list
+} //MatchTransfers()
+
+func daysBetween(a, b time.Time) int {
+	d := a.Sub(b)
+	if d < 0 {
+		d = -d
+	}
+	return int((d + 12*time.Hour) / (24 * time.Hour))
+}
+
+//Transfer is a collapsed pair of transactions: the outgoing transaction
+//now debits the receiving bank account and the incoming transaction is
+//kept without postings, marked with the transfer, so that it can be undone.
+//The In* fields are those of the incoming transaction.
+type Transfer struct {
+	ID                 string     `db:"id" json:"id"`
+	CreatedAt          db.SqlTime `db:"created_at" json:"created_at"`
+	OutTransactionID   string     `db:"out_transaction_id" json:"out_transaction_id"`
+	OutDtAccountID     string     `db:"out_dt_account_id" json:"out_dt_account_id"`
+	InTransactionID    string     `db:"in_transaction_id" json:"in_transaction_id"`
+	InDate             db.SqlTime `db:"in_date" json:"in_date"`
+	InAmount           Amount     `db:"in_amount" json:"in_amount"`
+	InDtAccountID      string     `db:"in_dt_account_id" json:"in_dt_account_id"`
+	InCtAccountID      string     `db:"in_ct_account_id" json:"in_ct_account_id"`
+	InStatementID      string     `db:"in_statement_id" json:"in_statement_id,omitempty"`
+	InStatementType    string     `db:"in_statement_type" json:"in_statement_type,omitempty"`
+	InStatementCode    string     `db:"in_statement_code" json:"in_statement_code,omitempty"`
+	InStatementDetails string     `db:"in_statement_details" json:"in_statement_details,omitempty"`
+	InNotes            string     `db:"in_notes" json:"in_notes,omitempty"`
+}
+
+const transferSelect = "SELECT tr.id,tr.created_at,tr.out_transaction_id,tr.out_dt_account_id" +
+	",tr.in_transaction_id,t.date AS in_date,t.amount AS in_amount" +
+	",IFNULL(t.dt_account_id,'') AS in_dt_account_id" +
+	",IFNULL(t.ct_account_id,'') AS in_ct_account_id" +
+	",IFNULL(t.statement_id,'') AS in_statement_id" +
+	",IFNULL(t.statement_type,'') AS in_statement_type" +
+	",IFNULL(t.statement_code,'') AS in_statement_code" +
+	",IFNULL(t.statement_details,'') AS in_statement_details" +
+	",IFNULL(t.notes,'') AS in_notes" +
+	" FROM `transfers` AS tr" +
+	" JOIN `transactions` AS t ON t.id=tr.in_transaction_id"
+
+//CollapseTransfer turns the candidate into one transaction from the
+//outgoing to the receiving bank account
+func CollapseTransfer(c TransferCandidate) (transfer *Transfer, err error) {
+	t := Transfer{
+		ID:                 uuid.New().String(),
+		OutTransactionID:   c.Out.ID,
+		OutDtAccountID:     c.Out.DtAccountID,
+		InTransactionID:    c.In.ID,
+		InDate:             c.In.Date,
+		InAmount:           c.In.Amount,
+		InDtAccountID:      c.In.DtAccountID,
+		InCtAccountID:      c.In.CtAccountID,
+		InStatementID:      c.In.StatementID,
+		InStatementType:    c.In.StatementType,
+		InStatementCode:    c.In.StatementCode,
+		InStatementDetails: c.In.StatementDetails,
+		InNotes:            c.In.Notes,
+	}
+	tx, err := db.Db().Beginx()
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to begin db transaction")
+	}
+	defer func() {
+		if err != nil {
+			tx.Rollback()
+		}
+	}()
+	if _, err = tx.Exec("INSERT INTO `transfers` SET id=?,out_transaction_id=?,out_dt_account_id=?,in_transaction_id=?",
+		t.ID,
+		t.OutTransactionID,
+		t.OutDtAccountID,
+		t.InTransactionID,
+	); err != nil {
+		return nil, errors.Wrapf(err, "failed to insert transfer")
+	}
+	//the receiving bank account is debited instead of unknown expense
+	if _, err = tx.Exec("UPDATE `transactions` SET dt_account_id=? WHERE id=?", c.In.DtAccountID, c.Out.ID); err != nil {
+		return nil, errors.Wrapf(err, "failed to update outgoing transaction")
+	}
+	if _, err = tx.Exec("UPDATE `postings` SET account_id=? WHERE transaction_id=? AND account_id=?", c.In.DtAccountID, c.Out.ID, c.Out.DtAccountID); err != nil {
+		return nil, errors.Wrapf(err, "failed to update outgoing transaction postings")
+	}
+	//the incoming transaction stays on its statement without postings
+	if _, err = tx.Exec("UPDATE `transactions` SET transfer_id=? WHERE id=?", t.ID, c.In.ID); err != nil {
+		return nil, errors.Wrapf(err, "failed to mark incoming transaction")
+	}
+	if _, err = tx.Exec("DELETE FROM `postings` WHERE transaction_id=?", c.In.ID); err != nil {
+		return nil, errors.Wrapf(err, "failed to delete incoming transaction postings")
+	}
+	if err = tx.Commit(); err != nil {
+		return nil, errors.Wrapf(err, "failed to commit")
+	}
+	log.Infof("Collapsed transfer(%s): transaction(%s) -> transaction(%s)", t.ID, c.Out.ID, c.In.ID)
+	return &t, nil
+} //CollapseTransfer()
+
+//GetTransfers lists collapsed transfers, most recent first
+func GetTransfers() ([]Transfer, error) {
+	var list []Transfer
+	if err := db.Db().Select(&list, transferSelect+" ORDER BY t.date DESC"); err != nil {
+		return nil, errors.Wrapf(err, "failed to get list of transfers")
+	}
+	return list, nil
+}
+
+//UndoTransfer restores both transactions of a collapsed transfer
+func UndoTransfer(id string) (err error) {
+	var t Transfer
+	if err := db.Db().Get(&t, transferSelect+" WHERE tr.id=?", id); err != nil {
+		if err == sql.ErrNoRows {
+			return notFoundf("transfer(%s) not found", id)
+		}
+		return errors.Wrapf(err, "failed to get transfer(%s)", id)
+	}
+	tx, err := db.Db().Beginx()
+	if err != nil {
+		return errors.Wrapf(err, "failed to begin db transaction")
+	}
+	defer func() {
+		if err != nil {
+			tx.Rollback()
+		}
+	}()
+	if err = undoTransfer(tx, t); err != nil {
+		return err
+	}
+	if err = tx.Commit(); err != nil {
+		return errors.Wrapf(err, "failed to commit")
+	}
+	log.Infof("Undone transfer(%s)", t.ID)
+	return nil
+} //UndoTransfer()
+
+//undoTransfers restores the transfers that the transactions of the statement are part of
+func undoTransfers(tx *sqlx.Tx, statementID string) error {
+	var list []Transfer
+	if err := tx.Select(&list, transferSelect+
+		" WHERE tr.in_transaction_id IN (SELECT id FROM `transactions` WHERE statement_id=?)"+
+		" OR tr.out_transaction_id IN (SELECT id FROM `transactions` WHERE statement_id=?)",
+		statementID,
+		statementID,
+	); err != nil {
+		return errors.Wrapf(err, "failed to get transfers of statement")
+	}
+	for _, t := range list {
+		if err := undoTransfer(tx, t); err != nil {
+			return err
+		}
+		log.Infof("Undone transfer(%s) of statement(%s)", t.ID, statementID)
+	}
+	return nil
+}
+
+func undoTransfer(tx *sqlx.Tx, t Transfer) error {
+	if _, err := tx.Exec("UPDATE `transactions` SET transfer_id=NULL WHERE id=?", t.InTransactionID); err != nil {
+		return errors.Wrapf(err, "failed to restore incoming transaction")
+	}
+	if err := insertPostings(tx, t.InTransactionID, twoLegPostings(t.InDtAccountID, t.InCtAccountID, t.InAmount)); err != nil {
+		return errors.Wrapf(err, "failed to restore incoming transaction postings")
+	}
+	if _, err := tx.Exec("UPDATE `transactions` SET dt_account_id=? WHERE id=?", t.OutDtAccountID, t.OutTransactionID); err != nil {
+		return errors.Wrapf(err, "failed to restore outgoing transaction")
+	}
+	if _, err := tx.Exec("UPDATE `postings` SET account_id=? WHERE transaction_id=? AND account_id=?", t.OutDtAccountID, t.OutTransactionID, t.InDtAccountID); err != nil {
+		return errors.Wrapf(err, "failed to restore outgoing transaction postings")
+	}
+	if _, err := tx.Exec("DELETE FROM `transfers` WHERE id=?", t.ID); err != nil {
+		return errors.Wrapf(err, "failed to delete transfer")
+	}
+	return nil
+} //undoTransfer()
@@ -0,0 +1,30 @@
+package bank_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/jansemmelink/money/bank"
+	"github.com/jansemmelink/money/db"
+)
+
+func TestMatchTransfers(t *testing.T) {
+	day := func(d int) db.SqlTime {
+		return db.SqlTime(time.Date(2021, 3, d, 0, 0, 0, 0, time.UTC))
+	}
+	txList := []bank.TransactionRecord{
+		{ID: "out1", BankAccountID: "cheque", Date: day(18), Amount: amount(t, "-3924.13")},
+		{ID: "in1", BankAccountID: "credit", Date: day(20), Amount: amount(t, "3924.13")},
+		{ID: "in2", BankAccountID: "credit", Date: day(19), Amount: amount(t, "3924.13")},
+		{ID: "same", BankAccountID: "cheque", Date: day(18), Amount: amount(t, "3924.13")},
+		{ID: "out2", BankAccountID: "cheque", Date: day(1), Amount: amount(t, "-100")},
+		{ID: "late", BankAccountID: "credit", Date: day(10), Amount: amount(t, "100")},
+	}
+	list := bank.MatchTransfers(txList, 3)
+	if len(list) != 1 {
+		t.Fatalf("expected 1 transfer, got %+v", list)
+	}
+	if list[0].Out.ID != "out1" || list[0].In.ID != "in2" || list[0].Days != 1 {
+		t.Fatalf("wrong transfer %+v", list[0])
+	}
+}
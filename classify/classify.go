@@ -0,0 +1,132 @@
+//Package classify is a multinomial naive Bayes classifier
+//used to suggest labels for a list of features
+package classify
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+type Classifier struct {
+	classes map[string]*class
+	vocab   map[string]bool
+	nrDocs  int
+}
+
+type class struct {
+	nrDocs      int
+	nrTokens    int
+	tokenCounts map[string]int
+}
+
+type Suggestion struct {
+	Label      string  `json:"label"`
+	Confidence float64 `json:"confidence"` //0..1, sums to 1 over all labels
+}
+
+func New() *Classifier {
+	return &Classifier{
+		classes: map[string]*class{},
+		vocab:   map[string]bool{},
+	}
+}
+
+//Train adds one example of features for the label
+func (c *Classifier) Train(label string, features []string) {
+	cl, ok := c.classes[label]
+	if !ok {
+		cl = &class{tokenCounts: map[string]int{}}
+		c.classes[label] = cl
+	}
+	cl.nrDocs++
+	c.nrDocs++
+	for _, f := range features {
+		cl.tokenCounts[f]++
+		cl.nrTokens++
+		c.vocab[f] = true
+	}
+}
+
+func (c *Classifier) NrDocs() int { return c.nrDocs }
+
+//Suggest returns the n most likely labels for the features, most likely first.
+//It returns nothing before training.
+func (c *Classifier) Suggest(features []string, n int) []Suggestion {
+	if c.nrDocs == 0 {
+		return nil
+	}
+	vocabSize := float64(len(c.vocab))
+	logProbs := map[string]float64{}
+	maxLogProb := math.Inf(-1)
+	for label, cl := range c.classes {
+		lp := math.Log(float64(cl.nrDocs) / float64(c.nrDocs))
+		for _, f := range features {
+			if !c.vocab[f] {
+				continue //unknown in all classes, does not discriminate
+			}
+			//laplace smoothing
+			lp += math.Log((float64(cl.tokenCounts[f]) + 1) / (float64(cl.nrTokens) + vocabSize))
+		}
+		logProbs[label] = lp
+		if lp > maxLogProb {
+			maxLogProb = lp
+		}
+	}
+
+	//normalise to confidence that sums to 1
+	list := []Suggestion{}
+	sum := 0.0
+	for label, lp := range logProbs {
+		p := math.Exp(lp - maxLogProb)
+		sum += p
+		list = append(list, Suggestion{Label: label, Confidence: p})
+	}
+	for i := range list {
+		list[i].Confidence /= sum
+	}
+	sort.Slice(list, func(i, j int) bool {
+		if list[i].Confidence != list[j].Confidence {
+			return list[i].Confidence > list[j].Confidence
+		}
+		return list[i].Label < list[j].Label
+	})
+	if n > 0 && len(list) > n {
+		list = list[:n]
+	}
+	return list
+} //Classifier.Suggest()
+
+//Tokens splits text into upper case words, ignoring words with digits
+//(card numbers, dates, references) and single characters
+func Tokens(text string) []string {
+	words := strings.FieldsFunc(strings.ToUpper(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	tokens := []string{}
+	for _, w := range words {
+		if len(w) < 2 || strings.IndexFunc(w, unicode.IsDigit) >= 0 {
+			continue
+		}
+		tokens = append(tokens, w)
+	}
+	return tokens
+}
+
+//AmountBucket names the order of magnitude of an amount without sign,
+//e.g. "<10", "<100", ... so similar amounts share a feature
+func AmountBucket(amount int64) string {
+	if amount < 0 {
+		amount = -amount
+	}
+	limit := int64(10)
+	for limit <= 1000000 {
+		if amount < limit {
+			return fmt.Sprintf("<%d", limit)
+		}
+		limit *= 10
+	}
+	return fmt.Sprintf(">=%d", limit/10)
+}
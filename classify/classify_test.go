@@ -0,0 +1,37 @@
+package classify_test
+
+import (
+	"testing"
+
+	"github.com/jansemmelink/money/classify"
+)
+
+func TestSuggest(t *testing.T) {
+	c := classify.New()
+	if list := c.Suggest([]string{"SPAR"}, 3); len(list) != 0 {
+		t.Fatalf("suggested before training: %+v", list)
+	}
+	c.Train("groceries", classify.Tokens("Spar Midstrea 5222*7143 23 SEP"))
+	c.Train("groceries", classify.Tokens("Spar Midstrea 5222*7143 24 SEP"))
+	c.Train("diesel", classify.Tokens("C*SASOL MIDRI 5222*7143 24 SEP"))
+	c.Train("diesel", classify.Tokens("SASOL MIDRIDG 5222*7143 15 MAR"))
+	c.Train("insurance", classify.Tokens("OUTSURANCE OT11259326 94752Q"))
+
+	list := c.Suggest(classify.Tokens("SASOL MIDRIDG 5222*7143 15 MAR"), 2)
+	if len(list) != 2 || list[0].Label != "diesel" || list[0].Confidence < 0.5 {
+		t.Fatalf("wrong suggestions: %+v", list)
+	}
+	if list[0].Confidence < list[1].Confidence {
+		t.Fatalf("not sorted: %+v", list)
+	}
+}
+
+func TestTokens(t *testing.T) {
+	tokens := classify.Tokens("C*SASOL MIDRI 5222*7143 24 SEP")
+	if len(tokens) != 3 || tokens[0] != "SASOL" || tokens[1] != "MIDRI" || tokens[2] != "SEP" {
+		t.Fatalf("wrong tokens: %+v", tokens)
+	}
+	if b := classify.AmountBucket(-932); b != "<1000" {
+		t.Fatalf("wrong bucket %s", b)
+	}
+}
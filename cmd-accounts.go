@@ -0,0 +1,323 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/go-msvc/errors"
+	"github.com/jansemmelink/money/bank"
+	"github.com/jansemmelink/money/output"
+)
+
+func cmdAccounts(args []string) error {
+	return runCommand("money accounts", args, []command{
+		{name: "list", args: "[-name n] [-type t] [-under a] [-sort path|name|type]", summary: "List accounts", run: cmdAccountsList},
+		{name: "create", args: "[-parent a] <name|path> [asset|liability|equity|income|expense]", summary: "Create an account", run: cmdAccountsCreate},
+		{name: "move", args: "<account> <parent|->", summary: "Move an account under another account", run: cmdAccountsMove},
+		{name: "rename", args: "<account> <new name>", summary: "Rename an account", run: cmdAccountsRename},
+		{name: "delete", args: "<account>", summary: "Delete an account that is not used", run: cmdAccountsDelete},
+		{name: "merge", args: "[-preview] <from> <to>", summary: "Move all transactions to another account and delete this one", run: cmdAccountsMerge},
+		{name: "balance", args: "[-date d] <account>", summary: "Show the balance of an account at a date", run: cmdAccountsBalance},
+		{name: "ledger", args: "[-from d] [-to d] [-tag t] [-q expr] <account>", summary: "List postings to an account with running balance", run: cmdAccountsLedger},
+	})
+}
+
+func cmdAccountsList(args []string) error {
+	flags := newFlags("money accounts list", "[-name n] [-type t] [-under a] [-sort path|name|type] [-offset n] [-limit n]")
+	name := flags.String("name", "", "Part of account name")
+	accountType := flags.String("type", "", "Part of account type")
+	under := flags.String("under", "", "Only this account and accounts under it")
+	sort := flags.String("sort", "path", "Sort on path, name or type, with - prefix for descending order")
+	offset := flags.Int("offset", 0, "Nr of accounts to skip")
+	limit := flags.Int("limit", 0, "Max nr of accounts to list (default all)")
+	format := flags.output()
+	if err := flags.parse(args, 0); err != nil {
+		return err
+	}
+	if err := connect(); err != nil {
+		return err
+	}
+	filter := bank.AccountFilter{Name: *name, Type: *accountType, Sort: *sort, Offset: *offset, Limit: *limit}
+	if *under != "" {
+		acc, err := findAccount(*under)
+		if err != nil {
+			return err
+		}
+		filter.UnderID = acc.ID
+	}
+	accList, err := bank.GetAccounts(filter)
+	if err != nil {
+		return err
+	}
+	t := newAccountTable()
+	for _, acc := range accList {
+		addAccountRow(t, acc)
+	}
+	return write(t, *format)
+}
+
+func cmdAccountsCreate(args []string) error {
+	flags := newFlags("money accounts create", "[-parent a] <name|path> [asset|liability|equity|income|expense]")
+	parent := flags.String("parent", "", "Parent account, which also determines the type")
+	format := flags.output()
+	if err := flags.parse(args, -1); err != nil {
+		return err
+	}
+	if flags.NArg() < 1 || flags.NArg() > 2 {
+		return usagef("expects name and optional type\n%s", flags.helpText())
+	}
+	if err := connect(); err != nil {
+		return err
+	}
+	acc := bank.Account{Name: flags.Arg(0)}
+	if flags.NArg() > 1 {
+		accountType, err := bank.ParseAccountType(flags.Arg(1))
+		if err != nil {
+			return usagef("%s\n%s", err.Error(), flags.helpText())
+		}
+		acc.Type = accountType
+	}
+	if *parent != "" {
+		p, err := findAccount(*parent)
+		if err != nil {
+			return err
+		}
+		acc.ParentID = p.ID
+		acc.Path = p.Path + bank.PathSeparator + acc.Name
+	}
+	if strings.Contains(acc.Name, bank.PathSeparator) {
+		//path like "Expenses:Car:Diesel" creates the missing accounts along it,
+		//with the type only applied to a new root account
+		path := acc.Name
+		if acc.ParentID != "" {
+			path = acc.Path
+		}
+		if existing, err := bank.GetAccountByPath(path); err != nil {
+			return err
+		} else if existing != nil {
+			return errors.Wrapf(bank.ErrAccountNameExists, "cannot create account(%s)", path)
+		}
+		created, err := bank.CreateAccountPath(path, acc.Type)
+		if err != nil {
+			return errors.Wrapf(err, "failed to create account")
+		}
+		return write(addAccountRow(newAccountTable(), *created), *format)
+	}
+	if err := acc.Save(); err != nil {
+		return errors.Wrapf(err, "failed to create account")
+	}
+	return write(addAccountRow(newAccountTable(), acc), *format)
+}
+
+func cmdAccountsMove(args []string) error {
+	flags := newFlags("money accounts move", "<account> <parent|->")
+	format := flags.output()
+	if err := flags.parse(args, 2); err != nil {
+		return err
+	}
+	if err := connect(); err != nil {
+		return err
+	}
+	acc, err := findAccount(flags.Arg(0))
+	if err != nil {
+		return err
+	}
+	acc.ParentID = ""
+	if flags.Arg(1) != "-" {
+		parent, err := findAccount(flags.Arg(1))
+		if err != nil {
+			return err
+		}
+		acc.ParentID = parent.ID
+		acc.Type = "" //inherit from the parent
+	}
+	if err := acc.Save(); err != nil {
+		return errors.Wrapf(err, "failed to move account")
+	}
+	return write(addAccountRow(newAccountTable(), *acc), *format)
+}
+
+func cmdAccountsRename(args []string) error {
+	flags := newFlags("money accounts rename", "<account> <new name>")
+	format := flags.output()
+	if err := flags.parse(args, 2); err != nil {
+		return err
+	}
+	if err := connect(); err != nil {
+		return err
+	}
+	acc, err := findAccount(flags.Arg(0))
+	if err != nil {
+		return err
+	}
+	acc.Name = flags.Arg(1)
+	if err := acc.Save(); err != nil {
+		return errors.Wrapf(err, "failed to rename account")
+	}
+	return write(addAccountRow(newAccountTable(), *acc), *format)
+}
+
+func cmdAccountsDelete(args []string) error {
+	flags := newFlags("money accounts delete", "<account>")
+	if err := flags.parse(args, 1); err != nil {
+		return err
+	}
+	if err := connect(); err != nil {
+		return err
+	}
+	acc, err := findAccount(flags.Arg(0))
+	if err != nil {
+		return err
+	}
+	if err := bank.DeleteAccount(acc.ID); err != nil {
+		if errors.Is(err, bank.ErrAccountInUse) {
+			return errors.Errorf("%s, merge it into another account instead", err)
+		}
+		return err
+	}
+	fmt.Printf("Deleted account \"%s\"\n", acc.Path)
+	return nil
+}
+
+func cmdAccountsMerge(args []string) error {
+	flags := newFlags("money accounts merge", "[-y] [-preview] <from account> <to account>")
+	yes := flags.Bool("y", false, "Merge without preview and prompt")
+	previewOnly := flags.Bool("preview", false, "Only show what will change")
+	format := flags.output()
+	if err := flags.parse(args, 2); err != nil {
+		return err
+	}
+	if err := connect(); err != nil {
+		return err
+	}
+	from, err := findAccount(flags.Arg(0))
+	if err != nil {
+		return err
+	}
+	to, err := findAccount(flags.Arg(1))
+	if err != nil {
+		return err
+	}
+	if *previewOnly || !*yes {
+		preview, err := bank.PreviewMerge(from.ID, to.ID)
+		if err != nil {
+			return err
+		}
+		if err := write(newMergeTable(*preview), *format); err != nil {
+			return err
+		}
+		if *previewOnly {
+			return nil
+		}
+		if !confirm(fmt.Sprintf("Merge \"%s\" into \"%s\" and delete \"%s\"", from.Name, to.Name, from.Name)) {
+			fmt.Printf("Not merged.\n")
+			return nil
+		}
+	}
+	merged, err := bank.MergeAccounts(from.ID, to.ID)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Moved %d transactions from \"%s\" to \"%s\"\n", merged.NrTransactions, from.Name, to.Name)
+	return nil
+} //cmdAccountsMerge()
+
+func newMergeTable(p bank.MergePreview) *output.Table {
+	return output.New("From", "To", "Transactions", "Debits", "Credits", "Rules", "Bank Accounts", "Budgets", "Envelopes", "From Balance", "To Balance", "Merged Balance").
+		Row(p.From.Name, p.To.Name, p.NrTransactions, p.NrDebits, p.NrCredits, p.NrRules, p.NrBankAccounts, p.NrBudgets, p.NrEnvelopes, p.FromBalance, p.ToBalance, p.MergedBalance)
+}
+
+func cmdAccountsBalance(args []string) error {
+	flags := newFlags("money accounts balance", "[-date CCYY-MM-DD] <account>")
+	date := flags.String("date", "", "Balance at the end of this date (default all transactions)")
+	format := flags.output()
+	if err := flags.parse(args, 1); err != nil {
+		return err
+	}
+	asOf, err := parseDate(*date, true)
+	if err != nil {
+		return err
+	}
+	if err := connect(); err != nil {
+		return err
+	}
+	acc, err := findAccount(flags.Arg(0))
+	if err != nil {
+		return err
+	}
+	b, err := bank.GetBalance(acc.ID, asOf)
+	if err != nil {
+		return err
+	}
+	return write(output.New("Account", "Type", "Date", "Transactions", "Debits", "Credits", "Balance").
+		Row(b.Account.Path, b.Account.Type, b.AsOf, b.NrTransactions, b.Debits, b.Credits, b.Balance), *format)
+}
+
+func cmdAccountsLedger(args []string) error {
+	flags := newFlags("money accounts ledger", "[-from CCYY-MM-DD] [-to CCYY-MM-DD] [-tag t] [-q expr] <account>")
+	from := flags.String("from", "", "First date CCYY-MM-DD")
+	to := flags.String("to", "", "Last date CCYY-MM-DD")
+	tag := flags.String("tag", "", "Only transactions with this tag, with a running total from zero")
+	q := flags.String("q", "", "Only transactions matching the filter expression, with a running total from zero")
+	format := flags.output()
+	if err := flags.parse(args, 1); err != nil {
+		return err
+	}
+	fromTime, err := parseDate(*from, false)
+	if err != nil {
+		return err
+	}
+	toTime, err := parseDate(*to, true)
+	if err != nil {
+		return err
+	}
+	if err := connect(); err != nil {
+		return err
+	}
+	acc, err := findAccount(flags.Arg(0))
+	if err != nil {
+		return err
+	}
+	l, err := bank.GetLedger(acc.ID, bank.LedgerFilter{From: fromTime, To: toTime, Tag: *tag, Query: *q})
+	if err != nil {
+		return err
+	}
+	t := output.New("Date", "ID", "Counter Account", "Details", "Notes", "Debit", "Credit", "Balance")
+	if *format == output.FormatTable {
+		t.Row(l.From, "", "", "Opening balance", "", nil, nil, l.OpeningBalance)
+	}
+	for _, e := range l.Entries {
+		t.Row(e.Date, e.TransactionID, e.CounterAccountName, e.Details, e.Notes, e.Debit, e.Credit, e.Balance)
+	}
+	return write(t, *format)
+} //cmdAccountsLedger()
+
+//findAccount gets an account by name, id or path
+func findAccount(nameOrID string) (*bank.Account, error) {
+	acc, err := bank.GetAccountByName(nameOrID)
+	if err != nil {
+		return nil, err
+	}
+	if acc == nil {
+		if acc, err = bank.GetAccount(nameOrID); err != nil {
+			return nil, err
+		}
+	}
+	if acc == nil {
+		if acc, err = bank.GetAccountByPath(nameOrID); err != nil {
+			return nil, err
+		}
+	}
+	if acc == nil {
+		return nil, errors.Errorf("account \"%s\" not found", nameOrID)
+	}
+	return acc, nil
+}
+
+func newAccountTable() *output.Table {
+	return output.New("ID", "Path", "Type")
+}
+
+func addAccountRow(t *output.Table, acc bank.Account) *output.Table {
+	return t.Row(acc.ID, acc.Path, acc.Type)
+}
@@ -0,0 +1,147 @@
+package main
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/go-msvc/errors"
+	"github.com/jansemmelink/money/bank"
+	"github.com/jansemmelink/money/output"
+)
+
+func cmdBudgets(args []string) error {
+	return runCommand("money budgets", args, []command{
+		{name: "report", args: "[month]", summary: "Compare budgets with actual amounts", run: cmdBudgetsReport},
+		{name: "set", args: "[-carry] <account> <amount> [month]", summary: "Set the budget of an account", run: cmdBudgetsSet},
+		{name: "delete", args: "<account> [month]", summary: "Delete the budget of an account", run: cmdBudgetsDelete},
+		{name: "copy", args: "[-overwrite] [month]", summary: "Copy the budgets from the previous month", run: cmdBudgetsCopy},
+	})
+}
+
+//budgetMonth is the optional month argument, default the current month
+func budgetMonth(flags *commandFlags, i int) (string, error) {
+	if flags.NArg() <= i {
+		return time.Now().Format(bank.MonthFormat), nil
+	}
+	if _, err := bank.ParseMonth(flags.Arg(i)); err != nil {
+		return "", usagef("%s\n%s", err.Error(), flags.helpText())
+	}
+	return flags.Arg(i), nil
+}
+
+func cmdBudgetsReport(args []string) error {
+	flags := newFlags("money budgets report", "[CCYY-MM]")
+	format := flags.output()
+	if err := flags.parse(args, -1); err != nil {
+		return err
+	}
+	if flags.NArg() > 1 {
+		return usagef("expects optional month\n%s", flags.helpText())
+	}
+	month, err := budgetMonth(flags, 0)
+	if err != nil {
+		return err
+	}
+	if err := connect(); err != nil {
+		return err
+	}
+	lines, err := bank.GetBudgetReport(month)
+	if err != nil {
+		return err
+	}
+	t := output.New("Month", "Account", "Budget", "Carried Over", "Available", "Actual", "Variance", "Used %")
+	for _, l := range lines {
+		t.Row(l.Month, l.AccountName, l.Amount, l.CarriedOver, l.Available, l.Actual, l.Variance, fmt.Sprintf("%.0f", l.PercentUsed))
+	}
+	return write(t, *format)
+}
+
+func cmdBudgetsSet(args []string) error {
+	flags := newFlags("money budgets set", "[-carry] <account> <amount> [CCYY-MM]")
+	carry := flags.Bool("carry", false, "Add the amount left over (or overspent) in the previous month")
+	format := flags.output()
+	if err := flags.parse(args, -1); err != nil {
+		return err
+	}
+	if flags.NArg() < 2 || flags.NArg() > 3 {
+		return usagef("expects account, amount and optional month\n%s", flags.helpText())
+	}
+	amount, err := bank.NewAmount(flags.Arg(1))
+	if err != nil {
+		return usagef("invalid amount \"%s\"\n%s", flags.Arg(1), flags.helpText())
+	}
+	month, err := budgetMonth(flags, 2)
+	if err != nil {
+		return err
+	}
+	if err := connect(); err != nil {
+		return err
+	}
+	acc, err := findAccount(flags.Arg(0))
+	if err != nil {
+		return err
+	}
+	b := bank.Budget{AccountID: acc.ID, AccountName: acc.Name, Month: month, Amount: amount, CarryOver: *carry}
+	if err := b.Save(); err != nil {
+		return errors.Wrapf(err, "failed to set budget")
+	}
+	return write(newBudgetTable([]bank.Budget{b}), *format)
+}
+
+func cmdBudgetsDelete(args []string) error {
+	flags := newFlags("money budgets delete", "<account> [CCYY-MM]")
+	if err := flags.parse(args, -1); err != nil {
+		return err
+	}
+	if flags.NArg() < 1 || flags.NArg() > 2 {
+		return usagef("expects account and optional month\n%s", flags.helpText())
+	}
+	month, err := budgetMonth(flags, 1)
+	if err != nil {
+		return err
+	}
+	if err := connect(); err != nil {
+		return err
+	}
+	acc, err := findAccount(flags.Arg(0))
+	if err != nil {
+		return err
+	}
+	if err := bank.DeleteBudget(acc.ID, month); err != nil {
+		return err
+	}
+	fmt.Printf("Deleted budget of %s in %s\n", acc.Name, month)
+	return nil
+}
+
+func cmdBudgetsCopy(args []string) error {
+	flags := newFlags("money budgets copy", "[-overwrite] [CCYY-MM]")
+	overwrite := flags.Bool("overwrite", false, "Replace budgets already set in the month")
+	format := flags.output()
+	if err := flags.parse(args, -1); err != nil {
+		return err
+	}
+	if flags.NArg() > 1 {
+		return usagef("expects optional month\n%s", flags.helpText())
+	}
+	month, err := budgetMonth(flags, 0)
+	if err != nil {
+		return err
+	}
+	if err := connect(); err != nil {
+		return err
+	}
+	copied, err := bank.CopyBudgets(month, *overwrite)
+	if err != nil {
+		return err
+	}
+	return write(newBudgetTable(copied), *format)
+}
+
+func newBudgetTable(list []bank.Budget) *output.Table {
+	t := output.New("Month", "Account", "Amount", "Carry Over")
+	for _, b := range list {
+		t.Row(b.Month, b.AccountName, b.Amount, b.CarryOver)
+	}
+	return t
+}
@@ -0,0 +1,141 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/go-msvc/errors"
+	"github.com/jansemmelink/money/bank"
+	"github.com/jansemmelink/money/output"
+)
+
+func cmdDocuments(args []string) error {
+	return runCommand("money documents", args, []command{
+		{name: "upload", args: "<transaction id> <file> ...", summary: "Store files (e.g. scanned receipts) linked to a transaction", run: cmdDocumentsUpload},
+		{name: "list", args: "[transaction id]", summary: "List documents of a transaction or all documents", run: cmdDocumentsList},
+		{name: "download", args: "<document id> [file]", summary: "Write a document to a file or stdout", run: cmdDocumentsDownload},
+		{name: "unlink", args: "<transaction id> <document id>", summary: "Remove a document from a transaction", run: cmdDocumentsUnlink},
+	})
+}
+
+func cmdDocumentsUpload(args []string) error {
+	flags := newFlags("money documents upload", "[-type mime/type] <transaction id> <file> ...")
+	mimeType := flags.String("type", "", "Mime type (default detected from the file)")
+	format := flags.output()
+	if err := flags.parse(args, -1); err != nil {
+		return err
+	}
+	if flags.NArg() < 2 {
+		return usagef("expects transaction id and files\n%s", flags.helpText())
+	}
+	if err := connect(); err != nil {
+		return err
+	}
+	t := newDocumentTable()
+	for _, filename := range flags.Args()[1:] {
+		f, err := os.Open(filename)
+		if err != nil {
+			return errors.Wrapf(err, "cannot open %s", filename)
+		}
+		d, err := bank.AddDocument(flags.Arg(0), filename, *mimeType, f)
+		f.Close()
+		if err != nil {
+			return errors.Wrapf(err, "failed to upload %s", filename)
+		}
+		addDocumentRow(t, *d)
+	}
+	return write(t, *format)
+}
+
+func cmdDocumentsList(args []string) error {
+	flags := newFlags("money documents list", "[transaction id]")
+	format := flags.output()
+	if err := flags.parse(args, -1); err != nil {
+		return err
+	}
+	if flags.NArg() > 1 {
+		return usagef("expects optional transaction id\n%s", flags.helpText())
+	}
+	if err := connect(); err != nil {
+		return err
+	}
+	list, err := bank.GetDocuments(flags.Arg(0))
+	if err != nil {
+		return err
+	}
+	t := newDocumentTable()
+	for _, d := range list {
+		addDocumentRow(t, d)
+	}
+	return write(t, *format)
+}
+
+func cmdDocumentsDownload(args []string) error {
+	flags := newFlags("money documents download", "<document id> [file|-]")
+	if err := flags.parse(args, -1); err != nil {
+		return err
+	}
+	if flags.NArg() < 1 || flags.NArg() > 2 {
+		return usagef("expects document id and optional file\n%s", flags.helpText())
+	}
+	if err := connect(); err != nil {
+		return err
+	}
+	d, err := bank.GetDocument(flags.Arg(0))
+	if err != nil {
+		return err
+	}
+	if d == nil {
+		return errors.Errorf("document \"%s\" not found", flags.Arg(0))
+	}
+	f, err := bank.Documents.Open(d.Hash)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	filename := d.Name
+	if flags.NArg() > 1 {
+		filename = flags.Arg(1)
+	}
+	if filename == "-" {
+		_, err = io.Copy(os.Stdout, f)
+		return err
+	}
+	out, err := os.OpenFile(filename, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0640)
+	if err != nil {
+		return errors.Wrapf(err, "cannot create %s", filename)
+	}
+	if _, err := io.Copy(out, f); err != nil {
+		out.Close()
+		return errors.Wrapf(err, "failed to write %s", filename)
+	}
+	if err := out.Close(); err != nil {
+		return errors.Wrapf(err, "failed to write %s", filename)
+	}
+	fmt.Printf("Wrote %s (%d bytes)\n", filename, d.Size)
+	return nil
+} //cmdDocumentsDownload()
+
+func cmdDocumentsUnlink(args []string) error {
+	flags := newFlags("money documents unlink", "<transaction id> <document id>")
+	if err := flags.parse(args, 2); err != nil {
+		return err
+	}
+	if err := connect(); err != nil {
+		return err
+	}
+	if err := bank.UnlinkDocument(flags.Arg(0), flags.Arg(1)); err != nil {
+		return err
+	}
+	fmt.Printf("Unlinked document %s from transaction %s\n", flags.Arg(1), flags.Arg(0))
+	return nil
+}
+
+func newDocumentTable() *output.Table {
+	return output.New("ID", "Name", "Type", "Size", "Uploaded", "Hash")
+}
+
+func addDocumentRow(t *output.Table, d bank.Document) *output.Table {
+	return t.Row(d.ID, d.Name, d.MimeType, d.Size, d.Uploaded.Date(), d.Hash)
+}
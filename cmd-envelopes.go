@@ -0,0 +1,180 @@
+package main
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/go-msvc/errors"
+	"github.com/jansemmelink/money/bank"
+	"github.com/jansemmelink/money/output"
+)
+
+func cmdEnvelopes(args []string) error {
+	return runCommand("money envelopes", args, []command{
+		{name: "list", args: "", summary: "List envelopes", run: cmdEnvelopesList},
+		{name: "create", args: "<name> <expense account>", summary: "Create an envelope for spending on an account", run: cmdEnvelopesCreate},
+		{name: "delete", args: "<envelope>", summary: "Delete an envelope, returning its money to be budgeted", run: cmdEnvelopesDelete},
+		{name: "move", args: "[-date d] <from|-> <to|-> <amount>", summary: "Move money between envelopes, - is to be budgeted", run: cmdEnvelopesMove},
+		{name: "report", args: "[month]", summary: "Envelope balances with income, moves and spending", run: cmdEnvelopesReport},
+	})
+}
+
+func cmdEnvelopesList(args []string) error {
+	flags := newFlags("money envelopes list", "")
+	format := flags.output()
+	if err := flags.parse(args, 0); err != nil {
+		return err
+	}
+	if err := connect(); err != nil {
+		return err
+	}
+	list, err := bank.GetEnvelopes()
+	if err != nil {
+		return err
+	}
+	t := output.New("ID", "Name", "Account")
+	for _, e := range list {
+		t.Row(e.ID, e.Name, e.AccountName)
+	}
+	return write(t, *format)
+}
+
+func cmdEnvelopesCreate(args []string) error {
+	flags := newFlags("money envelopes create", "<name> <expense account>")
+	if err := flags.parse(args, 2); err != nil {
+		return err
+	}
+	if err := connect(); err != nil {
+		return err
+	}
+	acc, err := findAccount(flags.Arg(1))
+	if err != nil {
+		return err
+	}
+	e := bank.Envelope{Name: flags.Arg(0), AccountID: acc.ID}
+	if err := e.Save(); err != nil {
+		return errors.Wrapf(err, "failed to create envelope")
+	}
+	fmt.Printf("Created envelope %s (%s) for %s\n", e.Name, e.ID, acc.Path)
+	return nil
+}
+
+func cmdEnvelopesDelete(args []string) error {
+	flags := newFlags("money envelopes delete", "<envelope>")
+	if err := flags.parse(args, 1); err != nil {
+		return err
+	}
+	if err := connect(); err != nil {
+		return err
+	}
+	e, err := findEnvelope(flags.Arg(0))
+	if err != nil {
+		return err
+	}
+	if e == nil {
+		return usagef("cannot delete \"%s\"", bank.ToBeBudgeted)
+	}
+	if err := bank.DeleteEnvelope(e.ID); err != nil {
+		return err
+	}
+	fmt.Printf("Deleted envelope %s\n", e.Name)
+	return nil
+}
+
+func cmdEnvelopesMove(args []string) error {
+	flags := newFlags("money envelopes move", "[-date CCYY-MM-DD] [-notes n] <from|-> <to|-> <amount>")
+	date := flags.String("date", "", "Date of the move (default today)")
+	notes := flags.String("notes", "", "Notes")
+	if err := flags.parse(args, 3); err != nil {
+		return err
+	}
+	amount, err := bank.NewAmount(flags.Arg(2))
+	if err != nil {
+		return usagef("invalid amount \"%s\"\n%s", flags.Arg(2), flags.helpText())
+	}
+	moveDate := time.Now()
+	if *date != "" {
+		if moveDate, err = parseDate(*date, false); err != nil {
+			return err
+		}
+	}
+	if err := connect(); err != nil {
+		return err
+	}
+	from, err := findEnvelope(flags.Arg(0))
+	if err != nil {
+		return err
+	}
+	to, err := findEnvelope(flags.Arg(1))
+	if err != nil {
+		return err
+	}
+	if _, err := bank.MoveToEnvelope(moveDate, envelopeID(from), envelopeID(to), amount, *notes); err != nil {
+		return err
+	}
+	fmt.Printf("Moved %s from %s to %s\n", amount, envelopeName(from), envelopeName(to))
+	return nil
+} //cmdEnvelopesMove()
+
+func cmdEnvelopesReport(args []string) error {
+	flags := newFlags("money envelopes report", "[CCYY-MM]")
+	format := flags.output()
+	if err := flags.parse(args, -1); err != nil {
+		return err
+	}
+	if flags.NArg() > 1 {
+		return usagef("expects optional month\n%s", flags.helpText())
+	}
+	month, err := budgetMonth(flags, 0)
+	if err != nil {
+		return err
+	}
+	from, _ := bank.ParseMonth(month)
+	to := from.AddDate(0, 1, 0).Add(-time.Second)
+	if err := connect(); err != nil {
+		return err
+	}
+	r, err := bank.GetEnvelopeReport(from, to)
+	if err != nil {
+		return err
+	}
+	t := output.New("Envelope", "Account", "Opening", "Income", "Moved", "Spent", "Balance")
+	for _, l := range r.Lines {
+		t.Row(l.Name, l.AccountName, l.Opening, l.Income, l.Moved, l.Spent, l.Balance)
+	}
+	return write(t, *format)
+}
+
+//findEnvelope by name or id, returns nil for "-" which is to be budgeted
+func findEnvelope(nameOrID string) (*bank.Envelope, error) {
+	if nameOrID == "-" {
+		return nil, nil
+	}
+	e, err := bank.GetEnvelopeByName(nameOrID)
+	if err != nil {
+		return nil, err
+	}
+	if e == nil {
+		if e, err = bank.GetEnvelope(nameOrID); err != nil {
+			return nil, err
+		}
+	}
+	if e == nil {
+		return nil, errors.Errorf("envelope \"%s\" not found", nameOrID)
+	}
+	return e, nil
+}
+
+func envelopeID(e *bank.Envelope) string {
+	if e == nil {
+		return ""
+	}
+	return e.ID
+}
+
+func envelopeName(e *bank.Envelope) string {
+	if e == nil {
+		return bank.ToBeBudgeted
+	}
+	return e.Name
+}
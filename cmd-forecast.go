@@ -0,0 +1,63 @@
+package main
+
+import (
+	"strings"
+
+	"github.com/jansemmelink/money/bank"
+	"github.com/jansemmelink/money/output"
+)
+
+func cmdForecast(args []string) error {
+	flags := newFlags("money forecast", "[-days 30] [-threshold 0] [-account a] [-below]")
+	nrDays := flags.Int("days", 30, "Nr of days to forecast")
+	threshold := flags.String("threshold", "0", "Flag days with a balance below this amount")
+	account := flags.String("account", "", "Only this bank account (id or account number)")
+	below := flags.Bool("below", false, "Only list days below the threshold")
+	format := flags.output()
+	if err := flags.parse(args, 0); err != nil {
+		return err
+	}
+	if *nrDays <= 0 {
+		return usagef("days must be > 0\n%s", flags.helpText())
+	}
+	thresholdAmount, err := bank.NewAmount(*threshold)
+	if err != nil {
+		return usagef("invalid threshold \"%s\"\n%s", *threshold, flags.helpText())
+	}
+	if err := connect(); err != nil {
+		return err
+	}
+	bankAccountID := ""
+	if *account != "" {
+		ba, err := findBankAccount(*account)
+		if err != nil {
+			return err
+		}
+		bankAccountID = ba.ID
+	}
+	list, err := bank.GetForecasts(*nrDays, thresholdAmount)
+	if err != nil {
+		return err
+	}
+	t := output.New("Bank Account", "Date", "Expected", "Items", "Discretionary", "Balance", "Flag")
+	for _, f := range list {
+		if bankAccountID != "" && f.BankAccount.ID != bankAccountID {
+			continue
+		}
+		name := f.BankAccount.BankName + " " + f.BankAccount.AccountNumber
+		if !*below {
+			t.Row(name, f.Start, nil, "latest balance", nil, f.StartBalance, nil)
+		}
+		for _, day := range f.Days {
+			if *below && !day.Below {
+				continue
+			}
+			flag := ""
+			if day.Below {
+				flag = "below " + f.Threshold.String()
+			}
+			t.Row(name, day.Date, day.Expected, strings.Join(day.Items, ", "), day.Discretionary, day.Balance, flag)
+		}
+	}
+	return write(t, *format)
+} //cmdForecast()
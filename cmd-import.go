@@ -0,0 +1,104 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/go-msvc/errors"
+	"github.com/jansemmelink/money/bank"
+	"github.com/jansemmelink/money/importer"
+	"github.com/jansemmelink/money/output"
+)
+
+func cmdImport(args []string) error {
+	flags := newFlags("money import", "[-y] [-v] <file>")
+	yes := flags.Bool("y", false, "Import without prompt")
+	verbose := flags.Bool("v", false, "List the statement transactions")
+	if err := flags.parse(args, 1); err != nil {
+		return err
+	}
+	filename := flags.Arg(0)
+
+	format, stmt, err := importer.Load(filename)
+	if err != nil {
+		return errors.Wrapf(err, "failed to load %s", filename)
+	}
+
+	if !*yes {
+		fmt.Printf("Statement Loaded Successfully\n")
+		fmt.Printf("Filename: %s\n", filename)
+		fmt.Printf("Format: %s\n", format.Name)
+		fmt.Printf("Bank Name: %s\n", stmt.BankName())
+		fmt.Printf("Branch Name: %s\n", stmt.BranchName())
+		fmt.Printf("Branch Code: %s\n", stmt.BranchCode())
+		fmt.Printf("Account Number: %s\n", stmt.AccountNumber())
+		fmt.Printf("Open Date: %s\n", stmt.OpenDate().Format("2006-01-02"))
+		fmt.Printf("Open Balance: %s\n", stmt.OpenBalance())
+		fmt.Printf("Close Date: %s\n", stmt.CloseDate().Format("2006-01-02"))
+		fmt.Printf("Close Balance: %s\n", stmt.CloseBalance())
+	}
+	if *verbose {
+		for _, tx := range stmt.Transactions() {
+			fmt.Printf("%s,%s,%s,%s\n",
+				tx.Date.Local().Format("2006-01-02"),
+				tx.Details,
+				tx.Type,
+				tx.Amount)
+		}
+	}
+	if !*yes && !confirm("Import") {
+		fmt.Printf("Not imported.\n")
+		return nil
+	}
+
+	if err := connect(); err != nil {
+		return err
+	}
+	id, err := stmt.ImportToDb()
+	if err != nil {
+		return errors.Wrapf(err, "failed to import")
+	}
+	fmt.Printf("Imported successfully as statement \"%s\"\n", id)
+
+	//flag recurring payments that were missed or changed in this statement
+	s, err := bank.GetStatement(id)
+	if err != nil || s == nil {
+		return err
+	}
+	list, err := bank.GetRecurring(bank.DefaultRecurringOptions)
+	if err != nil {
+		return err
+	}
+	if flagged := flaggedRecurring(list, s.BankAccountID); len(flagged) > 0 {
+		fmt.Printf("\n%d recurring payments missed or changed:\n", len(flagged))
+		t, err := newRecurringTable(flagged)
+		if err != nil {
+			return err
+		}
+		if err := write(t, output.FormatTable); err != nil {
+			return err
+		}
+	}
+
+	//scheduled transactions due before the end of the statement that were not matched
+	unmatched, err := bank.GetUnmatchedScheduled(s.BankAccountID, time.Time(s.ClosingDate))
+	if err != nil {
+		return err
+	}
+	if len(unmatched) > 0 {
+		fmt.Printf("\n%d scheduled transactions not matched:\n", len(unmatched))
+		return write(newScheduledTable(unmatched), output.FormatTable)
+	}
+	return nil
+} //cmdImport()
+
+//confirm prompts on stdout and returns true if the answer starts with 'y'
+func confirm(prompt string) bool {
+	fmt.Fprintf(os.Stdout, "%s (y/n)[n] ?", prompt)
+	answer := ""
+	fmt.Scanln(&answer)
+	answer = strings.ToUpper(answer)
+	return len(answer) > 0 && answer[0] == 'Y'
+}
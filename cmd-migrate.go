@@ -0,0 +1,42 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/jansemmelink/money/db"
+	"github.com/jansemmelink/money/output"
+)
+
+func cmdMigrate(args []string) error {
+	flags := newFlags("money migrate", "[-list]")
+	list := flags.Bool("list", false, "List migrations without applying them")
+	format := flags.output()
+	if err := flags.parse(args, 0); err != nil {
+		return err
+	}
+	if err := connect(); err != nil {
+		return err
+	}
+	if *list {
+		migrations, err := db.Migrations()
+		if err != nil {
+			return err
+		}
+		t := output.New("Version", "Applied")
+		for _, m := range migrations {
+			t.Row(m.Version, m.Applied)
+		}
+		return write(t, *format)
+	}
+	applied, err := db.Migrate()
+	for _, version := range applied {
+		fmt.Printf("Applied %s\n", version)
+	}
+	if err != nil {
+		return err
+	}
+	if len(applied) == 0 {
+		fmt.Printf("Database is up to date\n")
+	}
+	return nil
+}
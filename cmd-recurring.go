@@ -0,0 +1,101 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/jansemmelink/money/bank"
+	"github.com/jansemmelink/money/output"
+)
+
+func cmdRecurring(args []string) error {
+	return runCommand("money recurring", args, []command{
+		{name: "list", args: "[-flagged] [-tolerance 0.2] [-min 3]", summary: "List recurring transactions with the next expected payment", run: cmdRecurringList},
+		{name: "prices", args: "[-tolerance 0.2] [-min 3]", summary: "List price changes of recurring transactions", run: cmdRecurringPrices},
+	})
+}
+
+func recurringFlags(flags *commandFlags) *bank.RecurringOptions {
+	opts := bank.DefaultRecurringOptions
+	flags.Float64Var(&opts.Tolerance, "tolerance", opts.Tolerance, "Max relative difference between amounts in a series")
+	flags.IntVar(&opts.MinCount, "min", opts.MinCount, "Min nr of weekly or monthly payments in a series")
+	return &opts
+}
+
+func cmdRecurringList(args []string) error {
+	flags := newFlags("money recurring list", "[-flagged] [-tolerance 0.2] [-min 3]")
+	flagged := flags.Bool("flagged", false, "Only list missed and changed payments")
+	opts := recurringFlags(flags)
+	format := flags.output()
+	if err := flags.parse(args, 0); err != nil {
+		return err
+	}
+	if err := connect(); err != nil {
+		return err
+	}
+	list, err := bank.GetRecurring(*opts)
+	if err != nil {
+		return err
+	}
+	if *flagged {
+		list = flaggedRecurring(list, "")
+	}
+	t, err := newRecurringTable(list)
+	if err != nil {
+		return err
+	}
+	return write(t, *format)
+}
+
+func cmdRecurringPrices(args []string) error {
+	flags := newFlags("money recurring prices", "[-tolerance 0.2] [-min 3]")
+	opts := recurringFlags(flags)
+	format := flags.output()
+	if err := flags.parse(args, 0); err != nil {
+		return err
+	}
+	if err := connect(); err != nil {
+		return err
+	}
+	list, err := bank.GetRecurring(*opts)
+	if err != nil {
+		return err
+	}
+	t := output.New("Merchant", "Interval", "Date", "From", "To", "Change %")
+	for _, s := range list {
+		for _, c := range s.PriceChanges {
+			t.Row(s.Merchant, s.Interval, c.Date, c.From, c.To, fmt.Sprintf("%+.1f", c.Percent))
+		}
+	}
+	return write(t, *format)
+}
+
+//flaggedRecurring are the missed or changed series, of one bank account unless bankAccountID is ""
+func flaggedRecurring(list []bank.RecurringSeries, bankAccountID string) []bank.RecurringSeries {
+	flagged := []bank.RecurringSeries{}
+	for _, s := range list {
+		if s.Status != bank.RecurringOK && (bankAccountID == "" || s.BankAccountID == bankAccountID) {
+			flagged = append(flagged, s)
+		}
+	}
+	return flagged
+}
+
+func newRecurringTable(list []bank.RecurringSeries) (*output.Table, error) {
+	baList, err := bank.GetBankAccounts()
+	if err != nil {
+		return nil, err
+	}
+	baName := map[string]string{}
+	for _, ba := range baList {
+		baName[ba.ID] = ba.BankName + " " + ba.AccountNumber
+	}
+	t := output.New("Bank Account", "Merchant", "Interval", "Count", "Last", "Last Amount", "Next Date", "Next Amount", "Counter Account", "Status")
+	for _, s := range list {
+		status := s.Status
+		if s.NrMissed > 0 {
+			status = fmt.Sprintf("%s %d", status, s.NrMissed)
+		}
+		t.Row(baName[s.BankAccountID], s.Merchant, s.Interval, s.Count, s.Last, s.LastAmount, s.NextDate, s.NextAmount, s.CounterAccount, status)
+	}
+	return t, nil
+}
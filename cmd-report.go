@@ -0,0 +1,155 @@
+package main
+
+import (
+	"os"
+	"strings"
+	"time"
+
+	"github.com/jansemmelink/money/bank"
+	"github.com/jansemmelink/money/output"
+	"github.com/jansemmelink/money/report"
+)
+
+func cmdReport(args []string) error {
+	//without a sub command it reports the account tree
+	if len(args) == 0 || strings.HasPrefix(args[0], "-") {
+		return cmdReportTree(args)
+	}
+	return runCommand("money report", args, []command{
+		{name: "tree", args: "[-all] [-depth n]", summary: "Totals rolled up the account tree", run: cmdReportTree},
+		{name: "trial-balance", args: "[-date d] [-tag t]", summary: "Debit and credit balance of each account", run: cmdReportTrialBalance},
+		{name: "balance-sheet", args: "[-date d] [-compare d]", summary: "Assets, liabilities and equity at a date", run: cmdReportBalanceSheet},
+		{name: "income", args: "[-from d] [-to d] [-period p] [-tag t]", summary: "Income and expenses per month, quarter or year", run: cmdReportIncome},
+	})
+}
+
+func cmdReportTree(args []string) error {
+	flags := newFlags("money report tree", "[-all] [-depth n]")
+	all := flags.Bool("all", false, "Include accounts without transactions")
+	depth := flags.Int("depth", 0, "Only show accounts up to this depth (0 for all), with totals rolled up")
+	format := flags.output()
+	if err := flags.parse(args, 0); err != nil {
+		return err
+	}
+	if err := connect(); err != nil {
+		return err
+	}
+	tree, err := bank.GetAccountTree()
+	if err != nil {
+		return err
+	}
+	t := output.New("Account", "Type", "Transactions", "Debits", "Credits", "Balance", "Total")
+	for _, node := range tree {
+		if node.RollUp.NrTransactions == 0 && !*all {
+			continue
+		}
+		if *depth > 0 && node.Depth >= *depth {
+			continue
+		}
+		name := node.Account.Path
+		if *format == output.FormatTable {
+			name = strings.Repeat("  ", node.Depth) + node.Account.Name
+		}
+		t.Row(name, node.Account.Type, node.Own.NrTransactions, node.Own.Debits, node.Own.Credits, node.Own.NormalBalance(), node.RollUp.NormalBalance())
+	}
+	return write(t, *format)
+}
+
+func cmdReportTrialBalance(args []string) error {
+	flags := newFlags("money report trial-balance", "[-date CCYY-MM-DD] [-tag t]")
+	date := flags.String("date", "", "Balances at the end of this date (default all transactions)")
+	tag := flags.String("tag", "", "Only transactions with this tag")
+	format := flags.output()
+	if err := flags.parse(args, 0); err != nil {
+		return err
+	}
+	asOf, err := parseDate(*date, true)
+	if err != nil {
+		return err
+	}
+	if err := connect(); err != nil {
+		return err
+	}
+	r, err := report.TrialBalance(asOf, *tag)
+	if err != nil {
+		return err
+	}
+	return r.Write(os.Stdout, *format)
+}
+
+func cmdReportBalanceSheet(args []string) error {
+	flags := newFlags("money report balance-sheet", "[-date CCYY-MM-DD] [-compare CCYY-MM-DD] [-depth n]")
+	date := flags.String("date", "", "Balance sheet date (default today)")
+	compare := flags.String("compare", "", "Add a column for this date, e.g. the year before")
+	depth := flags.Int("depth", 0, "Only show accounts up to this depth (0 for all)")
+	format := flags.output()
+	if err := flags.parse(args, 0); err != nil {
+		return err
+	}
+	if *date == "" {
+		*date = time.Now().Format("2006-01-02")
+	}
+	dates := []time.Time{}
+	for _, d := range []string{*date, *compare} {
+		if d == "" {
+			continue
+		}
+		t, err := parseDate(d, true)
+		if err != nil {
+			return err
+		}
+		dates = append(dates, t)
+	}
+	if err := connect(); err != nil {
+		return err
+	}
+	r, err := report.BalanceSheet(dates)
+	if err != nil {
+		return err
+	}
+	return r.MaxDepth(*depth).Write(os.Stdout, *format)
+}
+
+func cmdReportIncome(args []string) error {
+	flags := newFlags("money report income", "[-from CCYY-MM-DD] [-to CCYY-MM-DD] [-period month|quarter|year] [-depth n] [-tag t]")
+	from := flags.String("from", "", "First date (default start of the year)")
+	to := flags.String("to", "", "Last date (default today)")
+	period := flags.String("period", string(report.Month), "Column per month|quarter|year")
+	depth := flags.Int("depth", 0, "Only show accounts up to this depth (0 for all)")
+	tag := flags.String("tag", "", "Only transactions with this tag")
+	format := flags.output()
+	if err := flags.parse(args, 0); err != nil {
+		return err
+	}
+	interval, err := report.ParseInterval(*period)
+	if err != nil {
+		return usagef("%s", err.Error())
+	}
+	now := time.Now()
+	if *from == "" {
+		*from = time.Date(now.Year(), 1, 1, 0, 0, 0, 0, time.Local).Format("2006-01-02")
+	}
+	if *to == "" {
+		*to = now.Format("2006-01-02")
+	}
+	fromTime, err := parseDate(*from, false)
+	if err != nil {
+		return err
+	}
+	toTime, err := parseDate(*to, true)
+	if err != nil {
+		return err
+	}
+	periods, err := report.Periods(fromTime, toTime, interval)
+	if err != nil {
+		return usagef("%s", err.Error())
+	}
+	if err := connect(); err != nil {
+		return err
+	}
+	r, err := report.IncomeStatement(periods, *tag)
+	if err != nil {
+		return err
+	}
+	return r.MaxDepth(*depth).Write(os.Stdout, *format)
+} //cmdReportIncome()
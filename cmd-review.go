@@ -0,0 +1,311 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/go-msvc/errors"
+	"github.com/jansemmelink/money/bank"
+	"github.com/jansemmelink/money/output"
+)
+
+const reviewHelp = `  1..n         use the suggested account
+  a <account>  use (or create) the named account
+  n <notes>    set the transaction notes
+  r [account]  create a rule for all similar transactions and apply it
+  s            skip (the next review resumes after it)
+  q            quit`
+
+func cmdReview(args []string) error {
+	flags := newFlags("money review", "[-restart] [-n 3]")
+	restart := flags.Bool("restart", false, "Also review transactions skipped before")
+	n := flags.Int("n", 3, "Nr of suggestions and similar transactions to show")
+	if err := flags.parse(args, 0); err != nil {
+		return err
+	}
+	if err := connect(); err != nil {
+		return err
+	}
+	if *restart {
+		if err := bank.ClearReviewSkips(); err != nil {
+			return err
+		}
+	}
+	skipped, err := bank.GetReviewSkips()
+	if err != nil {
+		return err
+	}
+	txList, err := bank.GetUnknownTransactions(0)
+	if err != nil {
+		return err
+	}
+	suggester, err := bank.TrainSuggester()
+	if err != nil {
+		return err
+	}
+
+	r := reviewer{
+		in:        bufio.NewReader(os.Stdin),
+		suggester: suggester,
+		n:         *n,
+	}
+	nrReviewed := 0
+	for i, tx := range txList {
+		if skipped[tx.ID] {
+			continue
+		}
+		//reload because a rule created during the review may have categorised it
+		current, err := bank.GetTransaction(tx.ID)
+		if err != nil {
+			return err
+		}
+		if current == nil || !current.IsUnknown() {
+			continue
+		}
+		fmt.Printf("\n[%d/%d]\n", i+1, len(txList))
+		quit, err := r.review(current)
+		if err != nil {
+			return err
+		}
+		if quit {
+			break
+		}
+		nrReviewed++
+	}
+	fmt.Printf("Reviewed %d transactions\n", nrReviewed)
+	return nil
+} //cmdReview()
+
+type reviewer struct {
+	in        *bufio.Reader
+	suggester *bank.Suggester
+	n         int
+}
+
+//review prompts until the transaction is categorised or skipped
+func (r reviewer) review(tx *bank.TransactionRecord) (quit bool, err error) {
+	stx := tx.StatementTransaction()
+	fmt.Printf("Date:    %s\n", tx.Date.Date())
+	fmt.Printf("Amount:  %s\n", tx.Amount)
+	fmt.Printf("Type:    %s\n", tx.StatementType)
+	fmt.Printf("Details: %s\n", tx.StatementDetails)
+	fmt.Printf("Code:    %s\n", tx.StatementCode)
+	if tx.Notes != "" {
+		fmt.Printf("Notes:   %s\n", tx.Notes)
+	}
+	suggestions := r.suggester.Suggest(stx, r.n)
+	if len(suggestions) > 0 {
+		fmt.Printf("Suggested:\n")
+		for i, s := range suggestions {
+			fmt.Printf("  %d) %s (%.0f%%)\n", i+1, s.Account.Name, s.Confidence*100)
+		}
+	}
+	if similar := r.suggester.Similar(stx, r.n); len(similar) > 0 {
+		fmt.Printf("Similar:\n")
+		for _, s := range similar {
+			fmt.Printf("  %s %10s %-30s %s\n", s.Date.Date(), s.Amount, s.CounterAccountName(), s.StatementDetails)
+		}
+	}
+
+	for {
+		line, err := r.prompt("Account (? for help)")
+		if err != nil {
+			if err == io.EOF {
+				return true, nil
+			}
+			return false, err
+		}
+		cmd, arg := line, ""
+		if i := strings.Index(line, " "); i > 0 {
+			cmd, arg = line[:i], strings.TrimSpace(line[i+1:])
+		}
+		if nr, err := strconv.Atoi(cmd); err == nil {
+			if nr < 1 || nr > len(suggestions) {
+				fmt.Printf("No suggestion %d\n", nr)
+				continue
+			}
+			return false, r.categorise(tx, suggestions[nr-1].Account)
+		}
+		switch cmd {
+		case "":
+		case "a":
+			acc, err := r.getOrCreateAccount(arg, tx)
+			if err != nil {
+				return false, err
+			}
+			if acc == nil {
+				continue
+			}
+			return false, r.categorise(tx, *acc)
+		case "n":
+			tx.Notes = arg
+			if err := tx.Save(); err != nil {
+				return false, err
+			}
+			fmt.Printf("Notes saved\n")
+		case "r":
+			done, err := r.createRule(tx, arg, suggestions)
+			if err != nil {
+				return false, err
+			}
+			if done {
+				return false, nil
+			}
+		case "s":
+			return false, bank.SkipReview(tx.ID)
+		case "q":
+			return true, nil
+		default:
+			fmt.Println(reviewHelp)
+		}
+	}
+} //reviewer.review()
+
+func (r reviewer) prompt(text string) (string, error) {
+	fmt.Printf("%s: ", text)
+	line, err := r.in.ReadString('\n')
+	if err != nil && (err != io.EOF || line == "") {
+		return "", err
+	}
+	return strings.TrimSpace(line), nil
+}
+
+func (r reviewer) categorise(tx *bank.TransactionRecord, acc bank.Account) error {
+	tx.SetCounterAccount(acc)
+	if err := tx.Save(); err != nil {
+		return errors.Wrapf(err, "failed to save transaction")
+	}
+	r.suggester.Learn(*tx)
+	fmt.Printf("-> %s\n", acc.Name)
+	return nil
+}
+
+//getOrCreateAccount returns nil if the user declined to create a new account
+func (r reviewer) getOrCreateAccount(name string, tx *bank.TransactionRecord) (*bank.Account, error) {
+	if name == "" {
+		fmt.Printf("Specify the account name\n")
+		return nil, nil
+	}
+	acc, err := bank.GetAccountByPath(name)
+	if err != nil || acc != nil {
+		return acc, err
+	}
+	if !strings.Contains(name, bank.PathSeparator) {
+		if acc, err = bank.GetAccountByName(name); err != nil {
+			fmt.Printf("%s\n", err.Error())
+			return nil, nil
+		} else if acc != nil {
+			return acc, nil
+		}
+	}
+	accountType := bank.AccountTypeExpense
+	if tx.Amount.MilliCents() > 0 {
+		accountType = bank.AccountTypeIncome
+	}
+	answer, err := r.prompt(fmt.Sprintf("Create account \"%s\" of type [%s] (- to cancel)", name, accountType))
+	if err != nil {
+		return nil, err
+	}
+	if answer == "-" {
+		return nil, nil
+	}
+	if answer != "" {
+		if accountType, err = bank.ParseAccountType(answer); err != nil {
+			fmt.Printf("%s\n", err.Error())
+			return nil, nil
+		}
+	}
+	//a path creates the missing accounts along it
+	if acc, err = bank.CreateAccountPath(name, accountType); err != nil {
+		fmt.Printf("Cannot create account: %s\n", err.Error())
+		return nil, nil
+	}
+	return acc, nil
+}
+
+//createRule creates a rule matching the details of tx and, after confirming the
+//transactions it matches, applies only that rule to the unknown transactions
+func (r reviewer) createRule(tx *bank.TransactionRecord, accountName string, suggestions []bank.AccountSuggestion) (done bool, err error) {
+	if accountName == "" && len(suggestions) > 0 {
+		accountName = suggestions[0].Account.Name
+	}
+	if answer, err := r.prompt(fmt.Sprintf("Rule account [%s]", accountName)); err != nil {
+		return false, err
+	} else if answer != "" {
+		accountName = answer
+	}
+	acc, err := r.getOrCreateAccount(accountName, tx)
+	if err != nil || acc == nil {
+		return false, err
+	}
+	match := detailsPrefix(tx.StatementDetails)
+	if answer, err := r.prompt(fmt.Sprintf("Details contains [%s]", match)); err != nil {
+		return false, err
+	} else if answer != "" {
+		match = answer
+	}
+	if match == "" {
+		fmt.Printf("Rule needs details to match\n")
+		return false, nil
+	}
+	rule := bank.Rule{
+		Name:            "review: " + match,
+		DetailsContains: match,
+		Sign:            "-",
+		AccountID:       acc.ID,
+		AccountName:     acc.Name,
+		Notes:           tx.Notes,
+	}
+	if tx.Amount.MilliCents() > 0 {
+		rule.Sign = "+"
+	}
+	if err := rule.Validate(); err != nil {
+		fmt.Printf("Cannot create rule: %s\n", err.Error())
+		return false, nil
+	}
+
+	//show what the new rule will categorise before saving it
+	matches, err := bank.ApplyRule(rule, true)
+	if err != nil {
+		return false, err
+	}
+	if err := write(newRuleMatchTable(matches), output.FormatTable); err != nil {
+		return false, err
+	}
+	if answer, err := r.prompt(fmt.Sprintf("Create rule and categorise %d transactions (y/n)[n]", len(matches))); err != nil {
+		return false, err
+	} else if !strings.HasPrefix(strings.ToUpper(answer), "Y") {
+		fmt.Printf("Rule not created\n")
+		return false, nil
+	}
+	if err := rule.Save(); err != nil {
+		fmt.Printf("Cannot create rule: %s\n", err.Error())
+		return false, nil
+	}
+	if matches, err = bank.ApplyRule(rule, false); err != nil {
+		return false, err
+	}
+	for _, m := range matches {
+		r.suggester.Learn(m.Transaction)
+	}
+	fmt.Printf("Rule \"%s\" categorised %d transactions\n", rule.Name, len(matches))
+	return true, nil
+} //reviewer.createRule()
+
+//detailsPrefix is the leading words of the details up to the first word with digits,
+//e.g. "Spar Midstrea" from "Spar Midstrea 5222*7143 23 SEP"
+func detailsPrefix(details string) string {
+	words := []string{}
+	for _, w := range strings.Fields(details) {
+		if strings.IndexFunc(w, unicode.IsDigit) >= 0 {
+			break
+		}
+		words = append(words, w)
+	}
+	return strings.Join(words, " ")
+}
@@ -0,0 +1,216 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/go-msvc/errors"
+	"github.com/jansemmelink/money/bank"
+	"github.com/jansemmelink/money/output"
+)
+
+func cmdRules(args []string) error {
+	return runCommand("money rules", args, []command{
+		{name: "list", args: "", summary: "List rules in the order they are applied", run: cmdRulesList},
+		{name: "create", args: "-name n -account a [match flags]", summary: "Create a rule", run: cmdRulesCreate},
+		{name: "delete", args: "<id>", summary: "Delete a rule", run: cmdRulesDelete},
+		{name: "apply", args: "[-y]", summary: "Preview then apply rules to unknown transactions", run: cmdRulesApply},
+	})
+}
+
+func cmdRulesList(args []string) error {
+	flags := newFlags("money rules list", "")
+	format := flags.output()
+	if err := flags.parse(args, 0); err != nil {
+		return err
+	}
+	if err := connect(); err != nil {
+		return err
+	}
+	rules, err := bank.GetRules()
+	if err != nil {
+		return err
+	}
+	t := newRuleTable()
+	for _, r := range rules {
+		addRuleRow(t, r)
+	}
+	return write(t, *format)
+}
+
+func cmdRulesCreate(args []string) error {
+	flags := newFlags("money rules create", "-name n -account a [match flags]")
+	name := flags.String("name", "", "Unique rule name")
+	priority := flags.Int("priority", 0, "Rules with lower priority are tried first")
+	account := flags.String("account", "", "Counter account name or id to assign")
+	notes := flags.String("notes", "", "Notes to assign")
+	bankAccount := flags.String("bank-account", "", "Only match this bank account (id or account number)")
+	statementType := flags.String("type", "", "Match statement type")
+	statementCode := flags.String("code", "", "Match statement code")
+	details := flags.String("details", "", "Match part of statement details")
+	regex := flags.String("regex", "", "Match statement details with regular expression")
+	sign := flags.String("sign", "", "Match \"+\" for income or \"-\" for expenses")
+	minAmount := flags.String("min", "", "Match amount (without sign) >= min")
+	maxAmount := flags.String("max", "", "Match amount (without sign) <= max")
+	format := flags.output()
+	if err := flags.parse(args, 0); err != nil {
+		return err
+	}
+	if *name == "" || *account == "" {
+		return usagef("-name and -account are required\n%s", flags.helpText())
+	}
+	rule := bank.Rule{
+		Name:            *name,
+		Priority:        *priority,
+		StatementType:   *statementType,
+		StatementCode:   *statementCode,
+		DetailsContains: *details,
+		DetailsRegex:    *regex,
+		Sign:            *sign,
+		Notes:           *notes,
+	}
+	var err error
+	if rule.MinAmount, err = parseOptionalAmount(*minAmount); err != nil {
+		return err
+	}
+	if rule.MaxAmount, err = parseOptionalAmount(*maxAmount); err != nil {
+		return err
+	}
+	if err := connect(); err != nil {
+		return err
+	}
+	acc, err := findAccount(*account)
+	if err != nil {
+		return err
+	}
+	rule.AccountID = acc.ID
+	rule.AccountName = acc.Name
+	if *bankAccount != "" {
+		ba, err := findBankAccount(*bankAccount)
+		if err != nil {
+			return err
+		}
+		rule.BankAccountID = ba.ID
+	}
+	if err := rule.Save(); err != nil {
+		return err
+	}
+	return write(addRuleRow(newRuleTable(), rule), *format)
+} //cmdRulesCreate()
+
+func cmdRulesDelete(args []string) error {
+	flags := newFlags("money rules delete", "<id>")
+	if err := flags.parse(args, 1); err != nil {
+		return err
+	}
+	if err := connect(); err != nil {
+		return err
+	}
+	if err := bank.DeleteRule(flags.Arg(0)); err != nil {
+		return err
+	}
+	fmt.Printf("Deleted rule %s\n", flags.Arg(0))
+	return nil
+}
+
+func cmdRulesApply(args []string) error {
+	flags := newFlags("money rules apply", "[-y]")
+	yes := flags.Bool("y", false, "Apply without preview and prompt")
+	format := flags.output()
+	if err := flags.parse(args, 0); err != nil {
+		return err
+	}
+	if err := connect(); err != nil {
+		return err
+	}
+	if !*yes {
+		matches, err := bank.ApplyRules(true)
+		if err != nil {
+			return err
+		}
+		if err := write(newRuleMatchTable(matches), *format); err != nil {
+			return err
+		}
+		if len(matches) == 0 || !confirm(fmt.Sprintf("Apply rules to %d transactions", len(matches))) {
+			fmt.Printf("Not applied.\n")
+			return nil
+		}
+	}
+	matches, err := bank.ApplyRules(false)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Updated %d transactions\n", len(matches))
+	return nil
+} //cmdRulesApply()
+
+func newRuleTable() *output.Table {
+	return output.New("ID", "Priority", "Name", "Match", "Account", "Notes")
+}
+
+func addRuleRow(t *output.Table, r bank.Rule) *output.Table {
+	match := ""
+	add := func(name string, value interface{}) {
+		if match != "" {
+			match += " "
+		}
+		match += fmt.Sprintf("%s=%v", name, value)
+	}
+	if r.BankAccountID != "" {
+		add("bank_account", r.BankAccountID)
+	}
+	if r.StatementType != "" {
+		add("type", r.StatementType)
+	}
+	if r.StatementCode != "" {
+		add("code", r.StatementCode)
+	}
+	if r.DetailsContains != "" {
+		add("details", r.DetailsContains)
+	}
+	if r.DetailsRegex != "" {
+		add("regex", r.DetailsRegex)
+	}
+	if r.Sign != "" {
+		add("sign", r.Sign)
+	}
+	if r.MinAmount != nil {
+		add("min", *r.MinAmount)
+	}
+	if r.MaxAmount != nil {
+		add("max", *r.MaxAmount)
+	}
+	return t.Row(r.ID, r.Priority, r.Name, match, r.AccountName, r.Notes)
+}
+
+func newRuleMatchTable(matches []bank.RuleMatch) *output.Table {
+	t := output.New("ID", "Date", "Amount", "Details", "Rule", "Account", "Notes")
+	for _, m := range matches {
+		t.Row(m.Transaction.ID, m.Transaction.Date.Date(), m.Transaction.Amount, m.Transaction.StatementDetails, m.Rule.Name, m.Rule.AccountName, m.Transaction.Notes)
+	}
+	return t
+}
+
+func parseOptionalAmount(s string) (*bank.Amount, error) {
+	if s == "" {
+		return nil, nil
+	}
+	a, err := bank.NewAmount(s)
+	if err != nil {
+		return nil, usagef("invalid amount \"%s\": %s", s, err.Error())
+	}
+	return &a, nil
+}
+
+//findBankAccount gets a bank account by id or account number
+func findBankAccount(idOrNumber string) (*bank.BankAccount, error) {
+	list, err := bank.GetBankAccounts()
+	if err != nil {
+		return nil, err
+	}
+	for _, ba := range list {
+		if ba.ID == idOrNumber || ba.AccountNumber == idOrNumber {
+			return &ba, nil
+		}
+	}
+	return nil, errors.Errorf("bank account \"%s\" not found", idOrNumber)
+}
@@ -0,0 +1,208 @@
+package main
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/go-msvc/errors"
+	"github.com/jansemmelink/money/bank"
+	"github.com/jansemmelink/money/db"
+	"github.com/jansemmelink/money/output"
+)
+
+func cmdScheduled(args []string) error {
+	return runCommand("money scheduled", args, []command{
+		{name: "list", args: "", summary: "List scheduled transactions", run: cmdScheduledList},
+		{name: "create", args: "[-every n] [-end d] [-contains s] ... <name> <bank account> <account> <amount> <start> [once|weekly|monthly|quarterly|annually]", summary: "Schedule an expected payment or receipt", run: cmdScheduledCreate},
+		{name: "delete", args: "<name|id>", summary: "Delete a scheduled transaction and its matches", run: cmdScheduledDelete},
+		{name: "due", args: "[-from d] [-to d]", summary: "List due dates with the matched bank transactions", run: cmdScheduledDue},
+		{name: "unmatched", args: "[-date d] [-account a]", summary: "List due dates without a matching bank transaction", run: cmdScheduledUnmatched},
+	})
+}
+
+func cmdScheduledList(args []string) error {
+	flags := newFlags("money scheduled list", "")
+	format := flags.output()
+	if err := flags.parse(args, 0); err != nil {
+		return err
+	}
+	if err := connect(); err != nil {
+		return err
+	}
+	list, err := bank.GetScheduledTransactions()
+	if err != nil {
+		return err
+	}
+	t := output.New("ID", "Name", "Account", "Amount", "Start", "End", "Recurrence", "Every", "Days ±", "Amount ±%", "Details Contains", "Notes")
+	for _, s := range list {
+		var end interface{}
+		if s.EndDate != nil {
+			end = s.EndDate.Date()
+		}
+		t.Row(s.ID, s.Name, s.AccountName, s.Amount, s.StartDate.Date(), end, s.Recurrence, s.Every, s.DaysTolerance, s.AmountTolerance, s.DetailsContains, s.Notes)
+	}
+	return write(t, *format)
+}
+
+func cmdScheduledCreate(args []string) error {
+	flags := newFlags("money scheduled create", "[-every n] [-end CCYY-MM-DD] [-contains s] [-days 5] [-percent 10] [-notes n]"+
+		" <name> <bank account> <account> <amount> <start CCYY-MM-DD> [once|weekly|monthly|quarterly|annually]")
+	every := flags.Int("every", 1, "Nr of weeks, months, quarters or years between due dates")
+	end := flags.String("end", "", "Last date CCYY-MM-DD (default no end)")
+	contains := flags.String("contains", "", "Only match bank transactions with details containing this text")
+	days := flags.Int("days", 5, "Match bank transactions this nr of days before/after the due date")
+	percent := flags.Int("percent", 10, "Match bank amounts this % more or less")
+	notes := flags.String("notes", "", "Notes for the matched transactions")
+	if err := flags.parse(args, -1); err != nil {
+		return err
+	}
+	if flags.NArg() < 5 || flags.NArg() > 6 {
+		return usagef("expects name, bank account, account, amount, start date and optional recurrence\n%s", flags.helpText())
+	}
+	amount, err := bank.NewAmount(flags.Arg(3))
+	if err != nil {
+		return usagef("invalid amount \"%s\" (negative for payments)\n%s", flags.Arg(3), flags.helpText())
+	}
+	start, err := parseDate(flags.Arg(4), false)
+	if err != nil {
+		return err
+	}
+	recurrence := bank.RecurrenceOnce
+	if flags.NArg() > 5 {
+		if recurrence, err = bank.ParseRecurrence(flags.Arg(5)); err != nil {
+			return usagef("%s\n%s", err.Error(), flags.helpText())
+		}
+	}
+	s := bank.ScheduledTransaction{
+		Name:            flags.Arg(0),
+		Amount:          amount,
+		StartDate:       db.SqlTime(start),
+		Recurrence:      recurrence,
+		Every:           *every,
+		DetailsContains: *contains,
+		DaysTolerance:   *days,
+		AmountTolerance: *percent,
+		Notes:           *notes,
+	}
+	if *end != "" {
+		endDate, err := parseDate(*end, true)
+		if err != nil {
+			return err
+		}
+		s.EndDate = (*db.SqlTime)(&endDate)
+	}
+	if err := connect(); err != nil {
+		return err
+	}
+	ba, err := findBankAccount(flags.Arg(1))
+	if err != nil {
+		return err
+	}
+	s.BankAccountID = ba.ID
+	acc, err := findAccount(flags.Arg(2))
+	if err != nil {
+		return err
+	}
+	s.AccountID = acc.ID
+	if err := s.Save(); err != nil {
+		return errors.Wrapf(err, "failed to create scheduled transaction")
+	}
+	fmt.Printf("Scheduled %s (%s) %s %s from %s\n", s.Name, s.ID, s.Recurrence, s.Amount, s.StartDate.Date())
+	return nil
+} //cmdScheduledCreate()
+
+func cmdScheduledDelete(args []string) error {
+	flags := newFlags("money scheduled delete", "<name|id>")
+	if err := flags.parse(args, 1); err != nil {
+		return err
+	}
+	if err := connect(); err != nil {
+		return err
+	}
+	list, err := bank.GetScheduledTransactions()
+	if err != nil {
+		return err
+	}
+	for _, s := range list {
+		if s.ID == flags.Arg(0) || s.Name == flags.Arg(0) {
+			if err := bank.DeleteScheduledTransaction(s.ID); err != nil {
+				return err
+			}
+			fmt.Printf("Deleted scheduled transaction %s\n", s.Name)
+			return nil
+		}
+	}
+	return errors.Errorf("scheduled transaction \"%s\" not found", flags.Arg(0))
+}
+
+func cmdScheduledDue(args []string) error {
+	flags := newFlags("money scheduled due", "[-from CCYY-MM-DD] [-to CCYY-MM-DD]")
+	from := flags.String("from", "", "First date CCYY-MM-DD (default a month ago)")
+	to := flags.String("to", "", "Last date CCYY-MM-DD (default a month from now)")
+	format := flags.output()
+	if err := flags.parse(args, 0); err != nil {
+		return err
+	}
+	fromTime := time.Now().AddDate(0, -1, 0)
+	toTime := time.Now().AddDate(0, 1, 0)
+	var err error
+	if *from != "" {
+		if fromTime, err = parseDate(*from, false); err != nil {
+			return err
+		}
+	}
+	if *to != "" {
+		if toTime, err = parseDate(*to, true); err != nil {
+			return err
+		}
+	}
+	if err := connect(); err != nil {
+		return err
+	}
+	list, err := bank.GetScheduledInstances(fromTime, toTime)
+	if err != nil {
+		return err
+	}
+	return write(newScheduledTable(list), *format)
+}
+
+func cmdScheduledUnmatched(args []string) error {
+	flags := newFlags("money scheduled unmatched", "[-date CCYY-MM-DD] [-account a]")
+	date := flags.String("date", "", "Due before this date (default today)")
+	account := flags.String("account", "", "Only this bank account")
+	format := flags.output()
+	if err := flags.parse(args, 0); err != nil {
+		return err
+	}
+	asOf := time.Now()
+	if *date != "" {
+		var err error
+		if asOf, err = parseDate(*date, true); err != nil {
+			return err
+		}
+	}
+	if err := connect(); err != nil {
+		return err
+	}
+	bankAccountID := ""
+	if *account != "" {
+		ba, err := findBankAccount(*account)
+		if err != nil {
+			return err
+		}
+		bankAccountID = ba.ID
+	}
+	list, err := bank.GetUnmatchedScheduled(bankAccountID, asOf)
+	if err != nil {
+		return err
+	}
+	return write(newScheduledTable(list), *format)
+}
+
+func newScheduledTable(list []bank.ScheduledInstance) *output.Table {
+	t := output.New("Due", "Name", "Account", "Amount", "Transaction")
+	for _, i := range list {
+		t.Row(i.DueDate.Format("2006-01-02"), i.Scheduled.Name, i.Scheduled.AccountName, i.Scheduled.Amount, i.TransactionID)
+	}
+	return t
+}
@@ -0,0 +1,17 @@
+package main
+
+import (
+	"github.com/jansemmelink/money/api"
+)
+
+func cmdServe(args []string) error {
+	flags := newFlags("money serve", "[-addr host:port]")
+	addr := flags.String("addr", "localhost:12345", "HTTP Server address")
+	if err := flags.parse(args, 0); err != nil {
+		return err
+	}
+	if err := connect(); err != nil {
+		return err
+	}
+	return api.Serve(*addr)
+}
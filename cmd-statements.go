@@ -0,0 +1,154 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/go-msvc/errors"
+	"github.com/jansemmelink/money/bank"
+	"github.com/jansemmelink/money/output"
+)
+
+func cmdStatements(args []string) error {
+	return runCommand("money statements", args, []command{
+		{name: "list", args: "", summary: "List imported statements", run: cmdStatementsList},
+		{name: "show", args: "<id>", summary: "Show a statement with its transactions", run: cmdStatementsShow},
+		{name: "delete", args: "[-y] <id>", summary: "Delete a statement and its transactions", run: cmdStatementsDelete},
+		{name: "opening-balances", args: "[-y]", summary: "Post the first statement opening balance of each bank account", run: cmdStatementsOpeningBalances},
+	})
+}
+
+func cmdStatementsList(args []string) error {
+	flags := newFlags("money statements list", "")
+	format := flags.output()
+	if err := flags.parse(args, 0); err != nil {
+		return err
+	}
+	if err := connect(); err != nil {
+		return err
+	}
+	list, err := bank.GetStatements("")
+	if err != nil {
+		return err
+	}
+	t := newStatementTable()
+	for _, s := range list {
+		addStatementRow(t, s)
+	}
+	return write(t, *format)
+}
+
+func cmdStatementsShow(args []string) error {
+	flags := newFlags("money statements show", "<id>")
+	format := flags.output()
+	if err := flags.parse(args, 1); err != nil {
+		return err
+	}
+	if err := connect(); err != nil {
+		return err
+	}
+	s, err := getStatement(flags.Arg(0))
+	if err != nil {
+		return err
+	}
+	if *format == output.FormatTable {
+		if err := write(addStatementRow(newStatementTable(), *s), *format); err != nil {
+			return err
+		}
+		fmt.Println()
+	}
+	txList, err := bank.GetTransactions(bank.TransactionFilter{StatementID: s.ID})
+	if err != nil {
+		return err
+	}
+	t := newTransactionTable()
+	for _, tx := range txList {
+		addTransactionRow(t, tx)
+	}
+	return write(t, *format)
+}
+
+func cmdStatementsDelete(args []string) error {
+	flags := newFlags("money statements delete", "[-y] <id>")
+	yes := flags.Bool("y", false, "Delete without prompt")
+	if err := flags.parse(args, 1); err != nil {
+		return err
+	}
+	if err := connect(); err != nil {
+		return err
+	}
+	s, err := getStatement(flags.Arg(0))
+	if err != nil {
+		return err
+	}
+	if !*yes && !confirm(fmt.Sprintf("Delete statement %s %s from %s to %s with %d transactions",
+		s.BankName, s.AccountNumber, s.OpeningDate.Date(), s.ClosingDate.Date(), s.NrTransactions)) {
+		fmt.Printf("Not deleted.\n")
+		return nil
+	}
+	nr, err := bank.DeleteStatement(s.ID)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Deleted statement %s with %d transactions\n", s.ID, nr)
+	return nil
+}
+
+//cmdStatementsOpeningBalances fixes bank accounts imported before opening balances
+//were posted, or whose first statement changed since
+func cmdStatementsOpeningBalances(args []string) error {
+	flags := newFlags("money statements opening-balances", "[-y]")
+	yes := flags.Bool("y", false, "Fix without preview and prompt")
+	format := flags.output()
+	if err := flags.parse(args, 0); err != nil {
+		return err
+	}
+	if err := connect(); err != nil {
+		return err
+	}
+	if !*yes {
+		list, err := bank.FixOpeningBalances(true)
+		if err != nil {
+			return err
+		}
+		if err := write(newOpeningBalanceTable(list), *format); err != nil {
+			return err
+		}
+		if len(list) == 0 || !confirm(fmt.Sprintf("Fix opening balances of %d bank accounts", len(list))) {
+			fmt.Printf("Not fixed.\n")
+			return nil
+		}
+	}
+	list, err := bank.FixOpeningBalances(false)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Fixed %d opening balances\n", len(list))
+	return nil
+} //cmdStatementsOpeningBalances()
+
+func newOpeningBalanceTable(list []bank.OpeningBalance) *output.Table {
+	t := output.New("Account", "Old Date", "Old Amount", "Date", "Amount")
+	for _, ob := range list {
+		t.Row(ob.AccountName, ob.OldDate, ob.OldAmount, ob.Date, ob.Amount)
+	}
+	return t
+}
+
+func getStatement(id string) (*bank.StatementRecord, error) {
+	s, err := bank.GetStatement(id)
+	if err != nil {
+		return nil, err
+	}
+	if s == nil {
+		return nil, errors.Errorf("statement \"%s\" not found", id)
+	}
+	return s, nil
+}
+
+func newStatementTable() *output.Table {
+	return output.New("ID", "Bank", "Account Number", "Opening Date", "Opening Balance", "Closing Date", "Closing Balance", "Transactions")
+}
+
+func addStatementRow(t *output.Table, s bank.StatementRecord) *output.Table {
+	return t.Row(s.ID, s.BankName, s.AccountNumber, s.OpeningDate.Date(), s.OpeningBalance, s.ClosingDate.Date(), s.ClosingBalance, s.NrTransactions)
+}
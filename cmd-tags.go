@@ -0,0 +1,50 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/jansemmelink/money/bank"
+	"github.com/jansemmelink/money/output"
+)
+
+func cmdTags(args []string) error {
+	return runCommand("money tags", args, []command{
+		{name: "list", args: "", summary: "List tags with the nr of transactions", run: cmdTagsList},
+		{name: "delete", args: "<tag>", summary: "Remove a tag from all transactions", run: cmdTagsDelete},
+	})
+}
+
+func cmdTagsList(args []string) error {
+	flags := newFlags("money tags list", "")
+	format := flags.output()
+	if err := flags.parse(args, 0); err != nil {
+		return err
+	}
+	if err := connect(); err != nil {
+		return err
+	}
+	list, err := bank.GetTags()
+	if err != nil {
+		return err
+	}
+	t := output.New("Tag", "Transactions")
+	for _, tag := range list {
+		t.Row(tag.Name, tag.NrTransactions)
+	}
+	return write(t, *format)
+}
+
+func cmdTagsDelete(args []string) error {
+	flags := newFlags("money tags delete", "<tag>")
+	if err := flags.parse(args, 1); err != nil {
+		return err
+	}
+	if err := connect(); err != nil {
+		return err
+	}
+	if err := bank.DeleteTag(flags.Arg(0)); err != nil {
+		return err
+	}
+	fmt.Printf("Deleted tag %s\n", flags.Arg(0))
+	return nil
+}
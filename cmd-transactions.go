@@ -0,0 +1,327 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/go-msvc/errors"
+	"github.com/jansemmelink/money/bank"
+	"github.com/jansemmelink/money/output"
+)
+
+func cmdTransactions(args []string) error {
+	return runCommand("money transactions", args, []command{
+		{name: "list", args: "[-account a] [-from d] [-to d] [-tag t] [-search q] ... [expression]", summary: "List transactions", run: cmdTransactionsList},
+		{name: "edit", args: "[-account a] [-notes n] <id>", summary: "Change the counter account and/or notes", run: cmdTransactionsEdit},
+		{name: "tag", args: "<id> <tag> ...", summary: "Add tags to a transaction", run: cmdTransactionsTag},
+		{name: "untag", args: "<id> <tag>", summary: "Remove a tag from a transaction", run: cmdTransactionsUntag},
+		{name: "show", args: "<id>", summary: "Show a transaction with its postings", run: cmdTransactionsShow},
+		{name: "split", args: "<id> <account>=<amount>[:notes] ...", summary: "Split the counter side over several accounts", run: cmdTransactionsSplit},
+		{name: "suggest", args: "[-n 3] <id>", summary: "Suggest counter accounts learned from categorised transactions", run: cmdTransactionsSuggest},
+	})
+}
+
+func cmdTransactionsList(args []string) error {
+	flags := newFlags("money transactions list", "[-account a] [-statement id] [-from CCYY-MM-DD] [-to CCYY-MM-DD] [-text t] [-tag t] [-search q] [-limit n] [expression]\n"+
+		"  expression e.g. 'amount < -500 and details ~ \"sasol\" and date >= 2021-01-01 and account = \"Groceries\"'\n"+
+		"  with fields "+strings.Join(bank.TransactionFields.Names(), ", ")+",\n"+
+		"  operators = != < <= > >= ~ (contains) !~, and, or, not and (...)")
+	account := flags.String("account", "", "Account name or id")
+	statementID := flags.String("statement", "", "Statement id")
+	from := flags.String("from", "", "First date CCYY-MM-DD")
+	to := flags.String("to", "", "Last date CCYY-MM-DD")
+	text := flags.String("text", "", "Part of statement details or notes")
+	tag := flags.String("tag", "", "Only transactions with this tag")
+	search := flags.String("search", "", "Words, prefixes (word*) and \"phrases\" in statement details, notes or tags")
+	limit := flags.Int("limit", 0, "Max nr of transactions to list (0 for all)")
+	format := flags.output()
+	if err := flags.parse(args, -1); err != nil {
+		return err
+	}
+	filter := bank.TransactionFilter{
+		StatementID: *statementID,
+		Details:     *text,
+		Tag:         *tag,
+		Search:      *search,
+		Query:       strings.Join(flags.Args(), " "),
+		Limit:       *limit,
+	}
+	var err error
+	if filter.From, err = parseDate(*from, false); err != nil {
+		return err
+	}
+	if filter.To, err = parseDate(*to, true); err != nil {
+		return err
+	}
+	if err := connect(); err != nil {
+		return err
+	}
+	if *account != "" {
+		acc, err := findAccount(*account)
+		if err != nil {
+			return err
+		}
+		filter.AccountID = acc.ID
+	}
+	txList, err := bank.GetTransactions(filter)
+	if err != nil {
+		return err
+	}
+	t := newTransactionTable()
+	for _, tx := range txList {
+		addTransactionRow(t, tx)
+	}
+	return write(t, *format)
+} //cmdTransactionsList()
+
+func cmdTransactionsEdit(args []string) error {
+	flags := newFlags("money transactions edit", "[-account a] [-notes n] <id>")
+	account := flags.String("account", "", "New counter account name or id")
+	notes := flags.String("notes", "", "New notes (use \"-\" to clear)")
+	format := flags.output()
+	if err := flags.parse(args, 1); err != nil {
+		return err
+	}
+	if *account == "" && *notes == "" {
+		return usagef("nothing to change, specify -account and/or -notes\n%s", flags.helpText())
+	}
+	if err := connect(); err != nil {
+		return err
+	}
+	tx, err := getTransaction(flags.Arg(0))
+	if err != nil {
+		return err
+	}
+	if *account != "" {
+		acc, err := findAccount(*account)
+		if err != nil {
+			return err
+		}
+		tx.SetCounterAccount(*acc)
+	}
+	switch *notes {
+	case "":
+	case "-":
+		tx.Notes = ""
+	default:
+		tx.Notes = *notes
+	}
+	if err := tx.Save(); err != nil {
+		return err
+	}
+	return write(addTransactionRow(newTransactionTable(), *tx), *format)
+} //cmdTransactionsEdit()
+
+func cmdTransactionsTag(args []string) error {
+	flags := newFlags("money transactions tag", "<id> <tag> ...")
+	format := flags.output()
+	if err := flags.parse(args, -1); err != nil {
+		return err
+	}
+	if flags.NArg() < 2 {
+		return usagef("expects transaction id and tags\n%s", flags.helpText())
+	}
+	for _, tag := range flags.Args()[1:] {
+		if _, err := bank.ParseTag(tag); err != nil {
+			return usagef("%s\n%s", err.Error(), flags.helpText())
+		}
+	}
+	if err := connect(); err != nil {
+		return err
+	}
+	if err := bank.TagTransaction(flags.Arg(0), flags.Args()[1:]...); err != nil {
+		return err
+	}
+	tx, err := getTransaction(flags.Arg(0))
+	if err != nil {
+		return err
+	}
+	return write(addTransactionRow(newTransactionTable(), *tx), *format)
+}
+
+func cmdTransactionsUntag(args []string) error {
+	flags := newFlags("money transactions untag", "<id> <tag>")
+	format := flags.output()
+	if err := flags.parse(args, 2); err != nil {
+		return err
+	}
+	if err := connect(); err != nil {
+		return err
+	}
+	if err := bank.UntagTransaction(flags.Arg(0), flags.Arg(1)); err != nil {
+		return err
+	}
+	tx, err := getTransaction(flags.Arg(0))
+	if err != nil {
+		return err
+	}
+	return write(addTransactionRow(newTransactionTable(), *tx), *format)
+}
+
+func cmdTransactionsSuggest(args []string) error {
+	flags := newFlags("money transactions suggest", "[-n 3] <id>")
+	n := flags.Int("n", 3, "Nr of suggestions")
+	format := flags.output()
+	if err := flags.parse(args, 1); err != nil {
+		return err
+	}
+	if err := connect(); err != nil {
+		return err
+	}
+	tx, err := getTransaction(flags.Arg(0))
+	if err != nil {
+		return err
+	}
+	suggester, err := bank.TrainSuggester()
+	if err != nil {
+		return err
+	}
+	return write(newSuggestionTable(suggester.Suggest(tx.StatementTransaction(), *n)), *format)
+}
+
+func newSuggestionTable(list []bank.AccountSuggestion) *output.Table {
+	t := output.New("Account", "Type", "Confidence")
+	for _, s := range list {
+		t.Row(s.Account.Name, s.Account.Type, fmt.Sprintf("%.2f", s.Confidence))
+	}
+	return t
+}
+
+func cmdTransactionsShow(args []string) error {
+	flags := newFlags("money transactions show", "<id>")
+	format := flags.output()
+	if err := flags.parse(args, 1); err != nil {
+		return err
+	}
+	if err := connect(); err != nil {
+		return err
+	}
+	tx, err := getTransaction(flags.Arg(0))
+	if err != nil {
+		return err
+	}
+	if *format == output.FormatTable {
+		if err := write(addTransactionRow(newTransactionTable(), *tx), *format); err != nil {
+			return err
+		}
+		fmt.Println()
+	}
+	postings, err := bank.GetPostings(tx.ID)
+	if err != nil {
+		return err
+	}
+	t := output.New("Account", "Debit", "Credit", "Notes")
+	for _, p := range postings {
+		if p.Amount.MilliCents() > 0 {
+			t.Row(p.AccountName, p.Amount, "", p.Notes)
+		} else {
+			t.Row(p.AccountName, "", p.Amount.Neg(), p.Notes)
+		}
+	}
+	if err := write(t, *format); err != nil {
+		return err
+	}
+	if *format == output.FormatTable {
+		docs, err := bank.GetDocuments(tx.ID)
+		if err != nil {
+			return err
+		}
+		if len(docs) > 0 {
+			fmt.Println()
+			t := newDocumentTable()
+			for _, d := range docs {
+				addDocumentRow(t, d)
+			}
+			return write(t, *format)
+		}
+	}
+	return nil
+} //cmdTransactionsShow()
+
+func cmdTransactionsSplit(args []string) error {
+	flags := newFlags("money transactions split", "<id> <account>=<amount>[:notes] ...")
+	if err := flags.parse(args, -1); err != nil {
+		return err
+	}
+	if flags.NArg() < 2 {
+		return usagef("expects transaction id and at least one split\n%s", flags.helpText())
+	}
+	if err := connect(); err != nil {
+		return err
+	}
+	tx, err := getTransaction(flags.Arg(0))
+	if err != nil {
+		return err
+	}
+	splits := []bank.Split{}
+	for _, arg := range flags.Args()[1:] {
+		eq := strings.LastIndex(arg, "=")
+		if eq < 1 {
+			return usagef("invalid split \"%s\" expects <account>=<amount>[:notes]", arg)
+		}
+		amountAndNotes := strings.SplitN(arg[eq+1:], ":", 2)
+		amount, err := bank.NewAmount(amountAndNotes[0])
+		if err != nil {
+			return usagef("invalid split amount \"%s\": %s", amountAndNotes[0], err.Error())
+		}
+		acc, err := findAccount(arg[:eq])
+		if err != nil {
+			return err
+		}
+		split := bank.Split{AccountID: acc.ID, Amount: amount.Abs()}
+		if len(amountAndNotes) > 1 {
+			split.Notes = amountAndNotes[1]
+		}
+		splits = append(splits, split)
+	}
+	if err := bank.SplitTransaction(tx.ID, splits); err != nil {
+		return err
+	}
+	return cmdTransactionsShow([]string{tx.ID})
+} //cmdTransactionsSplit()
+
+func getTransaction(id string) (*bank.TransactionRecord, error) {
+	tx, err := bank.GetTransaction(id)
+	if err != nil {
+		return nil, err
+	}
+	if tx == nil {
+		return nil, errors.Errorf("transaction \"%s\" not found", id)
+	}
+	return tx, nil
+}
+
+func newTransactionTable() *output.Table {
+	return output.New("ID", "Date", "Amount", "Debit", "Credit", "Details", "Notes", "Tags")
+}
+
+func addTransactionRow(t *output.Table, tx bank.TransactionRecord) *output.Table {
+	dt, ct := tx.DtAccountName, tx.CtAccountName
+	if tx.IsSplit() {
+		//counter side is only in the postings
+		if dt == "" {
+			dt = "(split)"
+		}
+		if ct == "" {
+			ct = "(split)"
+		}
+	}
+	return t.Row(tx.ID, tx.Date.Date(), tx.Amount, dt, ct, tx.StatementDetails, tx.Notes, strings.Join(tx.Tags, " "))
+}
+
+//parseDate parses "CCYY-MM-DD" in local time, with endOfDay to include the whole day.
+//Empty string returns zero time.
+func parseDate(s string, endOfDay bool) (time.Time, error) {
+	if s == "" {
+		return time.Time{}, nil
+	}
+	t, err := time.ParseInLocation("2006-01-02", s, time.Local)
+	if err != nil {
+		return time.Time{}, usagef("invalid date \"%s\" expects CCYY-MM-DD", s)
+	}
+	if endOfDay {
+		t = t.Add(24*time.Hour - time.Second)
+	}
+	return t, nil
+}
@@ -0,0 +1,133 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/jansemmelink/money/bank"
+	"github.com/jansemmelink/money/output"
+)
+
+func cmdTransfers(args []string) error {
+	return runCommand("money transfers", args, []command{
+		{name: "detect", args: "[-days 3]", summary: "List likely transfers between own bank accounts", run: cmdTransfersDetect},
+		{name: "apply", args: "[-days 3] [-y]", summary: "Review and collapse detected transfers", run: cmdTransfersApply},
+		{name: "list", args: "", summary: "List collapsed transfers", run: cmdTransfersList},
+		{name: "undo", args: "<id>", summary: "Restore both transactions of a collapsed transfer", run: cmdTransfersUndo},
+	})
+}
+
+func cmdTransfersDetect(args []string) error {
+	flags := newFlags("money transfers detect", "[-days 3]")
+	days := flags.Int("days", 3, "Max nr of days between the two transactions")
+	format := flags.output()
+	if err := flags.parse(args, 0); err != nil {
+		return err
+	}
+	if err := connect(); err != nil {
+		return err
+	}
+	list, err := bank.FindTransfers(*days)
+	if err != nil {
+		return err
+	}
+	t := newTransferCandidateTable()
+	for _, c := range list {
+		addTransferCandidateRow(t, c)
+	}
+	return write(t, *format)
+}
+
+func cmdTransfersApply(args []string) error {
+	flags := newFlags("money transfers apply", "[-days 3] [-y]")
+	days := flags.Int("days", 3, "Max nr of days between the two transactions")
+	yes := flags.Bool("y", false, "Collapse all without prompt")
+	if err := flags.parse(args, 0); err != nil {
+		return err
+	}
+	if err := connect(); err != nil {
+		return err
+	}
+	list, err := bank.FindTransfers(*days)
+	if err != nil {
+		return err
+	}
+	in := bufio.NewReader(os.Stdin)
+	all := *yes
+	nrCollapsed := 0
+	for _, c := range list {
+		if !all {
+			if err := write(addTransferCandidateRow(newTransferCandidateTable(), c), output.FormatTable); err != nil {
+				return err
+			}
+			fmt.Printf("Collapse into one transfer (y/n/a=all/q)[n] ?")
+			answer, err := in.ReadString('\n')
+			if err != nil && err != io.EOF {
+				return err
+			}
+			answer = strings.ToLower(strings.TrimSpace(answer))
+			if answer == "q" || (err == io.EOF && answer == "") {
+				break
+			}
+			if answer == "a" {
+				all = true
+			} else if answer != "y" {
+				continue
+			}
+		}
+		transfer, err := bank.CollapseTransfer(c)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Collapsed as transfer %s\n", transfer.ID)
+		nrCollapsed++
+	}
+	fmt.Printf("Collapsed %d of %d detected transfers\n", nrCollapsed, len(list))
+	return nil
+} //cmdTransfersApply()
+
+func cmdTransfersList(args []string) error {
+	flags := newFlags("money transfers list", "")
+	format := flags.output()
+	if err := flags.parse(args, 0); err != nil {
+		return err
+	}
+	if err := connect(); err != nil {
+		return err
+	}
+	list, err := bank.GetTransfers()
+	if err != nil {
+		return err
+	}
+	t := output.New("ID", "Date", "Amount", "Out Transaction", "In Details", "Created")
+	for _, tr := range list {
+		t.Row(tr.ID, tr.InDate.Date(), tr.InAmount, tr.OutTransactionID, tr.InStatementDetails, tr.CreatedAt)
+	}
+	return write(t, *format)
+}
+
+func cmdTransfersUndo(args []string) error {
+	flags := newFlags("money transfers undo", "<id>")
+	if err := flags.parse(args, 1); err != nil {
+		return err
+	}
+	if err := connect(); err != nil {
+		return err
+	}
+	if err := bank.UndoTransfer(flags.Arg(0)); err != nil {
+		return err
+	}
+	fmt.Printf("Restored transfer %s\n", flags.Arg(0))
+	return nil
+}
+
+func newTransferCandidateTable() *output.Table {
+	return output.New("Out Date", "From", "In Date", "To", "Amount", "Days", "Out Details", "In Details")
+}
+
+func addTransferCandidateRow(t *output.Table, c bank.TransferCandidate) *output.Table {
+	return t.Row(c.Out.Date.Date(), c.Out.CtAccountName, c.In.Date.Date(), c.In.DtAccountName, c.In.Amount, c.Days, c.Out.StatementDetails, c.In.StatementDetails)
+}
@@ -5,6 +5,7 @@ import (
 	"database/sql"
 	"fmt"
 	"os"
+	"sort"
 	"strconv"
 	"strings"
 	"sync"
@@ -25,6 +26,14 @@ var (
 
 func init() {
 	sql.Register("mysqlwithlog", sqlhooks.Wrap(&mysql.MySQLDriver{}, Hooks{}))
+}
+
+//Connect creates the pool of database connections using DB_* environment
+//variables for the config. It must be called before Db() is used.
+func Connect() error {
+	if db != nil {
+		return nil //already connected
+	}
 
 	c := Config{
 		Host:           os.Getenv("DB_HOST"),
@@ -38,7 +47,7 @@ func init() {
 	}
 
 	if err := c.Validate(); err != nil {
-		panic(errors.Wrapf(err, "invalid database config"))
+		return errors.Wrapf(err, "invalid database config")
 	}
 
 	//connect to the database to create the pool of connections
@@ -55,20 +64,19 @@ func init() {
 	select {
 	case connResult := <-connResultChan:
 		if connResult.err != nil {
-			panic(errors.Wrapf(connResult.err, "failed to connect to database %s on %s:%d", c.Database, c.Host, c.Port))
+			return errors.Wrapf(connResult.err, "failed to connect to database %s on %s:%d", c.Database, c.Host, c.Port)
 		}
 
 		db = connResult.db
 		db.SetMaxOpenConns(c.MaxConnOpen)
 		db.SetMaxIdleConns(c.MaxConnIdle)
-
-		return
+		return nil
 
 	case <-time.After(time.Duration(c.MaxConnSeconds) * time.Second):
-		panic(errors.Errorf("%d second timeout connecting to db %s on %s:%d", c.MaxConnSeconds, c.Database, c.Host, c.Port))
+		return errors.Errorf("%d second timeout connecting to db %s on %s:%d", c.MaxConnSeconds, c.Database, c.Host, c.Port)
 
 	} //select
-}
+} //Connect()
 
 func intDefault(s string, def int) int {
 	if i64, err := strconv.ParseInt(s, 10, 64); err != nil {
@@ -222,13 +230,24 @@ func (h Hooks) After(ctx context.Context, query string, args ...interface{}) (co
 	return ctx, nil
 }
 
+//FilteredSelect selects rows where each named column equals the filter value,
+//or contains it when the value is a string starting with "*", e.g. "*sasol".
+//Names are columns from code, never from user input, and values are args.
 func FilteredSelect(list interface{}, selectSQL string, filter map[string]interface{}, limit int) error {
+	//sorted for the same query text (compiled once) with the same filter names
+	names := []string{}
+	for n := range filter {
+		names = append(names, n)
+	}
+	sort.Strings(names)
 	filterQuery := []string{}
 	filterArgs := map[string]interface{}{}
-	for n, v := range filter {
-		log.Debugf("filter(%s)=\"%s\"", n, v)
+	for _, n := range names {
+		v := filter[n]
+		log.Debugf("filter(%s)=\"%v\"", n, v)
 		if s, ok := v.(string); ok && strings.HasPrefix(s, "*") {
-			filterQuery = append(filterQuery, fmt.Sprintf("%s like %%:%s%%", n, n))
+			filterQuery = append(filterQuery, fmt.Sprintf("%s like :%s", n, n))
+			v = "%" + LikeEscape(strings.TrimPrefix(s, "*")) + "%"
 		} else {
 			filterQuery = append(filterQuery, fmt.Sprintf("%s=:%s", n, n))
 		}
@@ -246,3 +265,8 @@ func FilteredSelect(list interface{}, selectSQL string, filter map[string]interf
 	query += fmt.Sprintf(" limit %d", limit)
 	return NamedSelect(list, query, filterArgs)
 } //FilteredSelect()
+
+//LikeEscape escapes the LIKE wildcards in s to match it literally
+func LikeEscape(s string) string {
+	return strings.NewReplacer("\\", "\\\\", "%", "\\%", "_", "\\_").Replace(s)
+}
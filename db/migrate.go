@@ -0,0 +1,107 @@
+package db
+
+import (
+	"embed"
+	"sort"
+	"strings"
+
+	"github.com/go-msvc/errors"
+)
+
+//go:embed migrations/*.sql
+var migrationFiles embed.FS
+
+type Migration struct {
+	Version string
+	Applied bool
+}
+
+//Migrations lists all embedded migrations in order and indicates which
+//of them are already applied to the database
+func Migrations() ([]Migration, error) {
+	entries, err := migrationFiles.ReadDir("migrations")
+	if err != nil {
+		return nil, errors.Wrapf(err, "cannot read migrations")
+	}
+	applied, err := appliedMigrations()
+	if err != nil {
+		return nil, errors.Wrapf(err, "cannot get applied migrations")
+	}
+	list := []Migration{}
+	for _, e := range entries {
+		version := strings.TrimSuffix(e.Name(), ".sql")
+		list = append(list, Migration{Version: version, Applied: applied[version]})
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Version < list[j].Version })
+	return list, nil
+}
+
+//Migrate applies all outstanding migrations in order
+//and returns the list of versions that were applied
+func Migrate() ([]string, error) {
+	list, err := Migrations()
+	if err != nil {
+		return nil, err
+	}
+	done := []string{}
+	for _, m := range list {
+		if m.Applied {
+			continue
+		}
+		script, err := migrationFiles.ReadFile("migrations/" + m.Version + ".sql")
+		if err != nil {
+			return done, errors.Wrapf(err, "cannot read migration(%s)", m.Version)
+		}
+		for i, stmt := range splitStatements(string(script)) {
+			if _, err := db.Exec(stmt); err != nil {
+				return done, errors.Wrapf(err, "migration(%s) statement[%d] failed", m.Version, i)
+			}
+		}
+		if _, err := db.Exec("INSERT INTO `schema_migrations` SET version=?", m.Version); err != nil {
+			return done, errors.Wrapf(err, "failed to record migration(%s)", m.Version)
+		}
+		log.Infof("Applied migration(%s)", m.Version)
+		done = append(done, m.Version)
+	}
+	return done, nil
+} //Migrate()
+
+func appliedMigrations() (map[string]bool, error) {
+	if _, err := db.Exec("CREATE TABLE IF NOT EXISTS `schema_migrations` (" +
+		"`version` VARCHAR(100) NOT NULL," +
+		"`applied_at` DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP," +
+		"UNIQUE KEY `schema_migration_version` (`version`)" +
+		") ENGINE=InnoDB DEFAULT CHARSET=utf8mb3"); err != nil {
+		return nil, errors.Wrapf(err, "failed to create schema_migrations")
+	}
+	var versions []string
+	if err := db.Select(&versions, "SELECT version FROM `schema_migrations`"); err != nil {
+		return nil, errors.Wrapf(err, "failed to select schema_migrations")
+	}
+	applied := map[string]bool{}
+	for _, v := range versions {
+		applied[v] = true
+	}
+	return applied, nil
+}
+
+//split a script into statements terminated by ';' at the end of a line
+func splitStatements(script string) []string {
+	list := []string{}
+	stmt := ""
+	for _, line := range strings.Split(script, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" || strings.HasPrefix(trimmed, "--") {
+			continue
+		}
+		stmt += line + "\n"
+		if strings.HasSuffix(trimmed, ";") {
+			list = append(list, strings.TrimSuffix(strings.TrimSpace(stmt), ";"))
+			stmt = ""
+		}
+	}
+	if strings.TrimSpace(stmt) != "" {
+		list = append(list, strings.TrimSpace(stmt))
+	}
+	return list
+}
@@ -0,0 +1,20 @@
+CREATE TABLE IF NOT EXISTS `rules` (
+  `id` VARCHAR(40) DEFAULT (uuid()) NOT NULL,
+  `name` VARCHAR(100) NOT NULL,
+  `priority` INT NOT NULL DEFAULT 0,
+  `bank_account_id` VARCHAR(40) DEFAULT NULL,
+  `statement_type` VARCHAR(200) DEFAULT NULL,
+  `statement_code` VARCHAR(200) DEFAULT NULL,
+  `details_contains` VARCHAR(200) DEFAULT NULL,
+  `details_regex` VARCHAR(200) DEFAULT NULL,
+  `sign` VARCHAR(1) DEFAULT NULL,
+  `min_amount` VARCHAR(20) DEFAULT NULL,
+  `max_amount` VARCHAR(20) DEFAULT NULL,
+  `account_id` VARCHAR(40) NOT NULL,
+  `notes` VARCHAR(200) DEFAULT NULL,
+  UNIQUE KEY `rule_id` (`id`),
+  UNIQUE KEY `rule_name` (`name`),
+  KEY `rule_priority` (`priority`,`name`),
+  FOREIGN KEY (`bank_account_id`) REFERENCES `bank_accounts`(`id`),
+  FOREIGN KEY (`account_id`) REFERENCES `accounts`(`id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb3;
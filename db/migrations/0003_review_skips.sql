@@ -0,0 +1,6 @@
+CREATE TABLE IF NOT EXISTS `review_skips` (
+  `transaction_id` VARCHAR(40) NOT NULL,
+  `skipped_at` DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  UNIQUE KEY `review_skip_transaction` (`transaction_id`),
+  FOREIGN KEY (`transaction_id`) REFERENCES `transactions`(`id`) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb3;
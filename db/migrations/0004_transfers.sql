@@ -0,0 +1,19 @@
+-- a collapsed transfer keeps the incoming transaction so that its statement still adds up.
+-- It is marked with the transfer and has no postings, as the outgoing transaction
+-- then debits the receiving bank account.
+CREATE TABLE IF NOT EXISTS `transfers` (
+  `id` VARCHAR(40) DEFAULT (uuid()) NOT NULL,
+  `created_at` DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  `out_transaction_id` VARCHAR(40) NOT NULL,
+  `out_dt_account_id` VARCHAR(40) NOT NULL,
+  `in_transaction_id` VARCHAR(40) NOT NULL,
+  UNIQUE KEY `transfer_id` (`id`),
+  UNIQUE KEY `transfer_out` (`out_transaction_id`),
+  UNIQUE KEY `transfer_in` (`in_transaction_id`),
+  FOREIGN KEY (`out_transaction_id`) REFERENCES `transactions`(`id`),
+  FOREIGN KEY (`in_transaction_id`) REFERENCES `transactions`(`id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb3;
+
+ALTER TABLE `transactions`
+  ADD COLUMN `transfer_id` VARCHAR(40) DEFAULT NULL,
+  ADD KEY `transaction_transfer` (`transfer_id`);
@@ -0,0 +1,24 @@
+-- journal model: each transaction has N postings that sum to zero
+-- with debits as positive and credits as negative amounts.
+-- transactions.dt_account_id/ct_account_id are kept for entries with two postings
+-- and are NULL on the counter side of split transactions.
+CREATE TABLE IF NOT EXISTS `postings` (
+  `id` VARCHAR(40) DEFAULT (uuid()) NOT NULL,
+  `transaction_id` VARCHAR(40) NOT NULL,
+  `account_id` VARCHAR(40) NOT NULL,
+  `amount` VARCHAR(100) NOT NULL,
+  `notes` VARCHAR(200) DEFAULT NULL,
+  UNIQUE KEY `posting_id` (`id`),
+  KEY `posting_transaction` (`transaction_id`),
+  KEY `posting_account` (`account_id`),
+  FOREIGN KEY (`transaction_id`) REFERENCES `transactions`(`id`) ON DELETE CASCADE,
+  FOREIGN KEY (`account_id`) REFERENCES `accounts`(`id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb3;
+
+INSERT INTO `postings` (`id`,`transaction_id`,`account_id`,`amount`)
+  SELECT uuid(), `id`, `dt_account_id`, TRIM(LEADING '-' FROM `amount`)
+  FROM `transactions` WHERE `dt_account_id` IS NOT NULL;
+
+INSERT INTO `postings` (`id`,`transaction_id`,`account_id`,`amount`)
+  SELECT uuid(), `id`, `ct_account_id`, CONCAT('-', TRIM(LEADING '-' FROM `amount`))
+  FROM `transactions` WHERE `ct_account_id` IS NOT NULL;
@@ -0,0 +1,4 @@
+ALTER TABLE `accounts`
+  ADD COLUMN `parent_id` VARCHAR(40) DEFAULT NULL,
+  ADD KEY `account_parent` (`parent_id`),
+  ADD FOREIGN KEY (`parent_id`) REFERENCES `accounts`(`id`);
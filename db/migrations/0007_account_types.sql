@@ -0,0 +1,10 @@
+-- map free-text account types to the five standard types
+UPDATE `accounts` SET `type`=LOWER(TRIM(`type`));
+UPDATE `accounts` SET `type`='asset' WHERE `type` IN ('assets','bank','cash','cheque','savings','investment');
+UPDATE `accounts` SET `type`='liability' WHERE `type` IN ('liabilities','credit','credit card','creditcard','loan','debt');
+UPDATE `accounts` SET `type`='equity' WHERE `type` IN ('capital','opening balance','opening balances');
+UPDATE `accounts` SET `type`='income' WHERE `type` IN ('revenue','salary','other income');
+UPDATE `accounts` SET `type`='expense' WHERE `type` NOT IN ('asset','liability','equity','income');
+
+ALTER TABLE `accounts`
+  ADD CONSTRAINT `account_type` CHECK (`type` IN ('asset','liability','equity','income','expense'));
@@ -0,0 +1,4 @@
+-- journal entry that posts the opening balance of the first statement
+ALTER TABLE `bank_accounts`
+  ADD COLUMN `opening_transaction_id` VARCHAR(40) DEFAULT NULL,
+  ADD FOREIGN KEY (`opening_transaction_id`) REFERENCES `transactions`(`id`) ON DELETE SET NULL;
@@ -0,0 +1,11 @@
+CREATE TABLE IF NOT EXISTS `budgets` (
+  `id` VARCHAR(40) DEFAULT (uuid()) NOT NULL,
+  `account_id` VARCHAR(40) NOT NULL,
+  `month` CHAR(7) NOT NULL,
+  `amount` VARCHAR(100) NOT NULL,
+  `carry_over` BOOLEAN NOT NULL DEFAULT FALSE,
+  UNIQUE KEY `budget_id` (`id`),
+  UNIQUE KEY `budget_account_month` (`account_id`,`month`),
+  KEY `budget_month` (`month`),
+  FOREIGN KEY (`account_id`) REFERENCES `accounts`(`id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb3;
@@ -0,0 +1,24 @@
+-- envelopes hold money allocated for spending on an expense account and its sub accounts
+CREATE TABLE IF NOT EXISTS `envelopes` (
+  `id` VARCHAR(40) DEFAULT (uuid()) NOT NULL,
+  `name` VARCHAR(100) NOT NULL,
+  `account_id` VARCHAR(40) NOT NULL,
+  UNIQUE KEY `envelope_id` (`id`),
+  UNIQUE KEY `envelope_name` (`name`),
+  UNIQUE KEY `envelope_account` (`account_id`),
+  FOREIGN KEY (`account_id`) REFERENCES `accounts`(`id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb3;
+
+-- money moved between envelopes, where NULL is "to be budgeted"
+CREATE TABLE IF NOT EXISTS `envelope_moves` (
+  `id` VARCHAR(40) DEFAULT (uuid()) NOT NULL,
+  `date` DATETIME NOT NULL,
+  `from_envelope_id` VARCHAR(40) DEFAULT NULL,
+  `to_envelope_id` VARCHAR(40) DEFAULT NULL,
+  `amount` VARCHAR(100) NOT NULL,
+  `notes` VARCHAR(200) DEFAULT NULL,
+  UNIQUE KEY `envelope_move_id` (`id`),
+  KEY `envelope_move_date` (`date`),
+  FOREIGN KEY (`from_envelope_id`) REFERENCES `envelopes`(`id`),
+  FOREIGN KEY (`to_envelope_id`) REFERENCES `envelopes`(`id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb3;
@@ -0,0 +1,33 @@
+-- expected payments entered ahead of time
+CREATE TABLE IF NOT EXISTS `scheduled_transactions` (
+  `id` VARCHAR(40) DEFAULT (uuid()) NOT NULL,
+  `name` VARCHAR(100) NOT NULL,
+  `bank_account_id` VARCHAR(40) NOT NULL,
+  `account_id` VARCHAR(40) NOT NULL,
+  `amount` VARCHAR(100) NOT NULL,
+  `details_contains` VARCHAR(200) DEFAULT NULL,
+  `start_date` DATETIME NOT NULL,
+  `end_date` DATETIME DEFAULT NULL,
+  `recurrence` VARCHAR(20) NOT NULL,
+  `every` INT NOT NULL DEFAULT 1,
+  `days_tolerance` INT NOT NULL DEFAULT 5,
+  `amount_tolerance` INT NOT NULL DEFAULT 10,
+  `notes` VARCHAR(200) DEFAULT NULL,
+  UNIQUE KEY `scheduled_transaction_id` (`id`),
+  UNIQUE KEY `scheduled_transaction_name` (`name`),
+  FOREIGN KEY (`bank_account_id`) REFERENCES `bank_accounts`(`id`),
+  FOREIGN KEY (`account_id`) REFERENCES `accounts`(`id`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb3;
+
+-- bank transactions that fulfilled a scheduled transaction on its due date
+CREATE TABLE IF NOT EXISTS `scheduled_matches` (
+  `id` VARCHAR(40) DEFAULT (uuid()) NOT NULL,
+  `scheduled_id` VARCHAR(40) NOT NULL,
+  `due_date` DATETIME NOT NULL,
+  `transaction_id` VARCHAR(40) NOT NULL,
+  UNIQUE KEY `scheduled_match_id` (`id`),
+  UNIQUE KEY `scheduled_match_due` (`scheduled_id`,`due_date`),
+  UNIQUE KEY `scheduled_match_transaction` (`transaction_id`),
+  FOREIGN KEY (`scheduled_id`) REFERENCES `scheduled_transactions`(`id`) ON DELETE CASCADE,
+  FOREIGN KEY (`transaction_id`) REFERENCES `transactions`(`id`) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb3;
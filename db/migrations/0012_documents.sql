@@ -0,0 +1,21 @@
+-- files (e.g. scanned receipts) stored on disk by the sha256 hash of their content
+CREATE TABLE IF NOT EXISTS `documents` (
+  `id` VARCHAR(40) DEFAULT (uuid()) NOT NULL,
+  `hash` VARCHAR(64) NOT NULL,
+  `name` VARCHAR(200) NOT NULL,
+  `mime_type` VARCHAR(100) NOT NULL,
+  `size` BIGINT NOT NULL,
+  `uploaded` DATETIME NOT NULL,
+  UNIQUE KEY `document_id` (`id`),
+  UNIQUE KEY `document_hash` (`hash`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb3;
+
+-- a transaction may have several documents and a document may be linked to several transactions
+CREATE TABLE IF NOT EXISTS `transaction_documents` (
+  `transaction_id` VARCHAR(40) NOT NULL,
+  `document_id` VARCHAR(40) NOT NULL,
+  PRIMARY KEY (`transaction_id`,`document_id`),
+  KEY `transaction_document_document` (`document_id`),
+  FOREIGN KEY (`transaction_id`) REFERENCES `transactions`(`id`) ON DELETE CASCADE,
+  FOREIGN KEY (`document_id`) REFERENCES `documents`(`id`) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb3;
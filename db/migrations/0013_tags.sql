@@ -0,0 +1,19 @@
+-- tags like "holiday-2021" or "tax-deductible" on transactions
+CREATE TABLE IF NOT EXISTS `tags` (
+  `id` VARCHAR(40) DEFAULT (uuid()) NOT NULL,
+  `name` VARCHAR(100) NOT NULL,
+  UNIQUE KEY `tag_id` (`id`),
+  UNIQUE KEY `tag_name` (`name`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb3;
+
+CREATE TABLE IF NOT EXISTS `transaction_tags` (
+  `transaction_id` VARCHAR(40) NOT NULL,
+  `tag_id` VARCHAR(40) NOT NULL,
+  PRIMARY KEY (`transaction_id`,`tag_id`),
+  KEY `transaction_tag_tag` (`tag_id`),
+  FOREIGN KEY (`transaction_id`) REFERENCES `transactions`(`id`) ON DELETE CASCADE,
+  FOREIGN KEY (`tag_id`) REFERENCES `tags`(`id`) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb3;
+
+-- full-text search over statement details and notes
+ALTER TABLE `transactions` ADD FULLTEXT INDEX IF NOT EXISTS `transaction_text` (`statement_details`,`notes`);
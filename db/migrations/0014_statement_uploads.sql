@@ -0,0 +1,10 @@
+-- statement files uploaded over the api, kept until the import is confirmed
+CREATE TABLE IF NOT EXISTS `statement_uploads` (
+  `id` VARCHAR(40) DEFAULT (uuid()) NOT NULL,
+  `name` VARCHAR(200) NOT NULL,
+  `format` VARCHAR(100) NOT NULL,
+  `content` MEDIUMBLOB NOT NULL,
+  `uploaded` DATETIME NOT NULL,
+  UNIQUE KEY `statement_upload_id` (`id`),
+  KEY `statement_upload_uploaded` (`uploaded`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb3;
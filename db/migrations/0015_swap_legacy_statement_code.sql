@@ -0,0 +1,12 @@
+-- transactions imported before the fix of ImportToDb have the statement details in
+-- statement_code and the code (which may be empty) in statement_details. Every
+-- statement row that exists when this runs was imported that way, as imports fail
+-- until the migrations are applied, so all of them are swapped back.
+-- The swap column holds the old code because SET assignments are applied left to right.
+ALTER TABLE `transactions` ADD COLUMN `swap` VARCHAR(200) DEFAULT NULL;
+
+UPDATE `transactions`
+  SET `swap`=`statement_code`, `statement_code`=`statement_details`, `statement_details`=`swap`
+  WHERE `statement_id` IS NOT NULL;
+
+ALTER TABLE `transactions` DROP COLUMN `swap`;
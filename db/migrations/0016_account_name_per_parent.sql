@@ -0,0 +1,9 @@
+-- account names are unique under the same parent only, e.g. Expenses:Fuel and Business:Fuel.
+-- Root accounts have parent_key '' because NULL parent_id values are never equal in a unique key.
+-- Names may not contain the path separator ':', which bank accounts used to be named with.
+UPDATE `accounts` SET `name`=REPLACE(`name`,':',' ') WHERE `name` LIKE '%:%';
+
+ALTER TABLE `accounts`
+  DROP KEY `account_name`,
+  ADD COLUMN `parent_key` VARCHAR(40) AS (IFNULL(`parent_id`,'')) PERSISTENT,
+  ADD UNIQUE KEY `account_parent_name` (`parent_key`,`name`);
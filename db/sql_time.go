@@ -48,3 +48,8 @@ func (t SqlTime) MarshalJSON() ([]byte, error) {
 	s := fmt.Sprintf("\"%s\"", t.String())
 	return []byte(s), nil
 }
+
+//Date formats the time as "2006-01-02" in local time
+func (t SqlTime) Date() string {
+	return time.Time(t).Local().Format("2006-01-02")
+}
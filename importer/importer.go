@@ -0,0 +1,62 @@
+//Package importer detects the format of bank statement files and parses them
+package importer
+
+import (
+	"bytes"
+	"io/ioutil"
+
+	"github.com/go-msvc/errors"
+	"github.com/jansemmelink/money/bank"
+	"github.com/jansemmelink/money/stdbank"
+)
+
+//Format is a type of statement file that can be imported
+type Format struct {
+	Name   string
+	Detect func(data []byte) bool //true if the data is in this format
+	Parse  func(data []byte) (bank.IStatement, error)
+}
+
+//Formats are tried in order to detect the format of a file
+var Formats = []Format{
+	{
+		Name:   "standardbank-csv",
+		Detect: stdbank.Detect,
+		Parse: func(data []byte) (bank.IStatement, error) {
+			return stdbank.Parse(bytes.NewReader(data))
+		},
+	},
+}
+
+//Detect returns the format of the statement data
+func Detect(data []byte) (*Format, error) {
+	for _, f := range Formats {
+		if f.Detect(data) {
+			return &f, nil
+		}
+	}
+	return nil, errors.Errorf("unknown statement format")
+}
+
+//Parse detects the format and parses the statement, which fails with
+//bank.ParseErrors when the format is known but the data is not valid
+func Parse(data []byte) (*Format, bank.IStatement, error) {
+	f, err := Detect(data)
+	if err != nil {
+		return nil, nil, err
+	}
+	stmt, err := f.Parse(data)
+	if err != nil {
+		return f, nil, err
+	}
+	return f, stmt, nil
+}
+
+//Load reads and parses the statement file
+func Load(filename string) (*Format, bank.IStatement, error) {
+	data, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return nil, nil, errors.Wrapf(err, "cannot read %s", filename)
+	}
+	return Parse(data)
+}
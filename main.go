@@ -3,61 +3,161 @@ package main
 import (
 	"flag"
 	"fmt"
+	"io"
 	"os"
 	"strings"
 
-	"github.com/jansemmelink/money/stdbank"
+	"github.com/go-msvc/errors"
+	"github.com/jansemmelink/money/db"
+	"github.com/jansemmelink/money/output"
 )
 
+//exit codes
+const (
+	exitOK    = 0
+	exitError = 1
+	exitUsage = 2
+)
+
+type command struct {
+	name    string
+	args    string
+	summary string
+	run     func(args []string) error
+}
+
+var commands []command
+
+func init() {
+	commands = []command{
+		{name: "import", args: "[-y] [-v] <file>", summary: "Import a bank statement", run: cmdImport},
+		{name: "accounts", args: "list|create|move|rename|merge|...", summary: "Manage accounts", run: cmdAccounts},
+		{name: "transactions", args: "list|edit|tag|untag|...", summary: "List, edit and tag transactions", run: cmdTransactions},
+		{name: "tags", args: "list|delete", summary: "Tags on transactions", run: cmdTags},
+		{name: "documents", args: "upload|list|download|unlink", summary: "Documents (e.g. scanned receipts) linked to transactions", run: cmdDocuments},
+		{name: "review", args: "[-restart]", summary: "Review unknown transactions interactively", run: cmdReview},
+		{name: "rules", args: "list|create|delete|apply", summary: "Manage rules that categorise transactions", run: cmdRules},
+		{name: "recurring", args: "list|prices", summary: "Detect recurring transactions and subscriptions", run: cmdRecurring},
+		{name: "transfers", args: "detect|apply|list|undo", summary: "Detect transfers between own bank accounts", run: cmdTransfers},
+		{name: "statements", args: "list|show|delete|opening-balances", summary: "Manage imported statements", run: cmdStatements},
+		{name: "budgets", args: "report|set|delete|copy", summary: "Monthly budgets compared to actual amounts", run: cmdBudgets},
+		{name: "envelopes", args: "list|create|move|report", summary: "Envelope (zero based) budgeting", run: cmdEnvelopes},
+		{name: "scheduled", args: "list|create|delete|due|unmatched", summary: "Expected payments matched to imported transactions", run: cmdScheduled},
+		{name: "forecast", args: "[-days 30] [-threshold 0]", summary: "Forecast bank account balances", run: cmdForecast},
+		{name: "report", args: "tree|trial-balance|...", summary: "Account tree, trial balance, balance sheet and income statement", run: cmdReport},
+		{name: "serve", args: "[-addr host:port]", summary: "Start the api server", run: cmdServe},
+		{name: "migrate", args: "[-list]", summary: "Apply database migrations", run: cmdMigrate},
+	}
+}
+
 func main() {
-	filePtr := flag.String("f", "", "Filename to import")
-	yesPtr := flag.Bool("y", false, "Import without prompt")
-	verbosePtr := flag.Bool("v", false, "Verbose output")
-	flag.Parse()
-	if *filePtr == "" {
-		panic("Missing -f <filename>")
-	}
-
-	stmt, err := stdbank.LoadStatement(*filePtr)
-	if err != nil {
-		panic(fmt.Errorf("load failed: %v", err))
-	}
-
-	if !(*yesPtr) {
-		fmt.Printf("Statement Loaded Successfully\n")
-		fmt.Printf("Filename: %s\n", os.Args[1])
-		fmt.Printf("Bank Name: %s\n", stmt.BankName())
-		fmt.Printf("Branch Name: %s\n", stmt.BranchCode())
-		fmt.Printf("Branch Code: %s\n", stmt.BranchCode())
-		fmt.Printf("Account Number: %s\n", stmt.AccountNumber())
-		fmt.Printf("Open Date: %s\n", stmt.OpenDate())
-		fmt.Printf("Open Balance: %s\n", stmt.OpenBalance())
-		fmt.Printf("Close Date: %s\n", stmt.CloseDate())
-		fmt.Printf("Close Balance: %s\n", stmt.CloseBalance())
-	}
-	if *verbosePtr {
-		for _, tx := range stmt.Transactions() {
-			fmt.Printf("%s,%s,%s,%s\n",
-				tx.Date.Local().Format("2006-01-02"),
-				tx.Details,
-				tx.Type,
-				tx.Amount)
+	os.Exit(run(os.Args[1:]))
+}
+
+func run(args []string) int {
+	err := runCommand("money", args, commands)
+	if err == nil {
+		return exitOK
+	}
+	if errors.Is(err, flag.ErrHelp) {
+		return exitOK
+	}
+	if usage, ok := err.(usageError); ok {
+		fmt.Fprintf(os.Stderr, "%s\n", usage.msg)
+		return exitUsage
+	}
+	fmt.Fprintf(os.Stderr, "error: %s\n", err.Error())
+	return exitError
+}
+
+//usageError is returned for invalid command lines and results in exitUsage
+type usageError struct {
+	msg string
+}
+
+func (e usageError) Error() string { return e.msg }
+
+func usagef(format string, args ...interface{}) error {
+	return usageError{msg: fmt.Sprintf(format, args...)}
+}
+
+//runCommand runs the named sub command from args[0]
+func runCommand(prefix string, args []string, cmds []command) error {
+	usage := "usage: " + prefix + " <command> [args]\ncommands:\n"
+	for _, c := range cmds {
+		usage += fmt.Sprintf("  %-14s %-28s %s\n", c.name, c.args, c.summary)
+	}
+	usage = strings.TrimSuffix(usage, "\n")
+	if len(args) == 0 {
+		return usageError{msg: usage}
+	}
+	if args[0] == "help" || args[0] == "-h" || args[0] == "--help" {
+		fmt.Fprintln(os.Stdout, usage)
+		return nil
+	}
+	for _, c := range cmds {
+		if c.name == args[0] {
+			return c.run(args[1:])
+		}
+	}
+	return usagef("unknown command \"%s\"\n%s", args[0], usage)
+}
+
+//commandFlags wraps a flag set to report parse errors as usage errors
+type commandFlags struct {
+	*flag.FlagSet
+	usage string
+}
+
+func newFlags(name string, args string) *commandFlags {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	return &commandFlags{FlagSet: fs, usage: "usage: " + name + " " + args}
+}
+
+//output adds the --output flag
+func (f *commandFlags) output() *string {
+	return f.String("output", output.FormatTable, "Output format "+strings.Join(output.Formats, "|"))
+}
+
+func (f *commandFlags) parse(args []string, nrArgs int) error {
+	if err := f.Parse(args); err != nil {
+		if err == flag.ErrHelp {
+			fmt.Fprintln(os.Stdout, f.helpText())
+			return err
 		}
+		return usagef("%s\n%s", err.Error(), f.helpText())
 	}
-	if !(*yesPtr) {
-		fmt.Printf("Import (y/n)[n] ?")
-		answer := ""
-		fmt.Scanf("%s", &answer)
-		answer = strings.ToUpper(answer)
-		if len(answer) < 1 || answer[0] != 'Y' {
-			fmt.Printf("Not imported.\n")
-			os.Exit(0)
+	if nrArgs >= 0 && f.NArg() != nrArgs {
+		return usagef("expects %d arguments\n%s", nrArgs, f.helpText())
+	}
+	if o := f.Lookup("output"); o != nil {
+		if err := output.ValidFormat(o.Value.String()); err != nil {
+			return usagef("%s", err.Error())
 		}
 	}
+	return nil
+}
 
-	id, err := stmt.ImportToDb()
-	if err != nil {
-		panic(fmt.Sprintf("failed to import: %+v", err))
+func (f *commandFlags) helpText() string {
+	text := f.usage
+	f.VisitAll(func(fl *flag.Flag) {
+		text += fmt.Sprintf("\n  -%-12s %s", fl.Name, fl.Usage)
+		if fl.DefValue != "" && fl.DefValue != "false" {
+			text += fmt.Sprintf(" (default %s)", fl.DefValue)
+		}
+	})
+	return text
+}
+
+//connect must be called by commands that use the database
+func connect() error {
+	if err := db.Connect(); err != nil {
+		return errors.Wrapf(err, "cannot connect to database")
 	}
-	fmt.Printf("Imported successfully as statement \"%s\"", id)
+	return nil
+}
+
+func write(t *output.Table, format string) error {
+	return t.Write(os.Stdout, format)
 }
@@ -0,0 +1,166 @@
+//Package output writes tabular command results as a text table, JSON or CSV
+package output
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/go-msvc/errors"
+)
+
+const (
+	FormatTable = "table"
+	FormatJSON  = "json"
+	FormatCSV   = "csv"
+)
+
+var Formats = []string{FormatTable, FormatJSON, FormatCSV}
+
+func ValidFormat(format string) error {
+	for _, f := range Formats {
+		if f == format {
+			return nil
+		}
+	}
+	return errors.Errorf("unknown output format \"%s\" (expects one of %s)", format, strings.Join(Formats, "|"))
+}
+
+//Table is a list of rows with the same columns.
+//Values are written with %v, except in JSON where they are marshalled as is.
+type Table struct {
+	columns []string
+	rows    [][]interface{}
+}
+
+func New(columns ...string) *Table {
+	return &Table{
+		columns: columns,
+		rows:    [][]interface{}{},
+	}
+}
+
+func (t *Table) Row(values ...interface{}) *Table {
+	t.rows = append(t.rows, values)
+	return t
+}
+
+func (t Table) Len() int { return len(t.rows) }
+
+func (t Table) Write(w io.Writer, format string) error {
+	switch format {
+	case FormatTable, "":
+		return t.writeTable(w)
+	case FormatJSON:
+		return t.writeJSON(w)
+	case FormatCSV:
+		return t.writeCSV(w)
+	}
+	return ValidFormat(format)
+}
+
+func (t Table) cell(row []interface{}, i int) string {
+	if i >= len(row) || row[i] == nil {
+		return ""
+	}
+	return fmt.Sprintf("%v", row[i])
+}
+
+func (t Table) writeTable(w io.Writer) error {
+	widths := make([]int, len(t.columns))
+	numeric := make([]bool, len(t.columns))
+	for i, c := range t.columns {
+		widths[i] = len(c)
+		numeric[i] = true
+	}
+	for _, row := range t.rows {
+		for i := range t.columns {
+			s := t.cell(row, i)
+			if len(s) > widths[i] {
+				widths[i] = len(s)
+			}
+			if _, err := strconv.ParseFloat(s, 64); err != nil && s != "" {
+				numeric[i] = false
+			}
+		}
+	}
+	line := func(cells []string) string {
+		padded := make([]string, len(cells))
+		for i, s := range cells {
+			if numeric[i] {
+				padded[i] = fmt.Sprintf("%*s", widths[i], s)
+			} else {
+				padded[i] = fmt.Sprintf("%-*s", widths[i], s)
+			}
+		}
+		return strings.TrimRight(strings.Join(padded, "  "), " ") + "\n"
+	}
+	if _, err := io.WriteString(w, line(t.columns)); err != nil {
+		return err
+	}
+	dashes := make([]string, len(t.columns))
+	for i := range t.columns {
+		dashes[i] = strings.Repeat("-", widths[i])
+	}
+	if _, err := io.WriteString(w, line(dashes)); err != nil {
+		return err
+	}
+	for _, row := range t.rows {
+		cells := make([]string, len(t.columns))
+		for i := range t.columns {
+			cells[i] = t.cell(row, i)
+		}
+		if _, err := io.WriteString(w, line(cells)); err != nil {
+			return err
+		}
+	}
+	return nil
+} //Table.writeTable()
+
+func (t Table) writeCSV(w io.Writer) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write(t.columns); err != nil {
+		return err
+	}
+	for _, row := range t.rows {
+		cells := make([]string, len(t.columns))
+		for i := range t.columns {
+			cells[i] = t.cell(row, i)
+		}
+		if err := cw.Write(cells); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+func (t Table) writeJSON(w io.Writer) error {
+	keys := make([]string, len(t.columns))
+	for i, c := range t.columns {
+		keys[i] = Key(c)
+	}
+	list := []map[string]interface{}{}
+	for _, row := range t.rows {
+		obj := map[string]interface{}{}
+		for i, k := range keys {
+			if i < len(row) {
+				obj[k] = row[i]
+			} else {
+				obj[k] = nil
+			}
+		}
+		list = append(list, obj)
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(list)
+}
+
+//Key converts a column heading like "Counter Account" to "counter_account"
+func Key(heading string) string {
+	return strings.ReplaceAll(strings.ToLower(strings.TrimSpace(heading)), " ", "_")
+}
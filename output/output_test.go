@@ -0,0 +1,47 @@
+package output_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/jansemmelink/money/output"
+)
+
+func TestTable(t *testing.T) {
+	tbl := output.New("Name", "Amount").
+		Row("Groceries", "-12.50").
+		Row("Diesel", "1000.00")
+
+	buf := bytes.NewBuffer(nil)
+	assert(t, tbl.Write(buf, output.FormatTable))
+	expected := "" +
+		"Name        Amount\n" +
+		"---------  -------\n" +
+		"Groceries   -12.50\n" +
+		"Diesel     1000.00\n"
+	if buf.String() != expected {
+		t.Fatalf("table:\n%s\n!=\n%s", buf.String(), expected)
+	}
+
+	buf.Reset()
+	assert(t, tbl.Write(buf, output.FormatCSV))
+	if buf.String() != "Name,Amount\nGroceries,-12.50\nDiesel,1000.00\n" {
+		t.Fatalf("csv: %s", buf.String())
+	}
+
+	buf.Reset()
+	assert(t, output.New("Counter Account").Row(1).Write(buf, output.FormatJSON))
+	if buf.String() != "[\n  {\n    \"counter_account\": 1\n  }\n]\n" {
+		t.Fatalf("json: %s", buf.String())
+	}
+
+	if err := tbl.Write(buf, "xml"); err == nil {
+		t.Fatalf("did not fail on unknown format")
+	}
+}
+
+func assert(t *testing.T, err error) {
+	if err != nil {
+		t.Fatalf("failed: %+v", err)
+	}
+}
@@ -0,0 +1,135 @@
+package query
+
+import (
+	"strings"
+	"unicode"
+
+	"github.com/go-msvc/errors"
+)
+
+type tokenKind int
+
+const (
+	tokenEOF tokenKind = iota
+	tokenIdent
+	tokenString
+	tokenNumber
+	tokenDate
+	tokenOperator
+	tokenOpen
+	tokenClose
+)
+
+type token struct {
+	kind tokenKind
+	text string
+	pos  int //1-based position in the expression
+}
+
+func (t token) String() string {
+	switch t.kind {
+	case tokenEOF:
+		return "end of expression"
+	case tokenString:
+		return "\"" + t.text + "\""
+	}
+	return t.text
+}
+
+var operators = []string{"!=", "<=", ">=", "!~", "=", "<", ">", "~"}
+
+//lex splits the expression into tokens, ending with tokenEOF
+func lex(s string) ([]token, error) {
+	tokens := []token{}
+	for i := 0; i < len(s); {
+		c := s[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			i++
+		case c == '(':
+			tokens = append(tokens, token{tokenOpen, "(", i + 1})
+			i++
+		case c == ')':
+			tokens = append(tokens, token{tokenClose, ")", i + 1})
+			i++
+		case c == '"' || c == '\'':
+			text, n, err := lexString(s[i:])
+			if err != nil {
+				return nil, &Error{Pos: i + 1, Message: err.Error()}
+			}
+			tokens = append(tokens, token{tokenString, text, i + 1})
+			i += n
+		case isDigit(c) || (c == '-' && i+1 < len(s) && isDigit(s[i+1])):
+			n := 1
+			for i+n < len(s) && (isDigit(s[i+n]) || s[i+n] == '.' || s[i+n] == '-') {
+				n++
+			}
+			text := s[i : i+n]
+			kind := tokenNumber
+			if isDate(text) {
+				kind = tokenDate
+			} else if strings.Count(text, ".") > 1 || strings.LastIndex(text, "-") > 0 {
+				return nil, &Error{Pos: i + 1, Message: "invalid number " + text}
+			}
+			tokens = append(tokens, token{kind, text, i + 1})
+			i += n
+		case c == '_' || unicode.IsLetter(rune(c)):
+			n := 1
+			for i+n < len(s) && (s[i+n] == '_' || isDigit(s[i+n]) || unicode.IsLetter(rune(s[i+n]))) {
+				n++
+			}
+			tokens = append(tokens, token{tokenIdent, s[i : i+n], i + 1})
+			i += n
+		default:
+			op := ""
+			for _, o := range operators {
+				if strings.HasPrefix(s[i:], o) {
+					op = o
+					break
+				}
+			}
+			if op == "" {
+				return nil, &Error{Pos: i + 1, Message: "unexpected character " + string(c)}
+			}
+			tokens = append(tokens, token{tokenOperator, op, i + 1})
+			i += len(op)
+		}
+	}
+	return append(tokens, token{tokenEOF, "", len(s) + 1}), nil
+} //lex()
+
+//lexString returns the text of the quoted string at the start of s
+//and the nr of bytes used, with \ escaping the next character
+func lexString(s string) (string, int, error) {
+	quote := s[0]
+	text := []byte{}
+	for i := 1; i < len(s); i++ {
+		switch s[i] {
+		case quote:
+			return string(text), i + 1, nil
+		case '\\':
+			if i+1 < len(s) {
+				i++
+			}
+		}
+		text = append(text, s[i])
+	}
+	return "", 0, errors.Errorf("missing closing quote")
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+//isDate is true for CCYY-MM-DD
+func isDate(s string) bool {
+	if len(s) != 10 || s[4] != '-' || s[7] != '-' {
+		return false
+	}
+	for _, i := range []int{0, 1, 2, 3, 5, 6, 8, 9} {
+		if !isDigit(s[i]) {
+			return false
+		}
+	}
+	return true
+}
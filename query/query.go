@@ -0,0 +1,266 @@
+//Package query compiles filter expressions like
+//
+//	amount < -500 and details ~ "sasol" and date >= 2021-01-01
+//
+//into parameterised SQL conditions. Fields map the names in the
+//expression to SQL, so only known columns are ever referenced and
+//all values are passed as args.
+package query
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jansemmelink/money/db"
+)
+
+//Error is a syntax or field error at a position in the expression
+type Error struct {
+	Pos     int    `json:"pos"` //1-based
+	Message string `json:"message"`
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("at %d: %s", e.Pos, e.Message)
+}
+
+//Type of a field determines the values and operators it accepts
+type Type int
+
+const (
+	String Type = iota //any value, compared with =, != or ~ (contains)
+	Number             //numbers
+	Date               //CCYY-MM-DD, compared per day
+)
+
+//Field is a name that can be used in an expression
+type Field struct {
+	SQL  string //column expression, e.g. "t.date"
+	Type Type
+	//In is optional SQL with %s where the comparison goes, for fields of
+	//related rows, e.g. "t.id IN (SELECT transaction_id FROM `postings` WHERE %s)".
+	//The negative operators != and !~ are then compiled as NOT IN.
+	In string
+}
+
+//Fields by name as used in expressions
+type Fields map[string]Field
+
+//Names sorted for help text
+func (fields Fields) Names() []string {
+	names := []string{}
+	for n := range fields {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+	return names
+}
+
+//Compile parses the expression and returns the SQL condition with args.
+//An empty expression returns "" without args.
+func Compile(expr string, fields Fields) (string, []interface{}, error) {
+	tokens, err := lex(expr)
+	if err != nil {
+		return "", nil, err
+	}
+	if tokens[0].kind == tokenEOF {
+		return "", nil, nil
+	}
+	c := compiler{tokens: tokens, fields: fields, args: []interface{}{}}
+	sql, err := c.or()
+	if err != nil {
+		return "", nil, err
+	}
+	if t := c.peek(); t.kind != tokenEOF {
+		return "", nil, &Error{Pos: t.pos, Message: "expected and/or instead of " + t.String()}
+	}
+	return sql, c.args, nil
+}
+
+//Parse checks the expression and field names, returning an *Error when invalid
+func Parse(expr string, fields Fields) error {
+	_, _, err := Compile(expr, fields)
+	return err
+}
+
+type compiler struct {
+	tokens []token
+	next   int
+	fields Fields
+	args   []interface{}
+}
+
+func (c *compiler) peek() token {
+	return c.tokens[c.next]
+}
+
+func (c *compiler) take() token {
+	t := c.tokens[c.next]
+	if t.kind != tokenEOF {
+		c.next++
+	}
+	return t
+}
+
+func (c *compiler) keyword(word string) bool {
+	t := c.peek()
+	if t.kind == tokenIdent && strings.EqualFold(t.text, word) {
+		c.next++
+		return true
+	}
+	return false
+}
+
+//or := and ("or" and)*
+func (c *compiler) or() (string, error) {
+	sql, err := c.and()
+	if err != nil {
+		return "", err
+	}
+	for c.keyword("or") {
+		right, err := c.and()
+		if err != nil {
+			return "", err
+		}
+		sql = "(" + sql + " OR " + right + ")"
+	}
+	return sql, nil
+}
+
+//and := unary ("and" unary)*
+func (c *compiler) and() (string, error) {
+	sql, err := c.unary()
+	if err != nil {
+		return "", err
+	}
+	for c.keyword("and") {
+		right, err := c.unary()
+		if err != nil {
+			return "", err
+		}
+		sql = "(" + sql + " AND " + right + ")"
+	}
+	return sql, nil
+}
+
+//unary := "not" unary | "(" or ")" | comparison
+func (c *compiler) unary() (string, error) {
+	if c.keyword("not") {
+		sql, err := c.unary()
+		if err != nil {
+			return "", err
+		}
+		return "NOT (" + sql + ")", nil
+	}
+	if c.peek().kind == tokenOpen {
+		c.take()
+		sql, err := c.or()
+		if err != nil {
+			return "", err
+		}
+		if t := c.take(); t.kind != tokenClose {
+			return "", &Error{Pos: t.pos, Message: "expected ) instead of " + t.String()}
+		}
+		return sql, nil
+	}
+	return c.comparison()
+}
+
+//comparison := field operator value
+func (c *compiler) comparison() (string, error) {
+	name := c.take()
+	if name.kind != tokenIdent {
+		return "", &Error{Pos: name.pos, Message: "expected field name instead of " + name.String()}
+	}
+	field, ok := c.fields[strings.ToLower(name.text)]
+	if !ok {
+		return "", &Error{Pos: name.pos, Message: fmt.Sprintf("unknown field %s (expects one of %s)", name.text, strings.Join(c.fields.Names(), ", "))}
+	}
+	op := c.take()
+	if op.kind != tokenOperator {
+		return "", &Error{Pos: op.pos, Message: "expected operator after " + name.text + " instead of " + op.String()}
+	}
+	value := c.take()
+	if value.kind != tokenString && value.kind != tokenNumber && value.kind != tokenDate && value.kind != tokenIdent {
+		return "", &Error{Pos: value.pos, Message: "expected value after " + op.text + " instead of " + value.String()}
+	}
+	if value.kind == tokenIdent && (strings.EqualFold(value.text, "and") || strings.EqualFold(value.text, "or")) {
+		return "", &Error{Pos: value.pos, Message: "expected value after " + op.text + " instead of " + value.String()}
+	}
+
+	//related rows are compared positively and negated outside the IN
+	negate := field.In != "" && (op.text == "!=" || op.text == "!~")
+	compareOp := op.text
+	if negate {
+		compareOp = map[string]string{"!=": "=", "!~": "~"}[op.text]
+	}
+	sql, err := c.compare(field, compareOp, op.pos, value)
+	if err != nil {
+		return "", err
+	}
+	if field.In == "" {
+		return sql, nil
+	}
+	sql = fmt.Sprintf(field.In, sql)
+	if negate {
+		return "NOT (" + sql + ")", nil
+	}
+	return sql, nil
+} //compiler.comparison()
+
+//compare is the SQL comparing the field to the value
+func (c *compiler) compare(field Field, op string, opPos int, value token) (string, error) {
+	switch field.Type {
+	case Number:
+		n, err := strconv.ParseFloat(value.text, 64)
+		if value.kind != tokenNumber || err != nil {
+			return "", &Error{Pos: value.pos, Message: "expected number instead of " + value.String()}
+		}
+		if op == "~" || op == "!~" {
+			return "", &Error{Pos: opPos, Message: "cannot use " + op + " with numbers"}
+		}
+		c.args = append(c.args, n)
+		return field.SQL + op + "?", nil
+
+	case Date:
+		day, err := time.ParseInLocation("2006-01-02", value.text, time.Local)
+		if err != nil {
+			return "", &Error{Pos: value.pos, Message: "expected date CCYY-MM-DD instead of " + value.String()}
+		}
+		next := day.AddDate(0, 0, 1)
+		//dates include the whole day
+		switch op {
+		case "=":
+			c.args = append(c.args, day, next)
+			return "(" + field.SQL + ">=? AND " + field.SQL + "<?)", nil
+		case "!=":
+			c.args = append(c.args, day, next)
+			return "(" + field.SQL + "<? OR " + field.SQL + ">=?)", nil
+		case "<", ">=":
+			c.args = append(c.args, day)
+			return field.SQL + op + "?", nil
+		case "<=":
+			c.args = append(c.args, next)
+			return field.SQL + "<?", nil
+		case ">":
+			c.args = append(c.args, next)
+			return field.SQL + ">=?", nil
+		}
+		return "", &Error{Pos: opPos, Message: "cannot use " + op + " with dates"}
+
+	default:
+		switch op {
+		case "~":
+			c.args = append(c.args, "%"+db.LikeEscape(value.text)+"%")
+			return field.SQL + " LIKE ?", nil
+		case "!~":
+			c.args = append(c.args, "%"+db.LikeEscape(value.text)+"%")
+			return field.SQL + " NOT LIKE ?", nil
+		}
+		c.args = append(c.args, value.text)
+		return field.SQL + op + "?", nil
+	}
+} //compiler.compare()
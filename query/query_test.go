@@ -0,0 +1,89 @@
+package query_test
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/jansemmelink/money/query"
+)
+
+var fields = query.Fields{
+	"amount":  {SQL: "t.amount", Type: query.Number},
+	"date":    {SQL: "t.date", Type: query.Date},
+	"details": {SQL: "t.details"},
+	"account": {SQL: "a.name", In: "t.id IN (SELECT p.transaction_id FROM postings AS p JOIN accounts AS a ON a.id=p.account_id WHERE %s)"},
+}
+
+func TestCompile(t *testing.T) {
+	day := func(s string) string {
+		d, err := time.ParseInLocation("2006-01-02", s, time.Local)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return d.Format("2006-01-02")
+	}
+	for _, c := range []struct {
+		expr string
+		sql  string
+		args string
+	}{
+		{``, ``, `[]`},
+		{`amount < -500`, `t.amount<?`, `[-500]`},
+		{`amount < -500 and details ~ "sasol" and date >= 2021-01-01 and account = "Groceries"`,
+			`(((t.amount<? AND t.details LIKE ?) AND t.date>=?) AND t.id IN (SELECT p.transaction_id FROM postings AS p JOIN accounts AS a ON a.id=p.account_id WHERE a.name=?))`,
+			`[-500 %sasol% ` + day("2021-01-01") + ` Groceries]`},
+		//and binds tighter than or
+		{`details ~ a or details ~ 'b c' and not amount >= 10.5`,
+			`(t.details LIKE ? OR (t.details LIKE ? AND NOT (t.amount>=?)))`,
+			`[%a% %b c% 10.5]`},
+		{`(details = "x" or details = "y") and DATE = 2021-02-03`,
+			`((t.details=? OR t.details=?) AND (t.date>=? AND t.date<?))`,
+			`[x y ` + day("2021-02-03") + ` ` + day("2021-02-04") + `]`},
+		{`date <= 2021-01-31`, `t.date<?`, `[` + day("2021-02-01") + `]`},
+		{`account != Unknown`,
+			`NOT (t.id IN (SELECT p.transaction_id FROM postings AS p JOIN accounts AS a ON a.id=p.account_id WHERE a.name=?))`,
+			`[Unknown]`},
+		{`details !~ "100%"`, `t.details NOT LIKE ?`, `[%100\%%]`},
+		{`details = "a \"quoted\" 'text'"`, `t.details=?`, `[a "quoted" 'text']`},
+	} {
+		sql, args, err := query.Compile(c.expr, fields)
+		if err != nil {
+			t.Errorf("%s: %v", c.expr, err)
+			continue
+		}
+		argText := []string{}
+		for _, a := range args {
+			if d, ok := a.(time.Time); ok {
+				argText = append(argText, d.Format("2006-01-02"))
+			} else {
+				argText = append(argText, fmt.Sprintf("%v", a))
+			}
+		}
+		if sql != c.sql || fmt.Sprintf("%v", argText) != c.args {
+			t.Errorf("%s:\n  sql  %s\n  != %s\n  args %v != %s", c.expr, sql, c.sql, argText, c.args)
+		}
+	}
+}
+
+func TestCompileErrors(t *testing.T) {
+	for expr, expected := range map[string]string{
+		`amount < x`:             `at 10: expected number instead of x`,
+		`colour = red`:           `at 1: unknown field colour (expects one of account, amount, date, details)`,
+		`details "x"`:            `at 9: expected operator after details instead of "x"`,
+		`details = "x`:           `at 11: missing closing quote`,
+		`date > 2021-13-01`:      `at 8: expected date CCYY-MM-DD instead of 2021-13-01`,
+		`(details = x`:           `at 13: expected ) instead of end of expression`,
+		`details = x amount = 1`: `at 13: expected and/or instead of amount`,
+		`details = x and`:        `at 16: expected field name instead of end of expression`,
+		`amount ~ 1`:             `at 8: cannot use ~ with numbers`,
+		`details = x # y`:        `at 13: unexpected character #`,
+		`amount = 1.2.3`:         `at 10: invalid number 1.2.3`,
+		`details = and`:          `at 11: expected value after = instead of and`,
+	} {
+		_, _, err := query.Compile(expr, fields)
+		if err == nil || err.Error() != expected {
+			t.Errorf("%s: error %v != %s", expr, err, expected)
+		}
+	}
+}
@@ -0,0 +1,76 @@
+package report
+
+import (
+	"time"
+
+	"github.com/jansemmelink/money/bank"
+)
+
+//BalanceSheet has one column per date, e.g. a date and the same date
+//the year before for comparison. The period result is the income less
+//expenses since the start of the year of each date.
+func BalanceSheet(dates []time.Time) (*Report, error) {
+	columns := []string{}
+	atDate := [][]bank.AccountTotal{}
+	inPeriod := [][]bank.AccountTotal{}
+	for _, date := range dates {
+		totals, err := bank.GetPeriodTotals(time.Time{}, date, "")
+		if err != nil {
+			return nil, err
+		}
+		yearStart := time.Date(date.Year(), 1, 1, 0, 0, 0, 0, date.Location())
+		periodTotals, err := bank.GetPeriodTotals(yearStart, date, "")
+		if err != nil {
+			return nil, err
+		}
+		columns = append(columns, date.Format("2006-01-02"))
+		atDate = append(atDate, totals)
+		inPeriod = append(inPeriod, periodTotals)
+	}
+	r := NewBalanceSheet("Balance sheet", columns, atDate, inPeriod)
+	return &r, nil
+}
+
+//NewBalanceSheet shows assets, liabilities and equity per column from the
+//totals at each date. Income and expenses are not closed to equity, so equity
+//includes the result before the period (retained earnings) and in the period.
+func NewBalanceSheet(title string, columns []string, atDate, inPeriod [][]bank.AccountTotal) Report {
+	r := Report{Title: title, Columns: columns, Rows: []Row{}}
+	r.heading("Assets")
+	assets := r.accountRows(bank.AccountTypeAsset, atDate)
+	r.total("Total assets", assets)
+
+	r.heading("Liabilities")
+	liabilities := r.accountRows(bank.AccountTypeLiability, atDate)
+	r.total("Total liabilities", liabilities)
+
+	r.heading("Equity")
+	equity := r.accountRows(bank.AccountTypeEquity, atDate)
+	result := netResult(atDate)
+	periodResult := netResult(inPeriod)
+	retained := sub(result, periodResult)
+	r.Rows = append(r.Rows,
+		Row{Label: "Retained earnings", Values: amountPtrs(retained)},
+		Row{Label: "Period result", Values: amountPtrs(periodResult)},
+	)
+	equity = add(equity, result)
+	r.total("Total equity", equity)
+	r.total("Total liabilities and equity", add(liabilities, equity))
+	return r
+}
+
+//netResult is income less expenses per column
+func netResult(columns [][]bank.AccountTotal) []bank.Amount {
+	result := make([]bank.Amount, len(columns))
+	for i, totals := range columns {
+		for _, t := range totals {
+			switch t.Account.Type {
+			case bank.AccountTypeIncome:
+				result[i] = result[i].Add(t.NormalBalance())
+			case bank.AccountTypeExpense:
+				result[i] = result[i].Sub(t.NormalBalance())
+			}
+		}
+	}
+	return result
+}
@@ -0,0 +1,64 @@
+package report
+
+import (
+	"github.com/jansemmelink/money/bank"
+)
+
+//IncomeStatement has one column per period in the list, and a total,
+//of only transactions with the tag unless it is ""
+func IncomeStatement(periods []Period, tag string) (*Report, error) {
+	columns := []string{}
+	inPeriod := [][]bank.AccountTotal{}
+	for _, p := range periods {
+		totals, err := bank.GetPeriodTotals(p.From, p.To, tag)
+		if err != nil {
+			return nil, err
+		}
+		columns = append(columns, p.Name)
+		inPeriod = append(inPeriod, totals)
+	}
+	title := "Income statement"
+	if tag != "" {
+		title += " tagged " + tag
+	}
+	r := NewIncomeStatement(title, columns, inPeriod)
+	return &r, nil
+}
+
+//NewIncomeStatement shows income and expenses per period side by side
+//with the total of all periods in the last column
+func NewIncomeStatement(title string, columns []string, inPeriod [][]bank.AccountTotal) Report {
+	if len(columns) > 1 {
+		columns = append(columns, "Total")
+		inPeriod = append(inPeriod, sumTotals(inPeriod))
+	}
+	r := Report{Title: title, Columns: columns, Rows: []Row{}}
+	r.heading("Income")
+	income := r.accountRows(bank.AccountTypeIncome, inPeriod)
+	r.total("Total income", income)
+	r.heading("Expenses")
+	expenses := r.accountRows(bank.AccountTypeExpense, inPeriod)
+	r.total("Total expenses", expenses)
+	r.total("Net result", sub(income, expenses))
+	return r
+}
+
+//sumTotals adds the totals of the same accounts in all columns
+func sumTotals(columns [][]bank.AccountTotal) []bank.AccountTotal {
+	list := []bank.AccountTotal{}
+	index := map[string]int{}
+	for _, totals := range columns {
+		for _, t := range totals {
+			i, ok := index[t.Account.ID]
+			if !ok {
+				i = len(list)
+				index[t.Account.ID] = i
+				list = append(list, bank.AccountTotal{Account: t.Account})
+			}
+			list[i].Debits = list[i].Debits.Add(t.Debits)
+			list[i].Credits = list[i].Credits.Add(t.Credits)
+			list[i].NrTransactions += t.NrTransactions
+		}
+	}
+	return list
+}
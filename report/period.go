@@ -0,0 +1,79 @@
+package report
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/go-msvc/errors"
+)
+
+type Interval string
+
+const (
+	Month   Interval = "month"
+	Quarter Interval = "quarter"
+	Year    Interval = "year"
+)
+
+//limit the nr of columns in a report
+const maxPeriods = 120
+
+func ParseInterval(s string) (Interval, error) {
+	switch i := Interval(s); i {
+	case Month, Quarter, Year:
+		return i, nil
+	}
+	return "", errors.Errorf("invalid interval \"%s\" (expects %s|%s|%s)", s, Month, Quarter, Year)
+}
+
+//Period is from the start of the first day up to the end of the last day
+type Period struct {
+	Name string
+	From time.Time
+	To   time.Time
+}
+
+//Periods covering from..to, starting at the start of the interval
+//containing from, e.g. months "2021-01","2021-02",... or quarters "2021-Q1",...
+func Periods(from, to time.Time, interval Interval) ([]Period, error) {
+	if from.IsZero() || to.IsZero() {
+		return nil, errors.Errorf("periods need from and to dates")
+	}
+	if to.Before(from) {
+		return nil, errors.Errorf("to %s is before from %s", to.Format("2006-01-02"), from.Format("2006-01-02"))
+	}
+	var start time.Time
+	var months int
+	switch interval {
+	case Month:
+		start = time.Date(from.Year(), from.Month(), 1, 0, 0, 0, 0, from.Location())
+		months = 1
+	case Quarter:
+		start = time.Date(from.Year(), from.Month()-(from.Month()-1)%3, 1, 0, 0, 0, 0, from.Location())
+		months = 3
+	case Year:
+		start = time.Date(from.Year(), 1, 1, 0, 0, 0, 0, from.Location())
+		months = 12
+	default:
+		return nil, errors.Errorf("invalid interval \"%s\"", interval)
+	}
+	list := []Period{}
+	for !start.After(to) {
+		if len(list) >= maxPeriods {
+			return nil, errors.Errorf("more than %d periods", maxPeriods)
+		}
+		next := start.AddDate(0, months, 0)
+		p := Period{From: start, To: next.Add(-time.Second)}
+		switch interval {
+		case Month:
+			p.Name = start.Format("2006-01")
+		case Quarter:
+			p.Name = fmt.Sprintf("%d-Q%d", start.Year(), (int(start.Month())+2)/3)
+		case Year:
+			p.Name = start.Format("2006")
+		}
+		list = append(list, p)
+		start = next
+	}
+	return list, nil
+} //Periods()
@@ -0,0 +1,168 @@
+//Package report builds financial statements from the account totals
+package report
+
+import (
+	"encoding/json"
+	"io"
+	"strings"
+
+	"github.com/go-msvc/errors"
+	"github.com/jansemmelink/money/bank"
+	"github.com/jansemmelink/money/output"
+)
+
+//Report is a list of labelled rows with one amount per column,
+//e.g. one column per period or per date
+type Report struct {
+	Title   string   `json:"title"`
+	Columns []string `json:"columns"`
+	Rows    []Row    `json:"rows"`
+}
+
+//Row is an account, a heading (without values) or a total
+type Row struct {
+	Label     string         `json:"label"`
+	AccountID string         `json:"account_id,omitempty"`
+	Depth     int            `json:"depth"` //0 for root accounts, headings and totals
+	Values    []*bank.Amount `json:"values,omitempty"`
+	Total     bool           `json:"total,omitempty"`
+}
+
+func (r *Report) heading(label string) {
+	r.Rows = append(r.Rows, Row{Label: label})
+}
+
+func (r *Report) total(label string, values []bank.Amount) {
+	r.Rows = append(r.Rows, Row{Label: label, Values: amountPtrs(values), Total: true})
+}
+
+//MaxDepth removes account rows at depth and deeper, totals are not changed
+func (r Report) MaxDepth(depth int) Report {
+	if depth <= 0 {
+		return r
+	}
+	rows := []Row{}
+	for _, row := range r.Rows {
+		if row.AccountID == "" || row.Depth < depth {
+			rows = append(rows, row)
+		}
+	}
+	r.Rows = rows
+	return r
+}
+
+//Table has the label and value columns, with account labels
+//indented by depth in the text table format
+func (r Report) Table(format string) *output.Table {
+	t := output.New(append([]string{"Account"}, r.Columns...)...)
+	for _, row := range r.Rows {
+		label := row.Label
+		if format == output.FormatTable {
+			label = strings.Repeat("  ", row.Depth) + label
+		}
+		values := []interface{}{label}
+		for i := range r.Columns {
+			if i < len(row.Values) && row.Values[i] != nil {
+				values = append(values, *row.Values[i])
+			} else {
+				values = append(values, nil)
+			}
+		}
+		t.Row(values...)
+	}
+	return t
+}
+
+//Write the report as a text table, CSV or JSON
+func (r Report) Write(w io.Writer, format string) error {
+	if format == output.FormatJSON {
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(r); err != nil {
+			return errors.Wrapf(err, "failed to encode report")
+		}
+		return nil
+	}
+	if format == output.FormatTable {
+		if _, err := io.WriteString(w, r.Title+"\n\n"); err != nil {
+			return err
+		}
+	}
+	return r.Table(format).Write(w, format)
+}
+
+func amountPtrs(values []bank.Amount) []*bank.Amount {
+	list := make([]*bank.Amount, len(values))
+	for i := range values {
+		v := values[i]
+		list[i] = &v
+	}
+	return list
+}
+
+//accountRows adds the accounts of the type as a tree with normal balances
+//rolled up, one value per column. Accounts that are zero in all columns are
+//skipped. It returns the column totals of the root accounts.
+func (r *Report) accountRows(accountType bank.AccountType, columns [][]bank.AccountTotal) []bank.Amount {
+	totals := make([]bank.Amount, len(columns))
+	nodesByColumn := make([]map[string]bank.AccountTreeNode, len(columns))
+	tree := bank.RollUp(ofType(sumTotals(columns), accountType))
+	for i, column := range columns {
+		nodesByColumn[i] = map[string]bank.AccountTreeNode{}
+		nodes := bank.RollUp(ofType(column, accountType))
+		for _, node := range nodes {
+			nodesByColumn[i][node.Account.ID] = node
+			if node.Depth == 0 {
+				totals[i] = totals[i].Add(node.RollUp.NormalBalance())
+			}
+		}
+	}
+	for _, node := range tree {
+		values := make([]bank.Amount, len(columns))
+		allZero := true
+		for i := range columns {
+			if n, ok := nodesByColumn[i][node.Account.ID]; ok {
+				values[i] = n.RollUp.NormalBalance()
+				if !values[i].IsZero() {
+					allZero = false
+				}
+			}
+		}
+		if allZero {
+			continue
+		}
+		r.Rows = append(r.Rows, Row{
+			Label:     node.Account.Name,
+			AccountID: node.Account.ID,
+			Depth:     node.Depth,
+			Values:    amountPtrs(values),
+		})
+	}
+	return totals
+}
+
+func ofType(totals []bank.AccountTotal, accountType bank.AccountType) []bank.AccountTotal {
+	list := []bank.AccountTotal{}
+	for _, t := range totals {
+		if t.Account.Type == accountType {
+			list = append(list, t)
+		}
+	}
+	return list
+}
+
+func add(a, b []bank.Amount) []bank.Amount {
+	sum := make([]bank.Amount, len(a))
+	for i := range a {
+		sum[i] = a[i].Add(b[i])
+	}
+	return sum
+}
+
+func sub(a, b []bank.Amount) []bank.Amount {
+	diff := make([]bank.Amount, len(a))
+	for i := range a {
+		diff[i] = a[i].Sub(b[i])
+	}
+	return diff
+}
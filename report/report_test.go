@@ -0,0 +1,139 @@
+package report_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/jansemmelink/money/bank"
+	"github.com/jansemmelink/money/report"
+)
+
+func TestPeriods(t *testing.T) {
+	date := func(s string) time.Time {
+		d, err := time.Parse("2006-01-02", s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return d
+	}
+	for _, tc := range []struct {
+		interval report.Interval
+		from, to string
+		names    []string
+	}{
+		{report.Month, "2021-01-15", "2021-03-01", []string{"2021-01", "2021-02", "2021-03"}},
+		{report.Quarter, "2021-02-01", "2021-07-31", []string{"2021-Q1", "2021-Q2", "2021-Q3"}},
+		{report.Year, "2020-12-31", "2021-01-01", []string{"2020", "2021"}},
+	} {
+		periods, err := report.Periods(date(tc.from), date(tc.to), tc.interval)
+		if err != nil {
+			t.Fatalf("%s: %v", tc.interval, err)
+		}
+		if len(periods) != len(tc.names) {
+			t.Fatalf("%s: %d periods instead of %d", tc.interval, len(periods), len(tc.names))
+		}
+		for i, p := range periods {
+			if p.Name != tc.names[i] {
+				t.Errorf("%s[%d]: %s != %s", tc.interval, i, p.Name, tc.names[i])
+			}
+		}
+	}
+	q, _ := report.Periods(date("2021-05-05"), date("2021-05-05"), report.Quarter)
+	if q[0].From.Format("2006-01-02") != "2021-04-01" || q[0].To.Format("2006-01-02") != "2021-06-30" {
+		t.Errorf("quarter %s..%s", q[0].From, q[0].To)
+	}
+	if _, err := report.Periods(date("2021-02-01"), date("2021-01-01"), report.Month); err == nil {
+		t.Errorf("expected error when to < from")
+	}
+}
+
+func amount(t *testing.T, s string) bank.Amount {
+	a, err := bank.NewAmount(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return a
+}
+
+//testTotals has a bank account that received a salary of 1000 and paid
+//200 for groceries and 50 for diesel, after an opening balance of 100
+func testTotals(t *testing.T) []bank.AccountTotal {
+	bankAcc := bank.Account{ID: "1", Name: "Bank", Path: "Bank", Type: bank.AccountTypeAsset}
+	opening := bank.Account{ID: "2", Name: "Opening balances", Path: "Opening balances", Type: bank.AccountTypeEquity}
+	salary := bank.Account{ID: "3", Name: "Salary", Path: "Salary", Type: bank.AccountTypeIncome}
+	expenses := bank.Account{ID: "4", Name: "Expenses", Path: "Expenses", Type: bank.AccountTypeExpense}
+	groceries := bank.Account{ID: "5", Name: "Groceries", ParentID: "4", Path: "Expenses:Groceries", Type: bank.AccountTypeExpense}
+	diesel := bank.Account{ID: "6", Name: "Diesel", ParentID: "4", Path: "Expenses:Diesel", Type: bank.AccountTypeExpense}
+	return []bank.AccountTotal{
+		{Account: bankAcc, Debits: amount(t, "1100"), Credits: amount(t, "250")},
+		{Account: opening, Credits: amount(t, "100")},
+		{Account: salary, Credits: amount(t, "1000")},
+		{Account: expenses},
+		{Account: groceries, Debits: amount(t, "200")},
+		{Account: diesel, Debits: amount(t, "50")},
+	}
+}
+
+func total(t *testing.T, r report.Report, label string) []string {
+	for _, row := range r.Rows {
+		if row.Label == label {
+			values := []string{}
+			for _, v := range row.Values {
+				if v == nil {
+					values = append(values, "")
+				} else {
+					values = append(values, v.String())
+				}
+			}
+			return values
+		}
+	}
+	t.Fatalf("row %s not found", label)
+	return nil
+}
+
+func TestTrialBalance(t *testing.T) {
+	r := report.NewTrialBalance("test", testTotals(t))
+	if got := total(t, r, "Total"); got[0] != "1100.00" || got[1] != "1100.00" {
+		t.Errorf("total %v", got)
+	}
+	if got := total(t, r, "Salary"); got[0] != "" || got[1] != "1000.00" {
+		t.Errorf("salary %v", got)
+	}
+}
+
+func TestBalanceSheet(t *testing.T) {
+	totals := testTotals(t)
+	//only the groceries were in the period
+	inPeriod := []bank.AccountTotal{totals[4]}
+	r := report.NewBalanceSheet("test", []string{"2021-12-31"}, [][]bank.AccountTotal{totals}, [][]bank.AccountTotal{inPeriod})
+	if got := total(t, r, "Total assets"); got[0] != "850.00" {
+		t.Errorf("assets %v", got)
+	}
+	if got := total(t, r, "Period result"); got[0] != "-200.00" {
+		t.Errorf("period result %v", got)
+	}
+	if got := total(t, r, "Retained earnings"); got[0] != "950.00" {
+		t.Errorf("retained earnings %v", got)
+	}
+	if got := total(t, r, "Total liabilities and equity"); got[0] != "850.00" {
+		t.Errorf("liabilities and equity %v", got)
+	}
+}
+
+func TestIncomeStatement(t *testing.T) {
+	totals := testTotals(t)
+	r := report.NewIncomeStatement("test", []string{"Jan", "Feb"}, [][]bank.AccountTotal{totals[4:5], totals})
+	if len(r.Columns) != 3 || r.Columns[2] != "Total" {
+		t.Fatalf("columns %v", r.Columns)
+	}
+	if got := total(t, r, "Total expenses"); got[0] != "200.00" || got[1] != "250.00" || got[2] != "450.00" {
+		t.Errorf("expenses %v", got)
+	}
+	if got := total(t, r, "Net result"); got[0] != "-200.00" || got[1] != "750.00" || got[2] != "550.00" {
+		t.Errorf("net result %v", got)
+	}
+	if got := len(r.MaxDepth(1).Rows); got != len(r.Rows)-2 {
+		t.Errorf("max depth 1 has %d of %d rows", got, len(r.Rows))
+	}
+}
@@ -0,0 +1,59 @@
+package report
+
+import (
+	"sort"
+	"time"
+
+	"github.com/jansemmelink/money/bank"
+)
+
+//TrialBalance lists the balance of each account at the end of asOf
+//in the debit or credit column, zero time for all transactions and
+//only transactions with the tag unless it is ""
+func TrialBalance(asOf time.Time, tag string) (*Report, error) {
+	totals, err := bank.GetPeriodTotals(time.Time{}, asOf, tag)
+	if err != nil {
+		return nil, err
+	}
+	title := "Trial balance"
+	if !asOf.IsZero() {
+		title += " at " + asOf.Format("2006-01-02")
+	}
+	if tag != "" {
+		title += " tagged " + tag
+	}
+	r := NewTrialBalance(title, totals)
+	return &r, nil
+}
+
+//NewTrialBalance lists accounts with non-zero balances by path.
+//The debit and credit totals are equal when all transactions balance.
+func NewTrialBalance(title string, totals []bank.AccountTotal) Report {
+	r := Report{Title: title, Columns: []string{"Debit", "Credit"}, Rows: []Row{}}
+	sorted := append([]bank.AccountTotal{}, totals...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		if sorted[i].Account.Type != sorted[j].Account.Type {
+			return sorted[i].Account.Type.Order() < sorted[j].Account.Type.Order()
+		}
+		return sorted[i].Account.Path < sorted[j].Account.Path
+	})
+	var debits, credits bank.Amount
+	for _, t := range sorted {
+		balance := t.Balance()
+		if balance.IsZero() {
+			continue
+		}
+		row := Row{Label: t.Account.Path, AccountID: t.Account.ID, Values: make([]*bank.Amount, 2)}
+		if balance.MilliCents() > 0 {
+			row.Values[0] = &balance
+			debits = debits.Add(balance)
+		} else {
+			credit := balance.Neg()
+			row.Values[1] = &credit
+			credits = credits.Add(credit)
+		}
+		r.Rows = append(r.Rows, row)
+	}
+	r.total("Total", []bank.Amount{debits, credits})
+	return r
+}
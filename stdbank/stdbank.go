@@ -1,6 +1,7 @@
 package stdbank
 
 import (
+	"bytes"
 	"encoding/csv"
 	"fmt"
 	"io"
@@ -13,57 +14,94 @@ import (
 
 var log = logger.New("money").New("stdbank")
 
-func LoadStatement(fn string) (stmt bank.IStatement, err error) {
+//parsing stops after this many errors
+const maxErrors = 20
+
+//Detect is true when the data starts with the branch and account number
+//lines of a Standard Bank CSV statement
+func Detect(data []byte) bool {
+	csvReader := csv.NewReader(bytes.NewReader(data))
+	csvReader.FieldsPerRecord = -1
+	for _, expected := range []string{"BRANCH", "ACC-NO"} {
+		record, err := csvReader.Read()
+		if err != nil || len(record) < 3 || record[2] != expected {
+			return false
+		}
+	}
+	return true
+}
+
+func LoadStatement(fn string) (bank.IStatement, error) {
 	csvFile, err := os.Open(fn)
 	if err != nil {
 		return nil, fmt.Errorf("cannot open %s: %v", fn, err)
 	}
 	defer csvFile.Close()
+	return Parse(csvFile)
+}
 
-	err = nil
-	stmt = bank.NewStatement("Standard Bank")
+//Parse reads a Standard Bank CSV statement and validates that the
+//transactions add up to the closing balance. All problems are
+//returned as bank.ParseErrors with their line numbers.
+func Parse(r io.Reader) (bank.IStatement, error) {
+	stmt := bank.NewStatement("Standard Bank")
+	errs := bank.ParseErrors{}
 	lineNr := 0
-	defer func() {
-		if err != nil {
-			err = fmt.Errorf("line(%d): %v", lineNr, err)
-		}
-	}()
+	closeLineNr := 0
+	fail := func(format string, args ...interface{}) {
+		errs = append(errs, bank.ParseError{Line: lineNr, Message: fmt.Sprintf(format, args...)})
+	}
 
-	csvReader := csv.NewReader(csvFile)
+	csvReader := csv.NewReader(r)
+	csvReader.FieldsPerRecord = -1
 
 	total, _ := bank.NewAmount(0)
-	for {
+	for len(errs) < maxErrors {
 		lineNr++
-		var record []string
-		record, err = csvReader.Read()
+		record, err := csvReader.Read()
 		if err == io.EOF {
-			err = nil
 			break
 		}
 		if err != nil {
-			err = fmt.Errorf("invalid CSV: %v", err)
-			return
+			if csvErr, ok := err.(*csv.ParseError); ok {
+				lineNr = csvErr.Line
+				fail("invalid CSV: %v", csvErr.Err)
+				continue
+			}
+			fail("invalid CSV: %v", err)
+			break
 		}
 
 		switch lineNr {
 		case 1:
 			//Line(     1): [0 2645 BRANCH 0  CENTURION 0 0]
+			if len(record) < 6 || record[2] != "BRANCH" {
+				fail("expects branch code and name")
+				continue
+			}
 			stmt = stmt.WithBranchName(record[5])
 			stmt = stmt.WithBranchCode(record[1])
 			continue
 
 		case 2:
 			//Line(     2): [ 12319791 ACC-NO 0   0 0]
+			if len(record) < 3 || record[2] != "ACC-NO" || record[1] == "" {
+				fail("expects account number")
+				continue
+			}
 			stmt = stmt.WithAccountNumber(record[1])
 			continue
 
 		} //switch(header lines)
 
-		var amount bank.Amount
-		amount, err = bank.NewAmount(record[3])
+		if len(record) < 7 {
+			fail("expects 7 or more columns instead of %d", len(record))
+			continue
+		}
+		amount, err := bank.NewAmount(record[3])
 		if err != nil {
-			err = fmt.Errorf("col[4]=%s is not valid amount: %v", record[3], err)
-			return
+			fail("col[4]=%s is not valid amount: %v", record[3], err)
+			continue
 		}
 
 		//open/closing balances:
@@ -77,6 +115,7 @@ func LoadStatement(fn string) (stmt bank.IStatement, err error) {
 			}
 			if record[2] == "CLOSE" {
 				stmt = stmt.WithClosingBalance(amount)
+				closeLineNr = lineNr
 				continue
 			}
 		}
@@ -85,11 +124,10 @@ func LoadStatement(fn string) (stmt bank.IStatement, err error) {
 		//Line(     3): [HIST 20200928  -211.22 TJEKKAART-AANKOOP Spar Midstrea 5222*7143 23 SEP 6076 0]
 		if record[0] == "HIST" {
 			//date: 20200928 -> 2020-09-28
-			var date time.Time
-			date, err = time.ParseInLocation("20060102", record[1], time.Now().Location())
+			date, err := time.ParseInLocation("20060102", record[1], time.Now().Location())
 			if err != nil {
-				err = fmt.Errorf("invalid date=\"%s\" not CCYYMMDD", record[1])
-				return
+				fail("invalid date=\"%s\" not CCYYMMDD", record[1])
+				continue
 			}
 			stmt = stmt.WithTransaction(bank.NewTransaction(date, amount, record[4], record[5], record[6]))
 			total = total.Add(amount)
@@ -98,9 +136,20 @@ func LoadStatement(fn string) (stmt bank.IStatement, err error) {
 		}
 	}
 
-	err = stmt.Validate()
-	return
-}
+	if len(errs) == 0 && len(stmt.Transactions()) == 0 {
+		errs = append(errs, bank.ParseError{Message: "no transactions in statement"})
+	}
+	if len(errs) == 0 {
+		//the balances must add up, which shows on the closing balance line
+		if err := stmt.Validate(); err != nil {
+			errs = append(errs, bank.ParseError{Line: closeLineNr, Message: err.Error()})
+		}
+	}
+	if len(errs) > 0 {
+		return nil, errs
+	}
+	return stmt, nil
+} //Parse()
 
 /*
 Line(     0): [0 2645 BRANCH 0  CENTURION 0 0]
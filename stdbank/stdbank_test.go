@@ -0,0 +1,64 @@
+package stdbank_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/jansemmelink/money/bank"
+	"github.com/jansemmelink/money/stdbank"
+)
+
+const header = "0,2645,BRANCH,0,,CENTURION,0,0\n" +
+	",12319791,ACC-NO,0,,,0,0\n"
+
+func TestParse(t *testing.T) {
+	data := header +
+		",0,OPEN,1000.00,OPEN BALANCE,,0,0\n" +
+		"HIST,20200928,,-211.22,TJEKKAART-AANKOOP,Spar Midstrea 5222*7143 23 SEP,6076,0\n" +
+		"HIST,20200929,,500,KREDIETOORPLASING,CORNUEX,6088,0\n" +
+		",0,CLOSE,1288.78,CLOSE BALANCE,,0,0\n"
+	if !stdbank.Detect([]byte(data)) {
+		t.Fatalf("not detected")
+	}
+	stmt, err := stdbank.Parse(strings.NewReader(data))
+	if err != nil {
+		t.Fatalf("failed: %+v", err)
+	}
+	if stmt.AccountNumber() != "12319791" || stmt.BranchCode() != "2645" || len(stmt.Transactions()) != 2 {
+		t.Errorf("parsed %s %s %d", stmt.AccountNumber(), stmt.BranchCode(), len(stmt.Transactions()))
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	if stdbank.Detect([]byte("date,amount,details\n")) {
+		t.Errorf("detected other CSV")
+	}
+	data := header +
+		",0,OPEN,1000.00,OPEN BALANCE,,0,0\n" +
+		"HIST,20200928,,-21x.22,TJEKKAART-AANKOOP,Spar,6076,0\n" +
+		"HIST,2020-09-29,,500,KREDIETOORPLASING,CORNUEX,6088,0\n" +
+		"HIST,20200930\n" +
+		",0,CLOSE,1288.78,CLOSE BALANCE,,0,0\n"
+	_, err := stdbank.Parse(strings.NewReader(data))
+	errs, ok := err.(bank.ParseErrors)
+	if !ok {
+		t.Fatalf("not ParseErrors: %+v", err)
+	}
+	lines := []int{}
+	for _, e := range errs {
+		lines = append(lines, e.Line)
+	}
+	if len(lines) != 3 || lines[0] != 4 || lines[1] != 5 || lines[2] != 6 {
+		t.Errorf("errors on lines %v: %v", lines, errs)
+	}
+
+	//balances that do not add up are reported on the closing balance line
+	data = header +
+		",0,OPEN,1000.00,OPEN BALANCE,,0,0\n" +
+		"HIST,20200928,,-211.22,TJEKKAART-AANKOOP,Spar,6076,0\n" +
+		",0,CLOSE,1000.00,CLOSE BALANCE,,0,0\n"
+	_, err = stdbank.Parse(strings.NewReader(data))
+	if errs, ok := err.(bank.ParseErrors); !ok || len(errs) != 1 || errs[0].Line != 5 {
+		t.Errorf("balance error %v", err)
+	}
+}